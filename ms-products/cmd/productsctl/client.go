@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/google/uuid"
+)
+
+// apiClient calls the products HTTP API. It authenticates with the API key
+// when there is one, otherwise with a bearer token issued for the client
+// credentials and renewed shortly before it expires.
+type apiClient struct {
+	baseURL      string
+	http         *http.Client
+	apiKey       string
+	clientID     string
+	clientSecret string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+var _ catalog = (*apiClient)(nil)
+
+func newAPIClient(p profile) *apiClient {
+	return &apiClient{
+		baseURL:      strings.TrimRight(p.URL, "/"),
+		http:         &http.Client{Timeout: time.Minute},
+		apiKey:       p.APIKey,
+		clientID:     p.ClientID,
+		clientSecret: p.ClientSecret,
+	}
+}
+
+// apiError is a problem+json response turned into an error.
+type apiError struct {
+	model.Problem
+	// RetryAfter is how long a rate limited client should wait
+	RetryAfter time.Duration
+}
+
+func (e *apiError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Title)
+	if e.Detail != "" && e.Detail != e.Title {
+		sb.WriteString(": " + e.Detail)
+	}
+	for _, field := range e.Errors {
+		fmt.Fprintf(&sb, "\n  %s: %s", field.Field, field.Message)
+	}
+	for _, permission := range e.MissingPermissions {
+		fmt.Fprintf(&sb, "\n  missing permission %s", permission)
+	}
+
+	return sb.String()
+}
+
+func (c *apiClient) GetProduct(ctx context.Context, productID int32) (*model.Product, error) {
+	var product model.Product
+	err := c.do(ctx, http.MethodGet, "/products/"+strconv.Itoa(int(productID)), nil, nil, &product)
+	return &product, err
+}
+
+func (c *apiClient) ListProducts(ctx context.Context, req model.ListProductsRquest) ([]*model.Product, error) {
+	query := url.Values{
+		"page_id":   []string{strconv.Itoa(int(req.PageID))},
+		"page_size": []string{strconv.Itoa(int(req.PageSize))},
+	}
+
+	var products []*model.Product
+	err := c.do(ctx, http.MethodGet, "/products?"+query.Encode(), nil, nil, &products)
+	return products, err
+}
+
+func (c *apiClient) CreateProduct(ctx context.Context, req model.CreateProductRequest) (*model.Product, error) {
+	var product model.Product
+	err := c.do(ctx, http.MethodPost, "/products", idempotent(), req, &product)
+	return &product, err
+}
+
+func (c *apiClient) UpdateProductStatus(ctx context.Context, req model.UpdateProductStatusRequest) (*model.Product, error) {
+	var product model.Product
+	err := c.do(ctx, http.MethodPatch, "/products", idempotent(), req, &product)
+	return &product, err
+}
+
+func (c *apiClient) InactiveProduct(ctx context.Context, productID int32) error {
+	response, err := c.send(ctx, http.MethodDelete, "/products/"+strconv.Itoa(int(productID)), nil, nil, "")
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+func (c *apiClient) ExportProducts(ctx context.Context, req model.ExportProductsRequest, w io.Writer) error {
+	query := url.Values{"format": []string{req.Format}}
+	if req.Status != "" {
+		query.Set("status", req.Status)
+	}
+	if !req.UpdatedSince.IsZero() {
+		query.Set("updated_since", req.UpdatedSince.UTC().Format(time.RFC3339))
+	}
+
+	response, err := c.send(ctx, http.MethodGet, "/products/export?"+query.Encode(), nil, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(w, response.Body)
+	return err
+}
+
+// CreateImport uploads the file as a multipart form, the way the API takes it.
+func (c *apiClient) CreateImport(ctx context.Context, name string, content io.Reader) (*model.ImportJob, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filepath.Base(name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	response, err := c.send(ctx, http.MethodPost, "/imports", idempotent(), &body, form.FormDataContentType())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var job model.ImportJob
+	return &job, json.NewDecoder(response.Body).Decode(&job)
+}
+
+func (c *apiClient) GetImport(ctx context.Context, jobID int32) (*model.ImportJob, error) {
+	var job model.ImportJob
+	err := c.do(ctx, http.MethodGet, "/imports/"+strconv.Itoa(int(jobID)), nil, nil, &job)
+	return &job, err
+}
+
+// do sends in as JSON, when not nil, and decodes the response into out.
+func (c *apiClient) do(ctx context.Context, method, path string, header http.Header, in any, out any) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	response, err := c.send(ctx, method, path, header, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// send returns the response when its status is 2xx and the problem it carries
+// otherwise.
+func (c *apiClient) send(ctx context.Context, method, path string, header http.Header, body io.Reader, contentType string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	if err := c.authorize(ctx, request); err != nil {
+		return nil, err
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		defer response.Body.Close()
+		return nil, readProblem(response)
+	}
+
+	return response, nil
+}
+
+func (c *apiClient) authorize(ctx context.Context, request *http.Request) error {
+	if c.apiKey != "" {
+		request.Header.Set("X-API-Key", c.apiKey)
+		return nil
+	}
+
+	if c.clientID == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" || time.Until(c.tokenExpiry) < 30*time.Second {
+		token, err := c.issueToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		c.token = token.AccessToken
+		c.tokenExpiry = token.ExpiresAt
+	}
+
+	request.Header.Set("Authorization", model.TokenTypeBearer+" "+c.token)
+	return nil
+}
+
+func (c *apiClient) issueToken(ctx context.Context) (*model.IssueTokenResponse, error) {
+	data, err := json.Marshal(model.IssueTokenRequest{ClientID: c.clientID, ClientSecret: c.clientSecret})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/tokens", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, readProblem(response)
+	}
+
+	var token model.IssueTokenResponse
+	return &token, json.NewDecoder(response.Body).Decode(&token)
+}
+
+func readProblem(response *http.Response) error {
+	var problem model.Problem
+	if err := json.NewDecoder(response.Body).Decode(&problem); err != nil || problem.Title == "" {
+		return fmt.Errorf("unexpected response %s", response.Status)
+	}
+
+	apiErr := &apiError{Problem: problem}
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
+
+// idempotent makes retries of the same invocation safe to replay.
+func idempotent() http.Header {
+	return http.Header{"Idempotency-Key": []string{uuid.NewString()}}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientAuthorization(t *testing.T) {
+	testCases := []struct {
+		name          string
+		profile       profile
+		authorization string
+		apiKey        string
+		tokenRequests int
+	}{
+		{
+			name:    "APIKey",
+			profile: profile{APIKey: "pk_test", ClientID: "order-service", ClientSecret: "secret"},
+			apiKey:  "pk_test",
+		},
+		{
+			name:          "ClientCredentials",
+			profile:       profile{ClientID: "order-service", ClientSecret: "secret"},
+			authorization: "Bearer token-1",
+			tokenRequests: 1,
+		},
+		{
+			name: "Anonymous",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			tokenRequests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/tokens" {
+					tokenRequests++
+					var req model.IssueTokenRequest
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					require.Equal(t, tC.profile.ClientSecret, req.ClientSecret)
+					json.NewEncoder(w).Encode(model.IssueTokenResponse{
+						AccessToken: "token-1",
+						TokenType:   model.TokenTypeBearer,
+						ExpiresAt:   time.Now().Add(time.Hour),
+					})
+					return
+				}
+
+				require.Equal(t, tC.authorization, r.Header.Get("Authorization"))
+				require.Equal(t, tC.apiKey, r.Header.Get("X-API-Key"))
+				json.NewEncoder(w).Encode(model.Product{ID: 1, Name: "Pen"})
+			}))
+			defer server.Close()
+
+			tC.profile.URL = server.URL + "/"
+			client := newAPIClient(tC.profile)
+
+			// when
+			for i := 0; i < 2; i++ {
+				product, err := client.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				require.Equal(t, "Pen", product.Name)
+			}
+
+			// then
+			require.Equal(t, tC.tokenRequests, tokenRequests)
+		})
+	}
+}
+
+func TestClientProblem(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NotEmpty(t, r.Header.Get("Idempotency-Key"))
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(model.Problem{
+			Title:  "Invalid request",
+			Status: http.StatusBadRequest,
+			Errors: []model.FieldProblem{{Field: "price", Rule: "price", Message: "price must be a valid price"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newAPIClient(profile{URL: server.URL})
+
+	// when
+	_, err := client.CreateProduct(context.Background(), model.CreateProductRequest{Name: "Pen", Price: "abc", Description: "Blue"})
+
+	// then
+	var problem *apiError
+	require.ErrorAs(t, err, &problem)
+	require.Equal(t, http.StatusBadRequest, problem.Status)
+	require.Contains(t, err.Error(), "price: price must be a valid price")
+}
+
+func TestClientInactiveProduct(t *testing.T) {
+	// given
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newAPIClient(profile{URL: server.URL})
+
+	// when
+	err := client.InactiveProduct(context.Background(), 42)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, http.MethodDelete, method)
+	require.Equal(t, "/products/42", path)
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(model.Problem{Title: "Too many requests", Status: http.StatusTooManyRequests})
+	}))
+	defer server.Close()
+
+	client := newAPIClient(profile{URL: server.URL})
+
+	// when
+	err := client.ExportProducts(context.Background(), model.ExportProductsRequest{Format: model.ExportFormatJSONL}, io.Discard)
+
+	// then
+	var problem *apiError
+	require.ErrorAs(t, err, &problem)
+	require.Equal(t, http.StatusTooManyRequests, problem.Status)
+	require.Equal(t, 7*time.Second, problem.RetryAfter)
+}
+
+func TestWatcherPoll(t *testing.T) {
+	// given
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	export := &fakeExport{products: []model.Product{
+		{ID: 1, Name: "Pen", UpdatedAt: start.Add(time.Second)},
+		{ID: 2, Name: "Ink", UpdatedAt: start.Add(2 * time.Second)},
+	}}
+	w := &watcher{catalog: export, since: start, seen: make(map[int32]time.Time)}
+
+	// when
+	first, err := w.poll(context.Background())
+	require.NoError(t, err)
+
+	export.products = append(export.products, model.Product{ID: 1, Name: "Pen v2", UpdatedAt: start.Add(3 * time.Second)})
+	second, err := w.poll(context.Background())
+	require.NoError(t, err)
+
+	third, err := w.poll(context.Background())
+	require.NoError(t, err)
+
+	// then
+	require.Len(t, first, 2)
+	require.Len(t, second, 1)
+	require.Equal(t, "Pen v2", second[0].Name)
+	require.Empty(t, third)
+	require.Equal(t, start.Add(3*time.Second), export.since)
+}
+
+func TestPrinter(t *testing.T) {
+	product := &model.Product{ID: 7, Name: "Pen", Price: "1.50", Status: model.ProductStatusAvailable}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{format: outputTable, want: "ID  NAME  PRICE  STATUS"},
+		{format: outputJSON, want: `"name": "Pen"`},
+		{format: outputYAML, want: "price: \"1.50\""},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.format, func(t *testing.T) {
+			// given
+			var out bytes.Buffer
+
+			// when
+			err := printer{w: &out, format: tC.format}.products(product)
+
+			// then
+			require.NoError(t, err)
+			require.Contains(t, out.String(), tC.want)
+		})
+	}
+}
+
+// fakeExport serves the products updated since the requested time, inclusive,
+// like the export query.
+type fakeExport struct {
+	catalog
+	products []model.Product
+	since    time.Time
+}
+
+func (f *fakeExport) ExportProducts(_ context.Context, req model.ExportProductsRequest, w io.Writer) error {
+	f.since = req.UpdatedSince
+	var lines []string
+	for _, product := range f.products {
+		if !product.UpdatedAt.Before(req.UpdatedSince) {
+			data, err := json.Marshal(product)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, string(data))
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
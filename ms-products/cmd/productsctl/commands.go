@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+)
+
+// catalog is the part of service.ProductService productsctl uses, served by
+// the HTTP API or, with --direct, by the service on top of the database.
+type catalog interface {
+	GetProduct(ctx context.Context, productID int32) (*model.Product, error)
+	ListProducts(ctx context.Context, req model.ListProductsRquest) ([]*model.Product, error)
+	CreateProduct(ctx context.Context, req model.CreateProductRequest) (*model.Product, error)
+	UpdateProductStatus(ctx context.Context, req model.UpdateProductStatusRequest) (*model.Product, error)
+	InactiveProduct(ctx context.Context, productID int32) error
+	ExportProducts(ctx context.Context, req model.ExportProductsRequest, w io.Writer) error
+}
+
+var _ catalog = (service.ProductService)(nil)
+
+var productStatuses = []string{model.ProductStatusAvailable, model.ProductStatusOutOfStock, model.ProductStatusInactive}
+
+type cli struct {
+	configPath  string
+	profileName string
+	flags       profile
+	direct      bool
+	output      string
+
+	api     *apiClient
+	catalog catalog
+	pool    *pgxpool.Pool
+}
+
+func newRootCommand() *cobra.Command {
+	c := &cli{}
+
+	root := &cobra.Command{
+		Use:           "productsctl",
+		Short:         "Operate the product catalog",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			return c.setup(cmd)
+		},
+		PersistentPostRunE: func(*cobra.Command, []string) error {
+			if c.pool != nil {
+				c.pool.Close()
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&c.configPath, "config", defaultConfigPath(), "config file with the profiles")
+	flags.StringVarP(&c.profileName, "profile", "p", "", "profile to use, defaults to the current one")
+	flags.StringVar(&c.flags.URL, "url", "", "API base URL")
+	flags.StringVar(&c.flags.APIKey, "api-key", "", "API key")
+	flags.StringVar(&c.flags.ClientID, "client-id", "", "client ID to request tokens with")
+	flags.StringVar(&c.flags.ClientSecret, "client-secret", "", "client secret to request tokens with")
+	flags.BoolVar(&c.direct, "direct", false, "use the database instead of the API")
+	flags.StringVar(&c.flags.DatabaseURL, "database-url", "", "database URL for --direct")
+	flags.StringVarP(&c.output, "output", "o", outputTable, "output format: table, json or yaml")
+
+	root.RegisterFlagCompletionFunc("output", fixedCompletions(outputFormats...))
+	root.RegisterFlagCompletionFunc("profile", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return profileNames(c.configPath), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(c.productsCommand(), c.importsCommand())
+
+	return root
+}
+
+// setup merges the profile with the flags, which take precedence.
+func (c *cli) setup(cmd *cobra.Command) error {
+	// completion and help need neither the API nor the database
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == "help" || cmd.Parent() != nil && cmd.Parent().Name() == "completion" {
+		return nil
+	}
+
+	if !slices.Contains(outputFormats, c.output) {
+		return fmt.Errorf("unknown output %q, use one of %v", c.output, outputFormats)
+	}
+
+	p, err := loadProfile(c.configPath, c.profileName)
+	if err != nil {
+		return err
+	}
+	override(&p.URL, c.flags.URL)
+	override(&p.APIKey, c.flags.APIKey)
+	override(&p.ClientID, c.flags.ClientID)
+	override(&p.ClientSecret, c.flags.ClientSecret)
+	override(&p.DatabaseURL, c.flags.DatabaseURL)
+
+	if !c.direct {
+		if p.URL == "" {
+			return errors.New("no API URL, set url in the profile or pass --url")
+		}
+		c.api = newAPIClient(p)
+		c.catalog = c.api
+		return nil
+	}
+
+	if p.DatabaseURL == "" {
+		return errors.New("no database URL, set database_url in the profile or pass --database-url")
+	}
+
+	c.pool, err = pgxpool.New(context.Background(), p.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("cannot open database: %w", err)
+	}
+
+	c.catalog = service.NewProductService(configs.Config{
+		BulkChunkSize:   100,
+		ExportFetchSize: 500,
+	}, db.NewStore(c.pool))
+
+	return nil
+}
+
+func (c *cli) printer(cmd *cobra.Command) printer {
+	return printer{w: cmd.OutOrStdout(), format: c.output}
+}
+
+func (c *cli) productsCommand() *cobra.Command {
+	products := &cobra.Command{
+		Use:     "products",
+		Aliases: []string{"product"},
+		Short:   "Get, list, create and export products",
+	}
+
+	products.AddCommand(
+		c.getCommand(),
+		c.listCommand(),
+		c.createCommand(),
+		c.statusCommand(),
+		c.exportCommand(),
+		c.watchCommand(),
+	)
+
+	return products
+}
+
+func (c *cli) getCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show a product",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			product, err := c.catalog.GetProduct(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			return c.printer(cmd).products(product)
+		},
+	}
+}
+
+func (c *cli) listCommand() *cobra.Command {
+	var req model.ListProductsRquest
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List a page of products",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			products, err := c.catalog.ListProducts(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return c.printer(cmd).products(products...)
+		},
+	}
+
+	list.Flags().Int32Var(&req.PageID, "page", 1, "page number, from 1")
+	list.Flags().Int32Var(&req.PageSize, "size", 10, "page size, from 5 to 10")
+
+	return list
+}
+
+func (c *cli) createCommand() *cobra.Command {
+	var req model.CreateProductRequest
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a product",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			product, err := c.catalog.CreateProduct(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			return c.printer(cmd).products(product)
+		},
+	}
+
+	create.Flags().StringVar(&req.Name, "name", "", "product name")
+	create.Flags().StringVar(&req.Price, "price", "", "price, e.g. 10.50")
+	create.Flags().StringVar(&req.Description, "description", "", "product description")
+	create.MarkFlagRequired("name")
+	create.MarkFlagRequired("price")
+	create.MarkFlagRequired("description")
+
+	return create
+}
+
+func (c *cli) statusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <id> <status>",
+		Short: "Change the status of a product",
+		Args:  cobra.ExactArgs(2),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return productStatuses, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			if !slices.Contains(productStatuses, args[1]) {
+				return fmt.Errorf("unknown status %q, use one of %v", args[1], productStatuses)
+			}
+
+			// the API only deactivates products through DELETE, which takes
+			// the products:deactivate permission
+			if args[1] == model.ProductStatusInactive {
+				err = c.catalog.InactiveProduct(cmd.Context(), id)
+				if err != nil {
+					return err
+				}
+
+				product, err := c.catalog.GetProduct(cmd.Context(), id)
+				if err != nil {
+					return err
+				}
+
+				return c.printer(cmd).products(product)
+			}
+
+			product, err := c.catalog.UpdateProductStatus(cmd.Context(), model.UpdateProductStatusRequest{
+				ID:     id,
+				Status: args[1],
+			})
+			if err != nil {
+				return err
+			}
+
+			return c.printer(cmd).products(product)
+		},
+	}
+}
+
+func (c *cli) exportCommand() *cobra.Command {
+	var (
+		req          model.ExportProductsRequest
+		updatedSince string
+		file         string
+	)
+
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export products as csv, jsonl or xlsx",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if updatedSince != "" {
+				t, err := time.Parse(time.RFC3339, updatedSince)
+				if err != nil {
+					return fmt.Errorf("--updated-since must be RFC 3339: %w", err)
+				}
+				req.UpdatedSince = t
+			}
+
+			w := cmd.OutOrStdout()
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			return c.catalog.ExportProducts(cmd.Context(), req, w)
+		},
+	}
+
+	export.Flags().StringVar(&req.Format, "format", model.ExportFormatCSV, "csv, jsonl or xlsx")
+	export.Flags().StringVar(&req.Status, "status", "", "only products with this status")
+	export.Flags().StringVar(&updatedSince, "updated-since", "", "only products updated since, RFC 3339")
+	export.Flags().StringVarP(&file, "file", "f", "", "write to a file instead of stdout")
+	export.RegisterFlagCompletionFunc("format", fixedCompletions(model.ExportFormatCSV, model.ExportFormatJSONL, model.ExportFormatXLSX))
+	export.RegisterFlagCompletionFunc("status", fixedCompletions(productStatuses...))
+
+	return export
+}
+
+// watchCommand follows catalog changes. There is no change feed to subscribe
+// to, so it polls the jsonl export with an updated_since watermark.
+func (c *cli) watchCommand() *cobra.Command {
+	var (
+		interval time.Duration
+		since    string
+	)
+
+	watch := &cobra.Command{
+		Use:   "watch",
+		Short: "Print products as they change",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			w := &watcher{catalog: c.catalog, since: time.Now(), seen: make(map[int32]time.Time)}
+			if since != "" {
+				t, err := time.Parse(time.RFC3339, since)
+				if err != nil {
+					return fmt.Errorf("--since must be RFC 3339: %w", err)
+				}
+				w.since = t
+			}
+
+			for {
+				wait := interval
+				changed, err := w.poll(cmd.Context())
+				var problem *apiError
+				switch {
+				case errors.As(err, &problem) && problem.Status == http.StatusTooManyRequests:
+					wait = max(wait, problem.RetryAfter)
+					fmt.Fprintf(cmd.ErrOrStderr(), "rate limited, polling again in %s\n", wait)
+				case err != nil && cmd.Context().Err() == nil:
+					return err
+				}
+				if len(changed) > 0 {
+					if err := c.printer(cmd).products(changed...); err != nil {
+						return err
+					}
+				}
+
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		},
+	}
+
+	// the default RATE_LIMITS allow 10 exports a minute
+	watch.Flags().DurationVar(&interval, "interval", 10*time.Second, "time between polls")
+	watch.Flags().StringVar(&since, "since", "", "also print changes since, RFC 3339, defaults to now")
+
+	return watch
+}
+
+// watcher remembers the newest update it has returned. updated_since is
+// inclusive, so products updated at the watermark are returned again by the
+// next poll and are skipped unless they changed since.
+type watcher struct {
+	catalog catalog
+	since   time.Time
+	seen    map[int32]time.Time
+}
+
+func (w *watcher) poll(ctx context.Context) ([]*model.Product, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(w.catalog.ExportProducts(ctx, model.ExportProductsRequest{
+			Format:       model.ExportFormatJSONL,
+			UpdatedSince: w.since,
+		}, pw))
+	}()
+	defer pr.Close()
+
+	var changed []*model.Product
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var product model.Product
+		if err := json.Unmarshal(scanner.Bytes(), &product); err != nil {
+			return nil, fmt.Errorf("cannot decode export: %w", err)
+		}
+
+		if updatedAt, ok := w.seen[product.ID]; ok && !product.UpdatedAt.After(updatedAt) {
+			continue
+		}
+		w.seen[product.ID] = product.UpdatedAt
+		changed = append(changed, &product)
+
+		if product.UpdatedAt.After(w.since) {
+			w.since = product.UpdatedAt
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for id, updatedAt := range w.seen {
+		if updatedAt.Before(w.since.Truncate(time.Second)) {
+			delete(w.seen, id)
+		}
+	}
+
+	return changed, nil
+}
+
+func (c *cli) importsCommand() *cobra.Command {
+	imports := &cobra.Command{
+		Use:     "imports",
+		Aliases: []string{"import"},
+		Short:   "Upload product imports and follow them",
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := c.setup(cmd); err != nil {
+				return err
+			}
+			if c.api == nil {
+				return errors.New("imports go through the API and cannot be used with --direct")
+			}
+			return nil
+		},
+	}
+
+	imports.AddCommand(c.createImportCommand(), c.getImportCommand())
+
+	return imports
+}
+
+func (c *cli) createImportCommand() *cobra.Command {
+	var (
+		wait     bool
+		interval time.Duration
+	)
+
+	create := &cobra.Command{
+		Use:   "create <file.csv|file.jsonl>",
+		Short: "Upload a csv or jsonl file of products",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return []string{"csv", "jsonl"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			job, err := c.api.CreateImport(cmd.Context(), args[0], f)
+			if err != nil {
+				return err
+			}
+
+			if wait {
+				job, err = c.waitImport(cmd.Context(), job, interval)
+				if err != nil {
+					return err
+				}
+			}
+
+			return c.printer(cmd).importJob(job)
+		},
+	}
+
+	create.Flags().BoolVar(&wait, "wait", false, "wait until the import finishes")
+	create.Flags().DurationVar(&interval, "interval", 2*time.Second, "time between progress checks with --wait")
+
+	return create
+}
+
+func (c *cli) getImportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show the progress of an import",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			job, err := c.api.GetImport(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			return c.printer(cmd).importJob(job)
+		},
+	}
+}
+
+func (c *cli) waitImport(ctx context.Context, job *model.ImportJob, interval time.Duration) (*model.ImportJob, error) {
+	for job.Status == model.ImportStatusPending || job.Status == model.ImportStatusRunning {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var err error
+		job, err = c.api.GetImport(ctx, job.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+func parseID(arg string) (int32, error) {
+	id, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid id %q", arg)
+	}
+
+	return int32(id), nil
+}
+
+func override(value *string, flag string) {
+	if flag != "" {
+		*value = flag
+	}
+}
+
+func fixedCompletions(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,32 @@
+// Command productsctl operates the product catalog from a terminal. It talks
+// to the HTTP API by default, or straight to the database with --direct.
+//
+//	productsctl products list --page 1 --size 10 -o json
+//	productsctl products create --name Pen --price 1.50 --description "Blue pen"
+//	productsctl products status 42 inactive
+//	productsctl products export --format csv > products.csv
+//	productsctl imports create products.csv --wait
+//	productsctl products watch
+//
+// Endpoints and credentials come from named profiles in the config file, see
+// fileConfig, and can be overridden with flags.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "productsctl:", err)
+		stop()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// printer renders command results. Tables only show the columns an operator
+// scans for, JSON and YAML carry every field with the API names.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) products(products ...*model.Product) error {
+	if p.format != outputTable {
+		if len(products) == 1 {
+			return p.structured(products[0])
+		}
+		return p.structured(products)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPRICE\tSTATUS\tUPDATED")
+	for _, product := range products {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", product.ID, product.Name, product.Price, product.Status, product.UpdatedAt.Format(time.RFC3339))
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) importJob(job *model.ImportJob) error {
+	if p.format != outputTable {
+		return p.structured(job)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tPROGRESS\tCREATED\tUPDATED\tREJECTED\tERROR")
+	fmt.Fprintf(tw, "%d\t%s\t%.0f%%\t%d\t%d\t%d\t%s\n", job.ID, job.Status, job.Progress*100, job.CreatedRows, job.UpdatedRows, job.RejectedRows, job.Error)
+
+	return tw.Flush()
+}
+
+func (p printer) structured(v any) error {
+	if p.format == outputJSON {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	// going through JSON keeps the snake_case names of the API
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(p.w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(generic); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,69 @@
+// Command secrets manages the encrypted secrets file read through SECRETS_FILE.
+//
+//	secrets keygen                      prints a new base64 SECRETS_KEY
+//	secrets encrypt < secrets.env > secrets.enc
+//	secrets decrypt < secrets.enc
+//
+// encrypt and decrypt take the key from the SECRETS_KEY environment variable.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/djudju12/ms-products/configs"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: secrets keygen|encrypt|decrypt")
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "secrets:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, in io.Reader, out io.Writer) error {
+	if command == "keygen" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+
+		_, err := fmt.Fprintln(out, base64.StdEncoding.EncodeToString(key))
+		return err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(os.Getenv("SECRETS_KEY"))
+	if err != nil || len(key) != 32 {
+		return errors.New("SECRETS_KEY must be 32 base64 encoded bytes")
+	}
+
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	switch command {
+	case "encrypt":
+		output, err = configs.EncryptSecrets(key, input)
+	case "decrypt":
+		output, err = configs.DecryptSecrets(key, input)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(output)
+	return err
+}
@@ -1,29 +1,337 @@
 package configs
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/djudju12/ms-products/token"
+	"github.com/djudju12/ms-products/tracing"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// profileKey selects the app.<profile>.env file layered over app.env.
+const profileKey = "APP_ENV"
+
+// sampleTokenKey is the placeholder key copied around in examples. Tokens
+// signed with it can be forged by anyone.
+const sampleTokenKey = "12345678901234567890123456789012"
 
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	Profile         string `mapstructure:"APP_ENV"`
+	DBDriver        string `mapstructure:"DB_DRIVER"`
+	DBSource        string `mapstructure:"DB_SOURCE" redact:"url"`
+	DBHost          string `mapstructure:"DB_HOST"`
+	DBPort          string `mapstructure:"DB_PORT"`
+	DBUser          string `mapstructure:"DB_USER"`
+	DBPassword      string `mapstructure:"DB_PASSWORD" redact:"true"`
+	DBName          string `mapstructure:"DB_NAME"`
+	DBSSLMode       string `mapstructure:"DB_SSLMODE"`
+	ServerAddress   string `mapstructure:"SERVER_ADDRESS"`
+	MaxBatchSize    int    `mapstructure:"MAX_BATCH_SIZE"`
+	MaxBulkSize     int    `mapstructure:"MAX_BULK_SIZE"`
+	BulkChunkSize   int    `mapstructure:"BULK_CHUNK_SIZE"`
+	MaxImportSize   int64  `mapstructure:"MAX_IMPORT_SIZE"`
+	ExportFetchSize int32  `mapstructure:"EXPORT_FETCH_SIZE"`
+	LogLevel        string `mapstructure:"LOG_LEVEL"`
+
+	DBMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+
+	DBReplicas             []string      `mapstructure:"DB_REPLICAS" redact:"url"`
+	DBReplicaMaxLag        time.Duration `mapstructure:"DB_REPLICA_MAX_LAG"`
+	DBReplicaCheckInterval time.Duration `mapstructure:"DB_REPLICA_CHECK_INTERVAL"`
+	DBReadYourWritesWindow time.Duration `mapstructure:"DB_READ_YOUR_WRITES_WINDOW"`
+
+	HTTPReadHeaderTimeout time.Duration `mapstructure:"HTTP_READ_HEADER_TIMEOUT"`
+	HTTPReadTimeout       time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout      time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout       time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	TLSCertFile           string        `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile            string        `mapstructure:"TLS_KEY_FILE"`
+	CORSOrigins           []string      `mapstructure:"CORS_ORIGINS"`
+
+	FeatureImports bool `mapstructure:"FEATURE_IMPORTS"`
+	FeatureFeeds   bool `mapstructure:"FEATURE_FEEDS"`
+
+	MigrateOnStart       bool          `mapstructure:"MIGRATE_ON_START"`
+	MigrationLockTimeout time.Duration `mapstructure:"MIGRATION_LOCK_TIMEOUT"`
+
+	HealthCheckTimeout   time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+	StartupAttempts      int           `mapstructure:"STARTUP_ATTEMPTS"`
+	StartupRetryInterval time.Duration `mapstructure:"STARTUP_RETRY_INTERVAL"`
+	ShutdownDelay        time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+	ShutdownTimeout      time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	SecretsFile string `mapstructure:"SECRETS_FILE"`
+	SecretsKey  string `mapstructure:"SECRETS_KEY" redact:"true"`
+
+	TokenType           string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY" redact:"true"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	ServiceAccounts     []string      `mapstructure:"SERVICE_ACCOUNTS" redact:"true"`
+	PolicyFile          string        `mapstructure:"POLICY_FILE"`
+
+	APIKeyRotationOverlap time.Duration `mapstructure:"API_KEY_ROTATION_OVERLAP"`
+
+	IdempotencyKeyTTL time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+
+	RateLimitBackend string   `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimits       []string `mapstructure:"RATE_LIMITS"`
+	TrustedProxies   []string `mapstructure:"TRUSTED_PROXIES"`
+
+	FeedTitle           string        `mapstructure:"FEED_TITLE"`
+	FeedLink            string        `mapstructure:"FEED_LINK"`
+	FeedProductURL      string        `mapstructure:"FEED_PRODUCT_URL"`
+	FeedImageURL        string        `mapstructure:"FEED_IMAGE_URL"`
+	FeedCurrency        string        `mapstructure:"FEED_CURRENCY"`
+	FeedRefreshInterval time.Duration `mapstructure:"FEED_REFRESH_INTERVAL"`
 }
 
-func LoadConfig(path string) (config Config, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName("app")
-	viper.SetConfigType("env")
+// LoadConfig layers, from lowest to highest precedence, app.env, the
+// app.<APP_ENV>.env profile, the encrypted SECRETS_FILE, environment variables,
+// command line flags and <KEY>_FILE secret files. Every setting has a flag
+// named after its key, e.g. --db-max-open-conns for DB_MAX_OPEN_CONNS. Without
+// a DB_SOURCE, one is assembled from DB_HOST, DB_PORT, DB_USER, DB_PASSWORD,
+// DB_NAME and DB_SSLMODE.
+func LoadConfig(path string, args []string) (config Config, err error) {
+	v := viper.New()
+	v.AddConfigPath(path)
+	v.SetConfigName("app")
+	v.SetConfigType("env")
+
+	v.AutomaticEnv()
+
+	err = v.ReadInConfig()
+	if err != nil {
+		return
+	}
+
+	flags := pflag.NewFlagSet("ms-products", pflag.ContinueOnError)
+	keys := make(map[string]string)
+	for _, key := range configKeys() {
+		// binding every key lets environment variables override settings
+		// missing from the files
+		err = v.BindEnv(key)
+		if err != nil {
+			return
+		}
 
-	viper.AutomaticEnv()
+		keys[flagName(key)] = key
+		flags.String(flagName(key), "", "overrides "+key)
+	}
 
-	err = viper.ReadInConfig()
+	err = flags.Parse(args)
 	if err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	flags.Visit(func(f *pflag.Flag) {
+		v.Set(keys[f.Name], f.Value.String())
+	})
+
+	if profile := v.GetString(profileKey); profile != "" {
+		v.SetConfigName("app." + profile)
+		err = v.MergeInConfig()
+		if err != nil {
+			err = fmt.Errorf("cannot read profile %q: %w", profile, err)
+			return
+		}
+	}
+
+	err = readSecretFiles(v)
 	if err != nil {
 		return
 	}
 
+	err = mergeSecretsFile(v)
+	if err != nil {
+		return
+	}
+
+	err = v.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	// a SQLite DB_SOURCE is a file name, there is no server to assemble it from
+	if config.DBSource == "" && config.DBHost != "" && config.DBDriver != "sqlite" {
+		config.DBSource = config.dataSource()
+	}
+
 	return
 }
+
+// Validate reports every invalid setting at once, so a broken deploy can be
+// fixed in a single round.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(slices.Contains([]string{"postgres", "sqlite"}, c.DBDriver), "DB_DRIVER", "must be postgres or sqlite, got %q", c.DBDriver)
+	check(c.DBSource != "", "DB_SOURCE", "is required, or DB_HOST to assemble it")
+	check(c.ServerAddress != "", "SERVER_ADDRESS", "is required")
+	check(c.MaxBatchSize > 0, "MAX_BATCH_SIZE", "must be positive, got %d", c.MaxBatchSize)
+	check(c.MaxBulkSize > 0, "MAX_BULK_SIZE", "must be positive, got %d", c.MaxBulkSize)
+	check(c.BulkChunkSize > 0, "BULK_CHUNK_SIZE", "must be positive, got %d", c.BulkChunkSize)
+	check(c.MaxImportSize > 0, "MAX_IMPORT_SIZE", "must be positive, got %d", c.MaxImportSize)
+	check(c.ExportFetchSize > 0, "EXPORT_FETCH_SIZE", "must be positive, got %d", c.ExportFetchSize)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.LogLevel)) == nil, "LOG_LEVEL", "must be one of debug, info, warn or error, got %q", c.LogLevel)
+
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative, got %d", c.DBMaxOpenConns)
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative, got %d", c.DBMaxIdleConns)
+	check(c.DBMaxOpenConns == 0 || c.DBMaxIdleConns <= c.DBMaxOpenConns, "DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d), got %d", c.DBMaxOpenConns, c.DBMaxIdleConns)
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative, got %s", c.DBConnMaxLifetime)
+	check(c.DBConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME", "must not be negative, got %s", c.DBConnMaxIdleTime)
+	if len(c.DBReplicas) > 0 {
+		check(c.DBDriver == "postgres", "DB_REPLICAS", "need DB_DRIVER postgres, got %q", c.DBDriver)
+		check(!slices.Contains(c.DBReplicas, ""), "DB_REPLICAS", "must not have an empty entry")
+		check(c.DBReplicaMaxLag > 0, "DB_REPLICA_MAX_LAG", "must be positive, got %s", c.DBReplicaMaxLag)
+		check(c.DBReplicaCheckInterval > 0, "DB_REPLICA_CHECK_INTERVAL", "must be positive, got %s", c.DBReplicaCheckInterval)
+		check(c.DBReadYourWritesWindow >= 0, "DB_READ_YOUR_WRITES_WINDOW", "must not be negative, got %s", c.DBReadYourWritesWindow)
+	}
+
+	check(c.HTTPReadHeaderTimeout > 0, "HTTP_READ_HEADER_TIMEOUT", "must be positive, got %s", c.HTTPReadHeaderTimeout)
+	check(c.HTTPReadTimeout >= 0, "HTTP_READ_TIMEOUT", "must not be negative, got %s", c.HTTPReadTimeout)
+	check(c.HTTPWriteTimeout >= 0, "HTTP_WRITE_TIMEOUT", "must not be negative, got %s", c.HTTPWriteTimeout)
+	check(c.HTTPIdleTimeout >= 0, "HTTP_IDLE_TIMEOUT", "must not be negative, got %s", c.HTTPIdleTimeout)
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE", "and TLS_KEY_FILE must be set together")
+	if c.TLSCertFile != "" {
+		_, err := os.Stat(c.TLSCertFile)
+		check(err == nil, "TLS_CERT_FILE", "cannot be read: %v", err)
+	}
+	if c.TLSKeyFile != "" {
+		_, err := os.Stat(c.TLSKeyFile)
+		check(err == nil, "TLS_KEY_FILE", "cannot be read: %v", err)
+	}
+	for _, origin := range c.CORSOrigins {
+		check(validOrigin(origin), "CORS_ORIGINS", "has an invalid origin %q", origin)
+	}
+
+	check(c.MigrationLockTimeout > 0, "MIGRATION_LOCK_TIMEOUT", "must be positive, got %s", c.MigrationLockTimeout)
+	check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT", "must be positive, got %s", c.HealthCheckTimeout)
+	check(c.StartupAttempts > 0, "STARTUP_ATTEMPTS", "must be positive, got %d", c.StartupAttempts)
+	check(c.StartupRetryInterval >= 0, "STARTUP_RETRY_INTERVAL", "must not be negative, got %s", c.StartupRetryInterval)
+	check(c.ShutdownDelay >= 0, "SHUTDOWN_DELAY", "must not be negative, got %s", c.ShutdownDelay)
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT", "must be positive, got %s", c.ShutdownTimeout)
+
+	check(slices.Contains([]string{"", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP}, c.TracingExporter), "TRACING_EXPORTER", "must be one of none, stdout or otlp, got %q", c.TracingExporter)
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %v", c.TracingSampleRatio)
+
+	check(slices.Contains([]string{token.TypeJWT, token.TypePaseto}, c.TokenType), "TOKEN_TYPE", "must be jwt or paseto, got %q", c.TokenType)
+	// PASETO v2 local tokens take a key of exactly 32 bytes, HMAC any longer one
+	if c.TokenType == token.TypePaseto {
+		check(len(c.TokenSymmetricKey) == 32, "TOKEN_SYMMETRIC_KEY", "must have exactly 32 characters with paseto, got %d, set it or TOKEN_SYMMETRIC_KEY_FILE", len(c.TokenSymmetricKey))
+	} else {
+		check(len(c.TokenSymmetricKey) >= 32, "TOKEN_SYMMETRIC_KEY", "must have at least 32 characters, got %d, set it or TOKEN_SYMMETRIC_KEY_FILE", len(c.TokenSymmetricKey))
+	}
+	check(c.TokenSymmetricKey != sampleTokenKey, "TOKEN_SYMMETRIC_KEY", "must not be the sample key")
+	check(c.AccessTokenDuration > 0, "ACCESS_TOKEN_DURATION", "must be positive, got %s", c.AccessTokenDuration)
+	check(c.PolicyFile != "", "POLICY_FILE", "is required")
+	check(c.APIKeyRotationOverlap >= 0, "API_KEY_ROTATION_OVERLAP", "must not be negative, got %s", c.APIKeyRotationOverlap)
+	check(c.IdempotencyKeyTTL > 0, "IDEMPOTENCY_KEY_TTL", "must be positive, got %s", c.IdempotencyKeyTTL)
+	check(slices.Contains([]string{"memory", "postgres"}, c.RateLimitBackend), "RATE_LIMIT_BACKEND", "must be memory or postgres, got %q", c.RateLimitBackend)
+
+	if c.FeatureFeeds {
+		check(c.FeedRefreshInterval > 0, "FEED_REFRESH_INTERVAL", "must be positive, got %s", c.FeedRefreshInterval)
+		check(validIDTemplate(c.FeedProductURL), "FEED_PRODUCT_URL", "must contain exactly one %%d for the product id, got %q", c.FeedProductURL)
+		check(validIDTemplate(c.FeedImageURL), "FEED_IMAGE_URL", "must contain exactly one %%d for the product id, got %q", c.FeedImageURL)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns the settings keyed by their names with secrets masked, ready
+// to be logged.
+func (c Config) Redacted() map[string]any {
+	settings := make(map[string]any)
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("mapstructure")
+
+		var setting any = value.Field(i).Interface()
+		switch field.Tag.Get("redact") {
+		case "true":
+			if !value.Field(i).IsZero() {
+				setting = "REDACTED"
+			}
+		case "url":
+			switch field := setting.(type) {
+			case string:
+				setting = redactURL(field)
+			case []string:
+				redacted := make([]string, 0, len(field))
+				for _, source := range field {
+					redacted = append(redacted, redactURL(source))
+				}
+				setting = redacted
+			}
+		}
+
+		if d, ok := setting.(time.Duration); ok {
+			setting = d.String()
+		}
+		settings[key] = setting
+	}
+
+	return settings
+}
+
+// redactURL masks the password of a URL, or all of a value that is not one,
+// like a key=value data source.
+func redactURL(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+
+	return "REDACTED"
+}
+
+func configKeys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("mapstructure"))
+	}
+
+	return keys
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// validIDTemplate tells whether template formats an id with a single %d and
+// no other verb. %% stays a literal percent sign.
+func validIDTemplate(template string) bool {
+	verbs := strings.ReplaceAll(template, "%%", "")
+	return strings.Count(verbs, "%") == 1 && strings.Count(verbs, "%d") == 1
+}
+
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == ""
+}
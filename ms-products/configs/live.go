@@ -0,0 +1,152 @@
+package configs
+
+import (
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadableKeys are the settings a running process can pick up without a
+// restart. Changes to any other setting are ignored until the next deploy.
+var reloadableKeys = []string{
+	"LOG_LEVEL",
+	"RATE_LIMITS",
+	"FEATURE_IMPORTS",
+	"FEATURE_FEEDS",
+	"CORS_ORIGINS",
+}
+
+// Snapshot is a configuration as it was applied. Version starts at 1 and is
+// bumped by every reload that changes a reloadable setting.
+type Snapshot struct {
+	Config   Config
+	Version  int64
+	LoadedAt time.Time
+}
+
+// Live holds the active configuration and swaps it atomically on reload.
+type Live struct {
+	path       string
+	args       []string
+	validators []func(Config) error
+
+	current atomic.Pointer[Snapshot]
+
+	mu          sync.Mutex
+	subscribers []func(Config)
+}
+
+// NewLive serves config as version 1. validators run, after Validate, against
+// every reloaded configuration before it is applied.
+func NewLive(config Config, validators ...func(Config) error) *Live {
+	l := &Live{validators: validators}
+	l.current.Store(&Snapshot{Config: config, Version: 1, LoadedAt: time.Now()})
+	return l
+}
+
+// Watch reloads the configuration from path and args every time app.env or
+// the active profile file changes.
+func (l *Live) Watch(path string, args []string) {
+	l.path = path
+	l.args = args
+
+	files := []string{"app.env"}
+	if profile := l.Current().Config.Profile; profile != "" {
+		files = append(files, "app."+profile+".env")
+	}
+
+	for _, file := range files {
+		v := viper.New()
+		v.SetConfigFile(filepath.Join(path, file))
+		v.OnConfigChange(func(fsnotify.Event) {
+			if err := l.Reload(); err != nil {
+				slog.Error("configuration reload rejected", "error", err)
+			}
+		})
+		v.WatchConfig()
+	}
+}
+
+func (l *Live) Current() Snapshot {
+	return *l.current.Load()
+}
+
+// Subscribe calls fn with the new configuration after every applied reload.
+func (l *Live) Subscribe(fn func(Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Reload reads the configuration again and applies its reloadable settings. An
+// invalid configuration is rejected and the active one is kept.
+func (l *Live) Reload() error {
+	loaded, err := LoadConfig(l.path, l.args)
+	if err != nil {
+		return err
+	}
+
+	return l.apply(loaded)
+}
+
+func (l *Live) apply(loaded Config) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := l.Current()
+	next, changed, ignored := mergeReloadable(current.Config, loaded)
+	if len(ignored) > 0 {
+		slog.Warn("configuration changes need a restart", "keys", ignored)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	for _, validate := range l.validators {
+		if err := validate(next); err != nil {
+			return err
+		}
+	}
+
+	l.current.Store(&Snapshot{Config: next, Version: current.Version + 1, LoadedAt: time.Now()})
+	slog.Info("configuration reloaded", "version", current.Version+1, "keys", changed)
+	for _, fn := range l.subscribers {
+		fn(next)
+	}
+
+	return nil
+}
+
+// mergeReloadable copies the reloadable settings of loaded over current and
+// reports the keys that changed and the changed keys that were left alone.
+func mergeReloadable(current, loaded Config) (next Config, changed []string, ignored []string) {
+	next = current
+	nextValue := reflect.ValueOf(&next).Elem()
+	loadedValue := reflect.ValueOf(loaded)
+	for i := 0; i < nextValue.NumField(); i++ {
+		key := nextValue.Type().Field(i).Tag.Get("mapstructure")
+		if reflect.DeepEqual(nextValue.Field(i).Interface(), loadedValue.Field(i).Interface()) {
+			continue
+		}
+
+		if !slices.Contains(reloadableKeys, key) {
+			ignored = append(ignored, key)
+			continue
+		}
+
+		nextValue.Field(i).Set(loadedValue.Field(i))
+		changed = append(changed, key)
+	}
+
+	return next, changed, ignored
+}
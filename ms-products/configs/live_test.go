@@ -0,0 +1,138 @@
+package configs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLive serves the repository app.env from a temporary directory so
+// tests can rewrite it.
+func newTestLive(t *testing.T, validators ...func(Config) error) (*Live, func(old, new string)) {
+	base, err := os.ReadFile(filepath.Join("..", "app.env"))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeEnvFile(t, dir, "app.env", string(base))
+	t.Setenv("TOKEN_SYMMETRIC_KEY", testTokenKey)
+
+	config, err := LoadConfig(dir, nil)
+	require.NoError(t, err)
+
+	live := NewLive(config, validators...)
+	live.path = dir
+
+	replace := func(old, new string) {
+		content, err := os.ReadFile(filepath.Join(dir, "app.env"))
+		require.NoError(t, err)
+		require.Contains(t, string(content), old)
+		writeEnvFile(t, dir, "app.env", strings.Replace(string(content), old, new, 1))
+	}
+
+	return live, replace
+}
+
+func TestLiveReload(t *testing.T) {
+	testCases := []struct {
+		name       string
+		old, new   string
+		validators []func(Config) error
+		check      func(t *testing.T, live *Live, err error, notified []Config)
+	}{
+		{
+			name: "Reloadable",
+			old:  "LOG_LEVEL=info",
+			new:  "LOG_LEVEL=debug",
+			check: func(t *testing.T, live *Live, err error, notified []Config) {
+				require.NoError(t, err)
+				require.Equal(t, int64(2), live.Current().Version)
+				require.Equal(t, "debug", live.Current().Config.LogLevel)
+				require.Len(t, notified, 1)
+				require.Equal(t, "debug", notified[0].LogLevel)
+			},
+		},
+		{
+			name: "Needs Restart",
+			old:  "SERVER_ADDRESS=0.0.0.0:8080",
+			new:  "SERVER_ADDRESS=0.0.0.0:9090",
+			check: func(t *testing.T, live *Live, err error, notified []Config) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), live.Current().Version)
+				require.Equal(t, "0.0.0.0:8080", live.Current().Config.ServerAddress)
+				require.Empty(t, notified)
+			},
+		},
+		{
+			name: "Invalid",
+			old:  "LOG_LEVEL=info",
+			new:  "LOG_LEVEL=verbose",
+			check: func(t *testing.T, live *Live, err error, notified []Config) {
+				require.ErrorContains(t, err, "LOG_LEVEL")
+				require.Equal(t, int64(1), live.Current().Version)
+				require.Equal(t, "info", live.Current().Config.LogLevel)
+				require.Empty(t, notified)
+			},
+		},
+		{
+			name: "Rejected By Validator",
+			old:  "FEATURE_FEEDS=true",
+			new:  "FEATURE_FEEDS=false",
+			validators: []func(Config) error{func(c Config) error {
+				if !c.FeatureFeeds {
+					return errors.New("feeds are required")
+				}
+				return nil
+			}},
+			check: func(t *testing.T, live *Live, err error, notified []Config) {
+				require.EqualError(t, err, "feeds are required")
+				require.True(t, live.Current().Config.FeatureFeeds)
+				require.Empty(t, notified)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			live, replace := newTestLive(t, tC.validators...)
+			var notified []Config
+			live.Subscribe(func(c Config) { notified = append(notified, c) })
+			replace(tC.old, tC.new)
+
+			// when
+			err := live.Reload()
+
+			// then
+			tC.check(t, live, err, notified)
+		})
+	}
+}
+
+func TestLiveWatch(t *testing.T) {
+	// given
+	live, replace := newTestLive(t)
+	reloaded := make(chan Config, 1)
+	live.Subscribe(func(c Config) {
+		select {
+		case reloaded <- c:
+		default:
+		}
+	})
+	live.Watch(live.path, nil)
+
+	// when
+	replace("CORS_ORIGINS=", "CORS_ORIGINS=https://shop.example.com")
+
+	// then
+	select {
+	case config := <-reloaded:
+		require.Equal(t, []string{"https://shop.example.com"}, config.CORSOrigins)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+}
@@ -0,0 +1,204 @@
+package configs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// fileSuffix marks the Docker and Kubernetes style indirection where a secret
+// is read from the file the variable points to, e.g. DB_PASSWORD_FILE.
+const fileSuffix = "_FILE"
+
+const secretsKeySize = 32
+
+// secretKeys are the settings tagged for redaction. Only they can be read from
+// files or from the encrypted secrets file.
+func secretKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("redact") != "" {
+			keys = append(keys, t.Field(i).Tag.Get("mapstructure"))
+		}
+	}
+
+	return keys
+}
+
+// readSecretFiles sets every secret whose <KEY>_FILE variable is set to the
+// content of that file. Errors name the variable and file, never the content.
+func readSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys() {
+		if err := v.BindEnv(key + fileSuffix); err != nil {
+			return err
+		}
+
+		path := v.GetString(key + fileSuffix)
+		if path == "" {
+			continue
+		}
+
+		if os.Getenv(key) != "" {
+			return fmt.Errorf("%s and %s%s are both set", key, key, fileSuffix)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read %s%s: %w", key, fileSuffix, err)
+		}
+
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+
+	return nil
+}
+
+// mergeSecretsFile layers the KEY=value lines of the encrypted SECRETS_FILE
+// over the plain files. Keys other than secrets are rejected so the encrypted
+// file cannot silently change regular settings.
+func mergeSecretsFile(v *viper.Viper) error {
+	path := v.GetString("SECRETS_FILE")
+	if path == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(v.GetString("SECRETS_KEY"))
+	if err != nil || len(key) != secretsKeySize {
+		return fmt.Errorf("SECRETS_KEY must be %d base64 encoded bytes", secretsKeySize)
+	}
+
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read SECRETS_FILE: %w", err)
+	}
+
+	plain, err := DecryptSecrets(key, sealed)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt SECRETS_FILE %s: %w", path, err)
+	}
+
+	secrets := viper.New()
+	secrets.SetConfigType("env")
+	if err := secrets.ReadConfig(bytes.NewReader(plain)); err != nil {
+		return fmt.Errorf("cannot parse SECRETS_FILE %s", path)
+	}
+
+	allowed := make(map[string]bool)
+	for _, key := range secretKeys() {
+		allowed[strings.ToLower(key)] = true
+	}
+	for key := range secrets.AllSettings() {
+		if !allowed[key] {
+			return fmt.Errorf("SECRETS_FILE %s has %s, which is not a secret", path, strings.ToUpper(key))
+		}
+	}
+
+	return v.MergeConfigMap(secrets.AllSettings())
+}
+
+// EncryptSecrets seals plain with AES-256-GCM under key. The result is the
+// base64 encoded nonce followed by the ciphertext.
+func EncryptSecrets(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+func DecryptSecrets(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sealed)))
+	if err != nil {
+		return nil, errors.New("secrets are not base64 encoded")
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("secrets are truncated")
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// dataSource assembles a postgres URL from the discrete DB_* settings, escaping
+// the credentials.
+func (c Config) dataSource() string {
+	u := url.URL{
+		Scheme: "postgresql",
+		User:   url.User(c.DBUser),
+		Host:   c.DBHost,
+		Path:   c.DBName,
+	}
+	if c.DBPassword != "" {
+		u.User = url.UserPassword(c.DBUser, c.DBPassword)
+	}
+	if c.DBPort != "" {
+		u.Host = net.JoinHostPort(c.DBHost, c.DBPort)
+	}
+	if c.DBSSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": []string{c.DBSSLMode}}.Encode()
+	}
+
+	return u.String()
+}
+
+// Secrets returns the values of the secret settings, for log scrubbing.
+func (c Config) Secrets() []string {
+	var secrets []string
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).Tag.Get("redact") == "" {
+			continue
+		}
+
+		var values []string
+		switch field := value.Field(i).Interface().(type) {
+		case string:
+			values = []string{field}
+		case []string:
+			values = field
+		}
+
+		for _, v := range values {
+			secrets = append(secrets, v)
+			if u, err := url.Parse(v); err == nil && u.User != nil {
+				password, _ := u.User.Password()
+				secrets = append(secrets, password)
+			}
+		}
+	}
+
+	return secrets
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeaderKey  = "X-API-Key"
+	apiKeyPayloadKey = "api_key"
+)
+
+type APIKeyController interface {
+	createAPIKey(ctx *gin.Context)
+	listAPIKeys(ctx *gin.Context)
+	revokeAPIKey(ctx *gin.Context)
+	rotateAPIKey(ctx *gin.Context)
+	authenticate(ctx *gin.Context)
+}
+
+type apiKeyController struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyController(service service.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		service: service,
+	}
+}
+
+func (kc *apiKeyController) createAPIKey(ctx *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	key, err := kc.service.CreateAPIKey(ctx, req)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+func (kc *apiKeyController) listAPIKeys(ctx *gin.Context) {
+	keys, err := kc.service.ListAPIKeys(ctx)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+func (kc *apiKeyController) revokeAPIKey(ctx *gin.Context) {
+	var req model.GetAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	key, err := kc.service.RevokeAPIKey(ctx, req.ID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, key)
+}
+
+func (kc *apiKeyController) rotateAPIKey(ctx *gin.Context) {
+	var uri model.GetAPIKeyRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	var req model.RotateAPIKeyRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	key, err := kc.service.RotateAPIKey(ctx, uri.ID, req.Overlap)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, key)
+}
+
+// authenticate is the X-API-Key middleware. It stores the key, and with it
+// the key's scopes, in the context under apiKeyPayloadKey.
+func (kc *apiKeyController) authenticate(ctx *gin.Context) {
+	key, err := kc.service.Authenticate(ctx, ctx.GetHeader(apiKeyHeaderKey))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Set(apiKeyPayloadKey, key)
+}
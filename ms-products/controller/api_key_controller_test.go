@@ -0,0 +1,389 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	req := model.CreateAPIKeyRequest{
+		Name:      "nightly-sync",
+		Scopes:    []string{"products:update", "imports:create"},
+		RateLimit: 60,
+	}
+
+	created := &model.CreatedAPIKey{
+		APIKey: model.APIKey{ID: 1, Name: req.Name, Prefix: "msp_abcdefgh", Scopes: req.Scopes, RateLimit: req.RateLimit},
+		Key:    "msp_abcdefghijklmnop",
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		request       any
+		buildStubs    func(service *mockservice.MockAPIKeyService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			role:    "admin",
+			request: req,
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Eq(req)).
+					Times(1).
+					Return(created, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var response model.CreatedAPIKey
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, created.Key, response.Key)
+				require.Equal(t, created.Scopes, response.Scopes)
+			},
+		},
+		{
+			name:    "Unknown Scope",
+			role:    "admin",
+			request: model.CreateAPIKeyRequest{Name: req.Name, Scopes: []string{"products:purge"}},
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Forbidden",
+			role:    "merchandiser",
+			request: req,
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "Internal Server Error",
+			role:    "admin",
+			request: req,
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/api-keys")
+			tC.buildStubs(test.apiKeyService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, toReader(t, tC.request))
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "ops", tC.role, time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	keys := []*model.APIKey{
+		{ID: 1, Name: "nightly-sync", Prefix: "msp_abcdefgh", Scopes: []string{"products:update"}},
+	}
+
+	// given
+	test := NewTest(t, "/api-keys")
+	test.apiKeyService.EXPECT().
+		ListAPIKeys(gomock.Any()).
+		Times(1).
+		Return(keys, nil)
+
+	request, err := http.NewRequest(http.MethodGet, test.url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "ops", "admin", time.Minute)
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, request)
+
+	// then
+	require.Equal(t, http.StatusOK, test.recorder.Code)
+
+	var response []*model.APIKey
+	err = json.Unmarshal(test.recorder.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Equal(t, keys, response)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	testCases := []struct {
+		name          string
+		keyID         int32
+		buildStubs    func(service *mockservice.MockAPIKeyService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			keyID: 1,
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				revokedAt := time.Now()
+				service.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(&model.APIKey{ID: 1, RevokedAt: &revokedAt}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "Not Found",
+			keyID: 1,
+			buildStubs: func(mock *mockservice.MockAPIKeyService) {
+				mock.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(nil, service.NotFound(service.CodeAPIKeyNotFound, "api key not found"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "Bad Request",
+			keyID: 0,
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					RevokeAPIKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, fmt.Sprintf("/api-keys/%d", tC.keyID))
+			tC.buildStubs(test.apiKeyService)
+
+			request, err := http.NewRequest(http.MethodDelete, test.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "ops", "admin", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(service *mockservice.MockAPIKeyService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?overlap=10m",
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				rotatedFrom := int32(1)
+				service.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Eq(int32(1)), gomock.Eq(10*time.Minute)).
+					Times(1).
+					Return(&model.CreatedAPIKey{APIKey: model.APIKey{ID: 2, RotatedFrom: &rotatedFrom}, Key: "msp_new"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:  "Bad Overlap",
+			query: "?overlap=soon",
+			buildStubs: func(service *mockservice.MockAPIKeyService) {
+				service.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Inactive",
+			buildStubs: func(mock *mockservice.MockAPIKeyService) {
+				mock.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Eq(int32(1)), gomock.Eq(time.Duration(0))).
+					Times(1).
+					Return(nil, service.ErrInactiveAPIKey)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Not Found",
+			buildStubs: func(mock *mockservice.MockAPIKeyService) {
+				mock.EXPECT().
+					RotateAPIKey(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.NotFound(service.CodeAPIKeyNotFound, "api key not found"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/api-keys/1/rotate"+tC.query)
+			tC.buildStubs(test.apiKeyService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "ops", "admin", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	const apiKey = "msp_abcdefghijklmnop"
+	job := &model.ImportJob{ID: 1}
+
+	testCases := []struct {
+		name          string
+		requests      int
+		buildStubs    func(test *TestProductController)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			requests: 1,
+			buildStubs: func(test *TestProductController) {
+				test.apiKeyService.EXPECT().
+					Authenticate(gomock.Any(), gomock.Eq(apiKey)).
+					Times(1).
+					Return(&model.APIKey{ID: 1, Scopes: []string{"imports:read"}}, nil)
+
+				test.importService.EXPECT().
+					GetImport(gomock.Any(), gomock.Eq(job.ID)).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Missing Scope",
+			requests: 1,
+			buildStubs: func(test *TestProductController) {
+				test.apiKeyService.EXPECT().
+					Authenticate(gomock.Any(), gomock.Eq(apiKey)).
+					Times(1).
+					Return(&model.APIKey{ID: 1, Prefix: "msp_abcdefgh", Scopes: []string{"products:update"}}, nil)
+
+				test.importService.EXPECT().
+					GetImport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: requireMissingPermissions("imports:read"),
+		},
+		{
+			name:     "Invalid Key",
+			requests: 1,
+			buildStubs: func(test *TestProductController) {
+				test.apiKeyService.EXPECT().
+					Authenticate(gomock.Any(), gomock.Eq(apiKey)).
+					Times(1).
+					Return(nil, service.ErrInvalidAPIKey)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Rate Limited",
+			requests: 3,
+			buildStubs: func(test *TestProductController) {
+				test.apiKeyService.EXPECT().
+					Authenticate(gomock.Any(), gomock.Eq(apiKey)).
+					Times(3).
+					Return(&model.APIKey{ID: 1, Scopes: []string{"imports:read"}, RateLimit: 2}, nil)
+
+				test.importService.EXPECT().
+					GetImport(gomock.Any(), gomock.Eq(job.ID)).
+					Times(2).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/imports/1")
+			tC.buildStubs(test)
+
+			for i := 0; i < tC.requests; i++ {
+				test.recorder = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet, test.url, nil)
+				require.NoError(t, err)
+				request.Header.Set(apiKeyHeaderKey, apiKey)
+
+				// when
+				test.server.router.ServeHTTP(test.recorder, request)
+			}
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
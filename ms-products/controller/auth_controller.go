@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController interface {
+	issueToken(ctx *gin.Context)
+}
+
+type authController struct {
+	service service.AuthService
+}
+
+func NewAuthController(service service.AuthService) AuthController {
+	return &authController{
+		service: service,
+	}
+}
+
+func (ac *authController) issueToken(ctx *gin.Context) {
+	var req model.IssueTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	response, err := ac.service.IssueToken(ctx, req)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIssueToken(t *testing.T) {
+	req := model.IssueTokenRequest{
+		ClientID:     "order-service",
+		ClientSecret: "order-service-secret",
+	}
+
+	res := &model.IssueTokenResponse{
+		AccessToken: "token",
+		TokenType:   model.TokenTypeBearer,
+		ExpiresAt:   time.Now().Add(time.Minute).UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		request       any
+		buildStubs    func(service *mockservice.MockAuthService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			request: req,
+			buildStubs: func(service *mockservice.MockAuthService) {
+				service.EXPECT().
+					IssueToken(gomock.Any(), gomock.Eq(req)).
+					Times(1).
+					Return(res, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response model.IssueTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, *res, response)
+			},
+		},
+		{
+			name:    "Bad Request",
+			request: model.IssueTokenRequest{ClientID: req.ClientID},
+			buildStubs: func(service *mockservice.MockAuthService) {
+				service.EXPECT().
+					IssueToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Unauthorized",
+			request: req,
+			buildStubs: func(mock *mockservice.MockAuthService) {
+				mock.EXPECT().
+					IssueToken(gomock.Any(), gomock.Eq(req)).
+					Times(1).
+					Return(nil, service.ErrInvalidCredentials)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "Internal Server Error",
+			request: req,
+			buildStubs: func(service *mockservice.MockAuthService) {
+				service.EXPECT().
+					IssueToken(gomock.Any(), gomock.Eq(req)).
+					Times(1).
+					Return(nil, errors.New("token error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/tokens")
+			tC.buildStubs(test.authService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, toReader(t, tC.request))
+			require.NoError(t, err)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/model"
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigController interface {
+	getConfig(ctx *gin.Context)
+}
+
+type configController struct {
+	live *configs.Live
+}
+
+func NewConfigController(live *configs.Live) ConfigController {
+	return &configController{
+		live: live,
+	}
+}
+
+// getConfig shows the active configuration, with secrets redacted, and the
+// version it reached through reloads.
+func (cc *configController) getConfig(ctx *gin.Context) {
+	snapshot := cc.live.Current()
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.ConfigSnapshot{
+		Version:  snapshot.Version,
+		LoadedAt: snapshot.LoadedAt,
+		Config:   snapshot.Config.Redacted(),
+	})
+}
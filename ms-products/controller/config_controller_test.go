@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		role   string
+		status int
+	}{
+		{name: "OK", role: "admin", status: http.StatusOK},
+		{name: "Forbidden", role: "support", status: http.StatusForbidden},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/admin/config")
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", tC.role, time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			require.Equal(t, tC.status, test.recorder.Code)
+			if tC.status != http.StatusOK {
+				return
+			}
+
+			var snapshot model.ConfigSnapshot
+			require.NoError(t, json.Unmarshal(test.recorder.Body.Bytes(), &snapshot))
+			require.Equal(t, int64(1), snapshot.Version)
+			require.Equal(t, float64(10), snapshot.Config["MAX_BATCH_SIZE"])
+		})
+	}
+}
+
+func TestFeatureToggleReload(t *testing.T) {
+	// given
+	test := NewTest(t, "/feeds/google.xml")
+	config := test.server.config
+	config.FeatureFeeds = false
+
+	// when
+	test.server.Reload(config)
+
+	request, err := http.NewRequest(http.MethodGet, test.url, nil)
+	require.NoError(t, err)
+	test.server.router.ServeHTTP(test.recorder, request)
+
+	// then
+	require.Equal(t, http.StatusNotFound, test.recorder.Code)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+type FeedController interface {
+	googleFeed(format string) gin.HandlerFunc
+}
+
+type feedController struct {
+	config  configs.Config
+	service service.FeedService
+}
+
+func NewFeedController(config configs.Config, service service.FeedService) FeedController {
+	return &feedController{
+		config:  config,
+		service: service,
+	}
+}
+
+func (fc *feedController) googleFeed(format string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		feed, err := fc.service.GoogleFeed(ctx, format)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		ctx.Header("Content-Type", feed.ContentType)
+		ctx.Header("ETag", feed.ETag)
+		ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(fc.config.FeedRefreshInterval.Seconds())))
+
+		// ServeContent answers If-None-Match and If-Modified-Since with 304
+		http.ServeContent(ctx.Writer, ctx.Request, "", feed.UpdatedAt, bytes.NewReader(feed.Body))
+	}
+}
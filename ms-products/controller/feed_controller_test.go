@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGoogleFeed(t *testing.T) {
+	feed := &model.Feed{
+		ContentType: "application/rss+xml; charset=utf-8",
+		Body:        []byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"></rss>`),
+		ETag:        `"abc"`,
+		UpdatedAt:   time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		header        http.Header
+		buildStubs    func(service *mockservice.MockFeedService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			url:  "/feeds/google.xml",
+			buildStubs: func(service *mockservice.MockFeedService) {
+				service.EXPECT().
+					GoogleFeed(gomock.Any(), gomock.Eq(model.FeedFormatRSS)).
+					Times(1).
+					Return(feed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, feed.ContentType, recorder.Header().Get("Content-Type"))
+				require.Equal(t, feed.ETag, recorder.Header().Get("ETag"))
+				require.Equal(t, "public, max-age=60", recorder.Header().Get("Cache-Control"))
+				require.Equal(t, string(feed.Body), recorder.Body.String())
+			},
+		},
+		{
+			name: "Atom",
+			url:  "/feeds/google.atom",
+			buildStubs: func(service *mockservice.MockFeedService) {
+				service.EXPECT().
+					GoogleFeed(gomock.Any(), gomock.Eq(model.FeedFormatAtom)).
+					Times(1).
+					Return(feed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Not Modified",
+			url:    "/feeds/google.xml",
+			header: http.Header{"If-None-Match": []string{feed.ETag}},
+			buildStubs: func(service *mockservice.MockFeedService) {
+				service.EXPECT().
+					GoogleFeed(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(feed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+				require.Empty(t, recorder.Body.String())
+			},
+		},
+		{
+			name: "Internal Server Error",
+			url:  "/feeds/google.xml",
+			buildStubs: func(service *mockservice.MockFeedService) {
+				service.EXPECT().
+					GoogleFeed(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, tC.url)
+			tC.buildStubs(test.feedService)
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			for key, values := range tC.header {
+				request.Header[key] = values
+			}
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
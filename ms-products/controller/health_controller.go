@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController interface {
+	liveness(ctx *gin.Context)
+	readiness(ctx *gin.Context)
+}
+
+type healthController struct {
+	service service.HealthService
+}
+
+func NewHealthController(service service.HealthService) HealthController {
+	return &healthController{
+		service: service,
+	}
+}
+
+// liveness only tells the process is up and serving, it never touches the
+// dependencies so a database outage does not get the pod restarted.
+func (hc *healthController) liveness(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, model.Health{Status: model.HealthStatusUp})
+}
+
+func (hc *healthController) readiness(ctx *gin.Context) {
+	health := hc.service.Readiness(ctx)
+
+	status := http.StatusOK
+	if !health.Up() {
+		status = http.StatusServiceUnavailable
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, health)
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLiveness(t *testing.T) {
+	// given
+	test := NewTest(t, "/healthz")
+	test.healthService.EXPECT().Readiness(gomock.Any()).Times(0)
+
+	request, err := http.NewRequest(http.MethodGet, test.url, nil)
+	require.NoError(t, err)
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, request)
+
+	// then
+	require.Equal(t, http.StatusOK, test.recorder.Code)
+	require.Equal(t, "no-store", test.recorder.Header().Get("Cache-Control"))
+	requireHealth(t, test.recorder, model.HealthStatusUp)
+}
+
+func TestReadiness(t *testing.T) {
+	testCases := []struct {
+		name          string
+		health        *model.Health
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Ready",
+			health: &model.Health{
+				Status: model.HealthStatusUp,
+				Checks: []model.HealthCheck{
+					{Name: "database", Status: model.HealthStatusUp},
+					{Name: "migrations", Status: model.HealthStatusUp},
+				},
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				health := requireHealth(t, recorder, model.HealthStatusUp)
+				require.Len(t, health.Checks, 2)
+			},
+		},
+		{
+			name: "Not Ready",
+			health: &model.Health{
+				Status: model.HealthStatusDown,
+				Checks: []model.HealthCheck{
+					{Name: "database", Status: model.HealthStatusDown, Detail: "context deadline exceeded"},
+				},
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				health := requireHealth(t, recorder, model.HealthStatusDown)
+				require.Equal(t, "context deadline exceeded", health.Checks[0].Detail)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/readyz")
+			test.healthService.EXPECT().
+				Readiness(gomock.Any()).
+				Times(1).
+				Return(tC.health)
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func requireHealth(t *testing.T, recorder *httptest.ResponseRecorder, status string) model.Health {
+	var health model.Health
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &health))
+	require.Equal(t, status, health.Status)
+	return health
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+
+	// idempotencySettleTimeout bounds storing or releasing a key once the
+	// handler has returned.
+	idempotencySettleTimeout = 5 * time.Second
+)
+
+type IdempotencyController interface {
+	idempotent(handler gin.HandlerFunc) gin.HandlerFunc
+}
+
+type idempotencyController struct {
+	config  configs.Config
+	service service.IdempotencyService
+}
+
+func NewIdempotencyController(config configs.Config, service service.IdempotencyService) IdempotencyController {
+	return &idempotencyController{
+		config:  config,
+		service: service,
+	}
+}
+
+// idempotent wraps the handler of a request sent with an Idempotency-Key
+// header. It must run after authMiddleware since keys are scoped to the
+// caller. The first response for a key is stored and replayed on retries;
+// server errors are not stored so the request can be retried. It calls handler
+// itself rather than ctx.Next, so it also works in the chains customMethods
+// runs.
+func (ic *idempotencyController) idempotent(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			handler(ctx)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			message := fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			abortWithError(ctx, service.NewError(service.KindValidation, service.CodeValidationFailed, message))
+			return
+		}
+
+		requestHash, err := ic.hashRequest(ctx)
+		if err != nil {
+			abortWithError(ctx, bindingError(err))
+			return
+		}
+
+		scope := requestIdentity(ctx)
+		stored, err := ic.service.Begin(ctx, scope, key, requestHash)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		if stored != nil {
+			ctx.Header(idempotencyReplayedHeader, "true")
+			ctx.Data(stored.Status, stored.ContentType, stored.Body)
+			ctx.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		handler(ctx)
+
+		// the handler has run, so the key is settled even if the client hung
+		// up; otherwise it stays in progress until it expires
+		settleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencySettleTimeout)
+		defer cancel()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := ic.service.Release(settleCtx, scope, key); err != nil {
+				slog.ErrorContext(ctx, "cannot release idempotency key", "key", key, "error", err)
+			}
+			return
+		}
+
+		err = ic.service.Complete(settleCtx, scope, key, model.IdempotentResponse{
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "cannot store response for idempotency key", "key", key, "error", err)
+		}
+	}
+}
+
+// hashRequest hashes the method, URI and body, then puts the body back for the
+// handler. At most MaxImportSize bytes are buffered, which is the largest body
+// any handler accepts; the rest is left to the handler to reject.
+func (ic *idempotencyController) hashRequest(ctx *gin.Context) ([]byte, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", ctx.Request.Method, ctx.Request.URL.RequestURI())
+
+	if ctx.Request.Body == nil {
+		return hash.Sum(nil), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, ic.config.MaxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), ctx.Request.Body), ctx.Request.Body}
+
+	hash.Write(body)
+	return hash.Sum(nil), nil
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotentCreateProduct(t *testing.T) {
+	product := RandomProduct()
+	request := model.CreateProductRequest{
+		Name:        product.Name,
+		Price:       product.Price,
+		Description: product.Description,
+	}
+
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	requestHash := sha256.Sum256([]byte(fmt.Sprintf("POST /products\n%s", body)))
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(test *TestProductController)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name: "No Key",
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "First Request",
+			key:  "retry-1",
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("retry-1"), gomock.Eq(requestHash[:])).
+					Times(1).
+					Return(nil, nil)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(product, nil)
+
+				test.idempotencyService.EXPECT().
+					Complete(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("retry-1"), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, _ string, _ string, response model.IdempotentResponse) error {
+						require.Equal(t, http.StatusCreated, response.Status)
+						require.Equal(t, "application/json; charset=utf-8", response.ContentType)
+
+						var stored model.Product
+						require.NoError(t, json.Unmarshal(response.Body, &stored))
+						require.Equal(t, product.ID, stored.ID)
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotencyReplayedHeader))
+				requireMatchProduct(t, recorder.Body, product)
+			},
+		},
+		{
+			name: "Replay",
+			key:  "retry-1",
+			buildStubs: func(test *TestProductController) {
+				stored, err := json.Marshal(product)
+				require.NoError(t, err)
+
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Eq("retry-1"), gomock.Eq(requestHash[:])).
+					Times(1).
+					Return(&model.IdempotentResponse{Status: http.StatusCreated, ContentType: "application/json; charset=utf-8", Body: stored}, nil)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotencyReplayedHeader))
+				requireMatchProduct(t, recorder.Body, product)
+			},
+		},
+		{
+			name: "Reused With Different Body",
+			key:  "retry-1",
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.ErrIdempotencyKeyReused)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "In Progress",
+			key:  "retry-1",
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.ErrIdempotencyKeyInProgress)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Server Error Is Not Stored",
+			key:  "retry-1",
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, nil)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+
+				test.idempotencyService.EXPECT().
+					Complete(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				test.idempotencyService.EXPECT().
+					Release(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("retry-1")).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Key Too Long",
+			key:  strings.Repeat("k", maxIdempotencyKeyLength+1),
+			buildStubs: func(test *TestProductController) {
+				test.idempotencyService.EXPECT().
+					Begin(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/products")
+			tC.buildStubs(test)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, strings.NewReader(string(body)))
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+			if tC.key != "" {
+				request.Header.Set(idempotencyKeyHeader, tC.key)
+			}
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestIdempotentClientGone(t *testing.T) {
+	product := RandomProduct()
+	request := model.CreateProductRequest{
+		Name:        product.Name,
+		Price:       product.Price,
+		Description: product.Description,
+	}
+
+	// given
+	test := NewTest(t, "/products")
+	requestCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	test.idempotencyService.EXPECT().
+		Begin(gomock.Any(), gomock.Any(), gomock.Eq("retry-1"), gomock.Any()).
+		Times(1).
+		Return(nil, nil)
+
+	// the client hangs up while the product is created
+	test.productService.EXPECT().
+		CreateProduct(gomock.Any(), gomock.Eq(request)).
+		Times(1).
+		DoAndReturn(func(_ context.Context, _ model.CreateProductRequest) (*model.Product, error) {
+			cancel()
+			return product, nil
+		})
+
+	var completeErr error
+	var deadline time.Time
+	test.idempotencyService.EXPECT().
+		Complete(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("retry-1"), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, _ string, _ string, _ model.IdempotentResponse) error {
+			completeErr = ctx.Err()
+			deadline, _ = ctx.Deadline()
+			return completeErr
+		})
+
+	httpRequest, err := http.NewRequestWithContext(requestCtx, http.MethodPost, test.url, toReader(t, request))
+	require.NoError(t, err)
+	addAuthorization(t, httpRequest, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+	httpRequest.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, httpRequest)
+
+	// then
+	require.Equal(t, http.StatusCreated, test.recorder.Code)
+	require.Error(t, requestCtx.Err())
+	require.NoError(t, completeErr)
+	require.WithinDuration(t, time.Now().Add(idempotencySettleTimeout), deadline, time.Second)
+}
+
+func TestIdempotentBulkProducts(t *testing.T) {
+	product := RandomProduct()
+	request := model.BulkProductsRequest{
+		Operations: []model.BulkProductOperation{
+			{
+				Operation:   model.BulkOperationCreate,
+				Name:        product.Name,
+				Price:       product.Price,
+				Description: product.Description,
+			},
+		},
+	}
+
+	// given
+	test := NewTest(t, "/products:bulk")
+
+	test.idempotencyService.EXPECT().
+		Begin(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("bulk-1"), gomock.Any()).
+		Times(1).
+		Return(nil, nil)
+
+	test.productService.EXPECT().
+		BulkProducts(gomock.Any(), gomock.Eq(request)).
+		Times(1).
+		Return(&model.BulkProductsResponse{
+			Results: []model.BulkProductResult{
+				{Index: 0, Status: model.BulkStatusCreated, Product: product},
+			},
+		}, nil)
+
+	var stored model.IdempotentResponse
+	test.idempotencyService.EXPECT().
+		Complete(gomock.Any(), gomock.Eq("user:order-service"), gomock.Eq("bulk-1"), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, _ string, _ string, response model.IdempotentResponse) error {
+			stored = response
+			return nil
+		})
+
+	httpRequest, err := http.NewRequest(http.MethodPost, test.url, toReader(t, request))
+	require.NoError(t, err)
+	addAuthorization(t, httpRequest, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+	httpRequest.Header.Set(idempotencyKeyHeader, "bulk-1")
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, httpRequest)
+
+	// then
+	require.Equal(t, http.StatusOK, test.recorder.Code)
+	require.Equal(t, http.StatusOK, stored.Status)
+	require.Equal(t, "application/json; charset=utf-8", stored.ContentType)
+	require.Equal(t, test.recorder.Body.Bytes(), stored.Body)
+
+	var response model.BulkProductsResponse
+	require.NoError(t, json.Unmarshal(stored.Body, &response))
+	require.Len(t, response.Results, 1)
+	require.Equal(t, model.BulkStatusCreated, response.Results[0].Status)
+}
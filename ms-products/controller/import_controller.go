@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ImportController interface {
+	createImport(ctx *gin.Context)
+	getImport(ctx *gin.Context)
+	getImportErrors(ctx *gin.Context)
+}
+
+type importController struct {
+	config  configs.Config
+	service service.ImportService
+}
+
+func NewImportController(config configs.Config, service service.ImportService) ImportController {
+	return &importController{
+		config:  config,
+		service: service,
+	}
+}
+
+func (ic *importController) createImport(ctx *gin.Context) {
+	var req model.CreateImportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		abortWithError(ctx, service.Validation("request has invalid fields", model.FieldProblem{
+			Field:   "file",
+			Rule:    "required",
+			Message: "is required",
+		}))
+		return
+	}
+
+	if header.Size > ic.config.MaxImportSize {
+		message := fmt.Sprintf("file size %d exceeds the maximum of %d bytes", header.Size, ic.config.MaxImportSize)
+		abortWithError(ctx, service.NewError(service.KindTooLarge, service.CodePayloadTooLarge, message))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	defer file.Close()
+
+	payload, err := io.ReadAll(file)
+	if err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	job, err := ic.service.CreateImport(ctx, format, payload)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/imports/%d", job.ID))
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+func (ic *importController) getImport(ctx *gin.Context) {
+	var req model.GetImportRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	job, err := ic.service.GetImport(ctx, req.ID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, job)
+}
+
+func (ic *importController) getImportErrors(ctx *gin.Context) {
+	var req model.GetImportRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	importErrors, err := ic.service.ListImportErrors(ctx, req.ID)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=import-%d-errors.csv", req.ID))
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Write([]string{"line", "message", "raw"})
+	for _, e := range importErrors {
+		writer.Write([]string{strconv.Itoa(int(e.Line)), e.Message, e.Raw})
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateImport(t *testing.T) {
+	payload := "name,price,description\nfoo bar,10.00,some product\n"
+	job := &model.ImportJob{
+		ID:        1,
+		Format:    model.ImportFormatCSV,
+		Status:    model.ImportStatusPending,
+		TotalRows: 1,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		filename      string
+		content       string
+		buildStubs    func(service *mockservice.MockImportService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			filename: "catalog.csv",
+			content:  payload,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					CreateImport(gomock.Any(), gomock.Eq(model.ImportFormatCSV), gomock.Eq([]byte(payload))).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Equal(t, "/imports/1", recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "Format From Query",
+			query:    "?format=jsonl",
+			filename: "catalog.txt",
+			content:  `{"name":"foo"}`,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					CreateImport(gomock.Any(), gomock.Eq(model.ImportFormatJSONL), gomock.Any()).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name:     "Bad Format",
+			query:    "?format=xml",
+			filename: "catalog.xml",
+			content:  payload,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					CreateImport(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Invalid File",
+			filename: "catalog.csv",
+			content:  "foo,bar\n",
+			buildStubs: func(mock *mockservice.MockImportService) {
+				mock.EXPECT().
+					CreateImport(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, service.ErrInvalidImport)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "File Too Large",
+			filename: "catalog.csv",
+			content:  strings.Repeat("a", 2048),
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					CreateImport(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:     "Internal Server Error",
+			filename: "catalog.csv",
+			content:  payload,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					CreateImport(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/imports"+tC.query)
+			tC.buildStubs(test.importService)
+
+			body, contentType := multipartFile(t, tC.filename, tC.content)
+			request, err := http.NewRequest(http.MethodPost, test.url, body)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+			request.Header.Set("Content-Type", contentType)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestGetImport(t *testing.T) {
+	job := &model.ImportJob{
+		ID:            1,
+		Format:        model.ImportFormatCSV,
+		Status:        model.ImportStatusRunning,
+		TotalRows:     10,
+		ProcessedRows: 5,
+		Progress:      0.5,
+	}
+
+	testCases := []struct {
+		name          string
+		jobID         int32
+		buildStubs    func(service *mockservice.MockImportService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			jobID: job.ID,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					GetImport(gomock.Any(), gomock.Eq(job.ID)).
+					Times(1).
+					Return(job, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response model.ImportJob
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, *job, response)
+			},
+		},
+		{
+			name:  "Not Found",
+			jobID: job.ID,
+			buildStubs: func(mock *mockservice.MockImportService) {
+				mock.EXPECT().
+					GetImport(gomock.Any(), gomock.Eq(job.ID)).
+					Times(1).
+					Return(nil, service.NotFound(service.CodeImportNotFound, "import not found"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "Bad Request",
+			jobID: 0,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					GetImport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, fmt.Sprintf("/imports/%d", tC.jobID))
+			tC.buildStubs(test.importService)
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "support", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestGetImportErrors(t *testing.T) {
+	importErrors := []*model.ImportError{
+		{Line: 3, Raw: "foo,abc,bar", Message: "price: failed on price"},
+	}
+
+	testCases := []struct {
+		name          string
+		jobID         int32
+		buildStubs    func(service *mockservice.MockImportService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			jobID: 1,
+			buildStubs: func(service *mockservice.MockImportService) {
+				service.EXPECT().
+					ListImportErrors(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(importErrors, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "line,message,raw\n3,price: failed on price,\"foo,abc,bar\"\n", recorder.Body.String())
+			},
+		},
+		{
+			name:  "Not Found",
+			jobID: 1,
+			buildStubs: func(mock *mockservice.MockImportService) {
+				mock.EXPECT().
+					ListImportErrors(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(nil, service.NotFound(service.CodeImportNotFound, "import not found"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, fmt.Sprintf("/imports/%d/errors", tC.jobID))
+			tC.buildStubs(test.importService)
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "support", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func multipartFile(t *testing.T, filename string, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return body, writer.FormDataContentType()
+}
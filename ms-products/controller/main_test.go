@@ -1,39 +1,116 @@
 package controller
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/djudju12/ms-products/policy"
+	"github.com/djudju12/ms-products/ratelimit"
 	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/djudju12/ms-products/token"
+	"github.com/djudju12/ms-products/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
 type TestProductController struct {
-	ctrl           *gomock.Controller
-	productService *mockservice.MockProductService
-	server         *Server
-	recorder       *httptest.ResponseRecorder
-	url            string
+	ctrl               *gomock.Controller
+	productService     *mockservice.MockProductService
+	importService      *mockservice.MockImportService
+	feedService        *mockservice.MockFeedService
+	authService        *mockservice.MockAuthService
+	apiKeyService      *mockservice.MockAPIKeyService
+	idempotencyService *mockservice.MockIdempotencyService
+	healthService      *mockservice.MockHealthService
+	tokenMaker         token.Maker
+	server             *Server
+	recorder           *httptest.ResponseRecorder
+	url                string
 }
 
 func NewTest(t *testing.T, url string) *TestProductController {
 	ctrl := gomock.NewController(t)
 	productService := mockservice.NewMockProductService(ctrl)
-	productController := New(productService)
-	server := NewServer(productController)
+	importService := mockservice.NewMockImportService(ctrl)
+	feedService := mockservice.NewMockFeedService(ctrl)
+	authService := mockservice.NewMockAuthService(ctrl)
+	apiKeyService := mockservice.NewMockAPIKeyService(ctrl)
+	idempotencyService := mockservice.NewMockIdempotencyService(ctrl)
+	healthService := mockservice.NewMockHealthService(ctrl)
+
+	config := configs.Config{
+		MaxBatchSize:  10,
+		MaxBulkSize:   10,
+		MaxImportSize: 1024,
+
+		FeatureImports: true,
+		FeatureFeeds:   true,
+
+		FeedRefreshInterval: time.Minute,
+	}
+
+	tokenMaker, err := token.NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	accessPolicy, err := policy.Load("../policy.json")
+	require.NoError(t, err)
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limits{
+		ratelimit.DefaultRoute: {Requests: 1000, Period: time.Minute},
+	})
+
+	server := NewServer(config, tokenMaker, accessPolicy, limiter, metrics.New(), Controllers{
+		Product:     New(config, productService),
+		Import:      NewImportController(config, importService),
+		Feed:        NewFeedController(config, feedService),
+		Auth:        NewAuthController(authService),
+		APIKey:      NewAPIKeyController(apiKeyService),
+		Idempotency: NewIdempotencyController(config, idempotencyService),
+		Health:      NewHealthController(healthService),
+		Config:      NewConfigController(configs.NewLive(config)),
+	})
 	recorder := httptest.NewRecorder()
 
 	return &TestProductController{
-		ctrl:           ctrl,
-		productService: productService,
-		server:         server,
-		recorder:       recorder,
-		url:            url,
+		ctrl:               ctrl,
+		productService:     productService,
+		importService:      importService,
+		feedService:        feedService,
+		authService:        authService,
+		apiKeyService:      apiKeyService,
+		idempotencyService: idempotencyService,
+		healthService:      healthService,
+		tokenMaker:         tokenMaker,
+		server:             server,
+		recorder:           recorder,
+		url:                url,
 	}
 }
 
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	subject string,
+	role string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(subject, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
+	request.Header.Set(authorizationHeaderKey, authorizationHeader)
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
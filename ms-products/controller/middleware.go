@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/djudju12/ms-products/logging"
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/policy"
+	"github.com/djudju12/ms-products/ratelimit"
+	"github.com/djudju12/ms-products/replica"
+	"github.com/djudju12/ms-products/service"
+	"github.com/djudju12/ms-products/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader         = "X-Request-ID"
+	maxRequestIDLength      = 128
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+var (
+	corsAllowedMethods = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete}, ", ")
+	corsAllowedHeaders = strings.Join([]string{"Authorization", "Content-Type", apiKeyHeaderKey, idempotencyKeyHeader, requestIDHeader}, ", ")
+	corsExposedHeaders = strings.Join([]string{requestIDHeader, idempotencyReplayedHeader, "ETag", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}, ", ")
+)
+
+// tracingMiddleware must run first. It continues the trace of the W3C
+// traceparent header, or starts a new one, with a server span that covers the
+// whole request and is the parent of the service and query spans.
+func tracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer("github.com/djudju12/ms-products/controller")
+	return func(ctx *gin.Context) {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		spanCtx, span := tracer.Start(parent, ctx.Request.Method+" "+ctx.FullPath(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPMethod(ctx.Request.Method), semconv.HTTPRoute(ctx.FullPath())),
+		)
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// requestLogMiddleware must run right after tracingMiddleware. It reuses the X-Request-ID header sent
+// by the client, or generates one, stores it in the request context for every
+// log written while handling the request, and logs the request when it ends.
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Request = ctx.Request.WithContext(logging.WithRequestID(ctx.Request.Context(), requestID))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		slog.Log(ctx, level, "request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.String("user_agent", ctx.Request.UserAgent()),
+		)
+	}
+}
+
+// validRequestID accepts IDs a client may reasonably send, so a crafted header
+// cannot flood or forge log lines.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// clientMiddleware tells the replica router who sent the request, so that a
+// client reads its own writes. Requests are told apart by IP until
+// authMiddleware verifies their credentials.
+func clientMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		setClient(ctx)
+		ctx.Next()
+	}
+}
+
+// setClient names the client of the request to the replica router the way
+// requestIdentity names it.
+func setClient(ctx *gin.Context) {
+	ctx.Request = ctx.Request.WithContext(replica.WithClient(ctx.Request.Context(), requestIdentity(ctx)))
+}
+
+// authMiddleware rejects requests without a valid bearer token and stores the
+// token payload in the context under authorizationPayloadKey. Requests that
+// send an X-API-Key header are handed to apiKey instead.
+func authMiddleware(tokenMaker token.Maker, apiKey gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader(apiKeyHeaderKey) != "" {
+			apiKey(ctx)
+			if !ctx.IsAborted() {
+				setClient(ctx)
+			}
+			return
+		}
+
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			abortWithError(ctx, service.NewError(service.KindUnauthenticated, service.CodeUnauthenticated, "authorization header is not provided"))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			abortWithError(ctx, service.NewError(service.KindUnauthenticated, service.CodeUnauthenticated, "invalid authorization header format"))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			message := fmt.Sprintf("unsupported authorization type %s", authorizationType)
+			abortWithError(ctx, service.NewError(service.KindUnauthenticated, service.CodeUnauthenticated, message))
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1])
+		if err != nil {
+			abortWithError(ctx, service.NewError(service.KindUnauthenticated, service.CodeInvalidToken, err.Error()))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		setClient(ctx)
+	}
+}
+
+// identifyMiddleware runs auth on the routes open to anonymous clients when
+// the request sends credentials anyway, so that it is rate limited and reads
+// its own writes as the client that made them.
+func identifyMiddleware(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader(authorizationHeaderKey) != "" || ctx.GetHeader(apiKeyHeaderKey) != "" {
+			auth(ctx)
+		}
+	}
+}
+
+// permissionMiddleware must run after authMiddleware. It rejects requests whose
+// role, or API key scopes, lack any of the required permissions.
+func permissionMiddleware(p *policy.Policy, required ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var identity string
+		var missing []string
+		if value, ok := ctx.Get(apiKeyPayloadKey); ok {
+			key := value.(*model.APIKey)
+			identity = fmt.Sprintf("api key %s", key.Prefix)
+			missing = policy.MissingScopes(key.Scopes, required...)
+		} else if value, ok := ctx.Get(authorizationPayloadKey); ok {
+			payload := value.(*token.Payload)
+			identity = fmt.Sprintf("role %q", payload.Role)
+			missing = p.Missing(payload.Role, required...)
+		} else {
+			abortWithError(ctx, service.NewError(service.KindUnauthenticated, service.CodeUnauthenticated, "request is not authenticated"))
+			return
+		}
+
+		if len(missing) > 0 {
+			message := fmt.Sprintf("%s is missing permission %s", identity, strings.Join(missing, ", "))
+			problem := newProblem(ctx, service.NewError(service.KindForbidden, service.CodePermissionDenied, message))
+			problem.MissingPermissions = missing
+			abortWithProblem(ctx, problem)
+		}
+	}
+}
+
+// rateLimitMiddleware must run after authMiddleware on protected routes, so
+// that requests are counted against their API key or token subject rather
+// than their IP. API keys with their own rate limit are also held to it.
+func rateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.Request.Method + " " + routeTemplate(ctx)
+
+		identity := requestIdentity(ctx)
+		var key *model.APIKey
+		if value, ok := ctx.Get(apiKeyPayloadKey); ok {
+			key = value.(*model.APIKey)
+		}
+
+		result, limited, err := limiter.Allow(ctx, route, identity)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limiter unavailable, allowing request", "route", route, "error", err)
+			return
+		}
+
+		if result.Allowed && key != nil && key.RateLimit > 0 {
+			keyLimit := ratelimit.Limit{Requests: int(key.RateLimit), Period: time.Minute}
+			keyResult, err := limiter.Take(ctx, identity, keyLimit)
+			if err != nil {
+				slog.WarnContext(ctx, "rate limiter unavailable, allowing request", "route", route, "error", err)
+				return
+			}
+
+			if !limited || !keyResult.Allowed || keyResult.Remaining < result.Remaining {
+				result, limited = keyResult, true
+			}
+		}
+
+		if !limited {
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			ctx.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			message := fmt.Sprintf("rate limit of %d requests exceeded", result.Limit)
+			abortWithError(ctx, service.NewError(service.KindRateLimited, service.CodeRateLimited, message))
+		}
+	}
+}
+
+// metricsMiddleware records the duration of every request by its route
+// template, so /products/1 and /products/2 share a series.
+func metricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := routeTemplate(ctx)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.ObserveRequest(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+// routeTemplate is the registered path of the request with the custom method
+// filled in, e.g. /products/:id or /products:bulk.
+func routeTemplate(ctx *gin.Context) string {
+	route := ctx.FullPath()
+	if method := ctx.Param("method"); method != "" {
+		route = strings.Replace(route, ":method", method, 1)
+	}
+
+	return route
+}
+
+// corsMiddleware lets browsers on the allowed origins call the API. It answers
+// preflight requests itself, before authentication, since they carry no
+// credentials. origins is read on every request so reloads apply right away.
+func corsMiddleware(origins func() []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+
+		ctx.Writer.Header().Add("Vary", "Origin")
+		allowed := origins()
+		if !slices.Contains(allowed, "*") && !slices.Contains(allowed, origin) {
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Access-Control-Allow-Origin", origin)
+		if ctx.Request.Method != http.MethodOptions || ctx.GetHeader("Access-Control-Request-Method") == "" {
+			ctx.Header("Access-Control-Expose-Headers", corsExposedHeaders)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+		ctx.Header("Access-Control-Allow-Headers", corsAllowedHeaders)
+		ctx.Header("Access-Control-Max-Age", "600")
+		ctx.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// featureMiddleware answers like an unknown route while enabled reports the
+// feature as off.
+func featureMiddleware(enabled func() bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !enabled() {
+			ctx.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requestIdentity names who sent the request: the API key or token subject
+// set by authMiddleware, or the client IP when there is neither.
+func requestIdentity(ctx *gin.Context) string {
+	if value, ok := ctx.Get(apiKeyPayloadKey); ok {
+		return fmt.Sprintf("apikey:%d", value.(*model.APIKey).ID)
+	}
+
+	if value, ok := ctx.Get(authorizationPayloadKey); ok {
+		return "user:" + value.(*token.Payload).Subject
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,563 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/logging"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/policy"
+	"github.com/djudju12/ms-products/ratelimit"
+	"github.com/djudju12/ms-products/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "No Authorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Unsupported Authorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", "order-service", "admin", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Authorization Format",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, "", "order-service", "admin", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Expired Token",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "order-service", "admin", -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Tampered Token",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+				request.Header.Set(authorizationHeaderKey, request.Header.Get(authorizationHeaderKey)+"x")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/auth")
+			test.server.router.GET(test.url, authMiddleware(test.tokenMaker, test.server.controllers.APIKey.authenticate), func(ctx *gin.Context) {
+				payload, ok := ctx.Get(authorizationPayloadKey)
+				require.True(t, ok)
+				require.Equal(t, "order-service", payload.(*token.Payload).Subject)
+				ctx.Status(http.StatusOK)
+			})
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			tC.setupAuth(t, request, test.tokenMaker)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestCustomMethodAuthorization(t *testing.T) {
+	// given
+	test := NewTest(t, "/products:bulk")
+	request, err := http.NewRequest(http.MethodPost, test.url, nil)
+	require.NoError(t, err)
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, request)
+
+	// then
+	require.Equal(t, http.StatusUnauthorized, test.recorder.Code)
+}
+
+func TestPermissionMiddleware(t *testing.T) {
+	product := RandomProduct()
+
+	testCases := []struct {
+		name          string
+		role          string
+		method        string
+		url           string
+		body          any
+		buildStubs    func(test *TestProductController)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Merchandiser Creates",
+			role:   "merchandiser",
+			method: http.MethodPost,
+			url:    "/products",
+			body:   model.CreateProductRequest{Name: product.Name, Price: product.Price, Description: product.Description},
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:   "Support Cannot Create",
+			role:   "support",
+			method: http.MethodPost,
+			url:    "/products",
+			body:   model.CreateProductRequest{Name: product.Name, Price: product.Price, Description: product.Description},
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: requireMissingPermissions(policy.ProductsCreate),
+		},
+		{
+			name:   "Merchandiser Reprices",
+			role:   "merchandiser",
+			method: http.MethodPatch,
+			url:    "/products",
+			body:   model.UpdateProductStatusRequest{ID: product.ID, Status: model.ProductStatusOutOfStock},
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					UpdateProductStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Merchandiser Cannot Deactivate",
+			role:   "merchandiser",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/products/%d", product.ID),
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					InactiveProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: requireMissingPermissions(policy.ProductsDeactivate),
+		},
+		{
+			name:   "Admin Deactivates",
+			role:   "admin",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/products/%d", product.ID),
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					InactiveProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Support Reads Imports",
+			role:   "support",
+			method: http.MethodGet,
+			url:    "/imports/1",
+			buildStubs: func(test *TestProductController) {
+				test.importService.EXPECT().
+					GetImport(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(&model.ImportJob{ID: 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Support Cannot Bulk",
+			role:   "support",
+			method: http.MethodPost,
+			url:    "/products:bulk",
+			body:   model.BulkProductsRequest{},
+			buildStubs: func(test *TestProductController) {
+				test.productService.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: requireMissingPermissions(policy.ProductsCreate, policy.ProductsUpdate),
+		},
+		{
+			name:   "Unknown Role",
+			role:   "guest",
+			method: http.MethodGet,
+			url:    "/imports/1",
+			buildStubs: func(test *TestProductController) {
+				test.importService.EXPECT().
+					GetImport(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: requireMissingPermissions(policy.ImportsRead),
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, tC.url)
+			tC.buildStubs(test)
+
+			var body io.Reader
+			if tC.body != nil {
+				body = toReader(t, tC.body)
+			}
+
+			request, err := http.NewRequest(tC.method, test.url, body)
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", tC.role, time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func requireMissingPermissions(permissions ...string) func(t *testing.T, recorder *httptest.ResponseRecorder) {
+	return func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+
+		var response struct {
+			MissingPermissions []string `json:"missing_permissions"`
+		}
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, permissions, response.MissingPermissions)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	type call struct {
+		remoteAddr string
+		subject    string
+		code       int
+	}
+
+	testCases := []struct {
+		name  string
+		calls []call
+		check func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "By IP",
+			calls: []call{
+				{remoteAddr: "10.0.0.1:1234", code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", code: http.StatusOK},
+				{remoteAddr: "10.0.0.2:1234", code: http.StatusOK},
+				{remoteAddr: "10.0.0.1:1234", code: http.StatusTooManyRequests},
+			},
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+				require.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+				require.Equal(t, "60", recorder.Header().Get("RateLimit-Reset"))
+				require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name: "By Subject",
+			calls: []call{
+				{remoteAddr: "10.0.0.1:1234", subject: "order-service", code: http.StatusOK},
+				{remoteAddr: "10.0.0.2:1234", subject: "order-service", code: http.StatusOK},
+				{remoteAddr: "10.0.0.3:1234", subject: "order-service", code: http.StatusTooManyRequests},
+				{remoteAddr: "10.0.0.3:1234", subject: "nightly-sync", code: http.StatusOK},
+				{remoteAddr: "10.0.0.3:1234", code: http.StatusOK},
+			},
+			check: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, "1", recorder.Header().Get("RateLimit-Remaining"))
+				require.Empty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/limited")
+			limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limits{
+				"GET /limited": {Requests: 2, Period: time.Minute},
+			})
+			identify := identifyMiddleware(authMiddleware(test.tokenMaker, nil))
+			test.server.router.GET(test.url, identify, rateLimitMiddleware(limiter), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			for i, c := range tC.calls {
+				test.recorder = httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodGet, test.url, nil)
+				require.NoError(t, err)
+				request.RemoteAddr = c.remoteAddr
+				if c.subject != "" {
+					addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, c.subject, "support", time.Minute)
+				}
+
+				// when
+				test.server.router.ServeHTTP(test.recorder, request)
+
+				// then
+				require.Equal(t, c.code, test.recorder.Code, "call %d", i)
+			}
+
+			tC.check(t, test.recorder)
+		})
+	}
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	testCases := []struct {
+		name           string
+		requestID      string
+		checkRequestID func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "Reuses Client ID",
+			requestID: "order-42.retry_1",
+			checkRequestID: func(t *testing.T, requestID string) {
+				require.Equal(t, "order-42.retry_1", requestID)
+			},
+		},
+		{
+			name: "Generates Missing ID",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "Replaces Invalid ID",
+			requestID: "forged\nlog line",
+			checkRequestID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/products/1")
+
+			var serviceRequestID string
+			test.productService.EXPECT().
+				GetProduct(gomock.Any(), gomock.Eq(int32(1))).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, id int32) (*model.Product, error) {
+					serviceRequestID = logging.RequestID(ctx)
+					return &model.Product{ID: id}, nil
+				})
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			if tC.requestID != "" {
+				request.Header.Set(requestIDHeader, tC.requestID)
+			}
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			require.Equal(t, http.StatusOK, test.recorder.Code)
+			requestID := test.recorder.Header().Get(requestIDHeader)
+			tC.checkRequestID(t, requestID)
+			require.Equal(t, requestID, serviceRequestID)
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	// given
+	test := NewTest(t, "/products/1")
+	test.productService.EXPECT().
+		GetProduct(gomock.Any(), gomock.Eq(int32(1))).
+		Times(1).
+		Return(&model.Product{ID: 1}, nil)
+
+	request, err := http.NewRequest(http.MethodGet, test.url, nil)
+	require.NoError(t, err)
+	test.server.router.ServeHTTP(test.recorder, request)
+	require.Equal(t, http.StatusOK, test.recorder.Code)
+
+	// when
+	recorder := httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	test.server.router.ServeHTTP(recorder, request)
+
+	// then
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `ms_products_http_request_duration_seconds_count{method="GET",route="/products/:id",status="200"} 1`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	// given
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	test := NewTest(t, "/products/1")
+	test.productService.EXPECT().
+		GetProduct(gomock.Any(), gomock.Eq(int32(1))).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int32) (*model.Product, error) {
+			require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			return &model.Product{ID: id}, nil
+		})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	request, err := http.NewRequest(http.MethodGet, test.url, nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	// when
+	test.server.router.ServeHTTP(test.recorder, request)
+
+	// then
+	require.Equal(t, http.StatusOK, test.recorder.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /products/:id", spans[0].Name())
+	require.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.Contains(t, spans[0].Attributes(), semconv.HTTPStatusCode(http.StatusOK))
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		header        http.Header
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Allowed Origin",
+			method: http.MethodGet,
+			header: http.Header{"Origin": []string{"https://shop.example.com"}},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "https://shop.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+				require.Contains(t, recorder.Header().Get("Access-Control-Expose-Headers"), requestIDHeader)
+				require.Equal(t, "Origin", recorder.Header().Get("Vary"))
+			},
+		},
+		{
+			name:   "Preflight",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        []string{"https://shop.example.com"},
+				"Access-Control-Request-Method": []string{http.MethodPost},
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				require.Equal(t, "https://shop.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+				require.Contains(t, recorder.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+				require.Contains(t, recorder.Header().Get("Access-Control-Allow-Headers"), idempotencyKeyHeader)
+			},
+		},
+		{
+			name:   "Disallowed Origin",
+			method: http.MethodGet,
+			header: http.Header{"Origin": []string{"https://evil.example.com"}},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+			},
+		},
+		{
+			name:   "Same Origin",
+			method: http.MethodGet,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Vary"))
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			router := gin.New()
+			router.Use(corsMiddleware(func() []string { return []string{"https://shop.example.com"} }))
+			router.GET("/cors", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tC.method, "/cors", nil)
+			require.NoError(t, err)
+			for key, values := range tC.header {
+				request.Header[key] = values
+			}
+
+			// when
+			router.ServeHTTP(recorder, request)
+
+			// then
+			tC.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:ms-products:problem:"
+)
+
+var problemStatus = map[service.ErrorKind]int{
+	service.KindInternal:        http.StatusInternalServerError,
+	service.KindValidation:      http.StatusBadRequest,
+	service.KindUnauthenticated: http.StatusUnauthorized,
+	service.KindForbidden:       http.StatusForbidden,
+	service.KindNotFound:        http.StatusNotFound,
+	service.KindConflict:        http.StatusConflict,
+	service.KindTooLarge:        http.StatusRequestEntityTooLarge,
+	service.KindUnprocessable:   http.StatusUnprocessableEntity,
+	service.KindRateLimited:     http.StatusTooManyRequests,
+}
+
+// abortWithError ends the request with the problem document for err.
+func abortWithError(ctx *gin.Context, err error) {
+	abortWithProblem(ctx, newProblem(ctx, err))
+}
+
+func abortWithProblem(ctx *gin.Context, problem model.Problem) {
+	ctx.Header("Content-Type", problemContentType)
+	ctx.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// newProblem describes err to the client. Only a *service.Error is shown as
+// is; anything else is logged and reported as an internal error, so database
+// and other internal messages never reach the response.
+func newProblem(ctx *gin.Context, err error) model.Problem {
+	var serviceErr *service.Error
+	detail := err.Error()
+	if !errors.As(err, &serviceErr) {
+		slog.ErrorContext(ctx, "request failed", "error", err)
+		serviceErr = service.NewError(service.KindInternal, service.CodeInternal, "internal server error")
+		detail = serviceErr.Message
+	}
+
+	status := problemStatus[serviceErr.Kind]
+	return model.Problem{
+		Type:     problemTypePrefix + serviceErr.Code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+		Code:     serviceErr.Code,
+		Errors:   serviceErr.Fields,
+	}
+}
+
+// bindingError turns an error from the ShouldBind methods into a validation
+// error listing each invalid field.
+func bindingError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return service.Validation("request has invalid fields", model.FieldProblems(validationErrors)...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return service.Validation("request has invalid fields", model.FieldProblem{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + jsonType(typeErr.Type.Kind()),
+		})
+	}
+
+	malformed := service.NewError(service.KindValidation, service.CodeMalformedRequest, "request could not be parsed")
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		malformed.Message = "request body is empty"
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		malformed.Message = "request body is not valid json"
+	}
+
+	malformed.Err = err
+	return malformed
+}
+
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+
+	return "a " + kind.String()
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
+	mockservice "github.com/djudju12/ms-products/service/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProblemResponse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		buildStubs    func(mock *mockservice.MockProductService)
+		checkResponse func(t *testing.T, problem model.Problem)
+	}{
+		{
+			name: "Invalid Fields",
+			body: `{"name":"foo","price":"10.123"}`,
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, problem model.Problem) {
+				require.Equal(t, http.StatusBadRequest, problem.Status)
+				require.Equal(t, service.CodeValidationFailed, problem.Code)
+				require.Equal(t, "urn:ms-products:problem:validation_failed", problem.Type)
+				require.ElementsMatch(t, []model.FieldProblem{
+					{Field: "price", Rule: "price", Message: "must be a decimal with up to 10 digits and 2 decimal places"},
+					{Field: "description", Rule: "required", Message: "is required"},
+				}, problem.Errors)
+			},
+		},
+		{
+			name: "Wrong Type",
+			body: `{"name":"foo","price":10,"description":"bar"}`,
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, problem model.Problem) {
+				require.Equal(t, http.StatusBadRequest, problem.Status)
+				require.Equal(t, []model.FieldProblem{{Field: "price", Rule: "type", Message: "must be a string"}}, problem.Errors)
+			},
+		},
+		{
+			name: "Malformed",
+			body: `{"name":`,
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, problem model.Problem) {
+				require.Equal(t, http.StatusBadRequest, problem.Status)
+				require.Equal(t, service.CodeMalformedRequest, problem.Code)
+				require.Equal(t, "request body is not valid json", problem.Detail)
+			},
+		},
+		{
+			name: "Conflict",
+			body: `{"name":"foo","price":"10.00","description":"bar"}`,
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &service.Error{
+						Kind:    service.KindConflict,
+						Code:    service.CodeAlreadyExists,
+						Message: "product already exists",
+						Fields:  []model.FieldProblem{{Field: "name", Rule: "unique", Message: "is already taken"}},
+					})
+			},
+			checkResponse: func(t *testing.T, problem model.Problem) {
+				require.Equal(t, http.StatusConflict, problem.Status)
+				require.Equal(t, "Conflict", problem.Title)
+				require.Equal(t, service.CodeAlreadyExists, problem.Code)
+				require.Equal(t, "product already exists", problem.Detail)
+				require.Len(t, problem.Errors, 1)
+			},
+		},
+		{
+			name: "Internal Error Is Hidden",
+			body: `{"name":"foo","price":"10.00","description":"bar"}`,
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, problem model.Problem) {
+				require.Equal(t, http.StatusInternalServerError, problem.Status)
+				require.Equal(t, service.CodeInternal, problem.Code)
+				require.Equal(t, "internal server error", problem.Detail)
+				require.NotContains(t, problem.Detail, sql.ErrConnDone.Error())
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/products")
+			tC.buildStubs(test.productService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, strings.NewReader(tC.body))
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			problem := requireProblem(t, test.recorder)
+			require.Equal(t, "/products", problem.Instance)
+			tC.checkResponse(t, problem)
+		})
+	}
+}
+
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder) model.Problem {
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+
+	var problem model.Problem
+	err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Equal(t, recorder.Code, problem.Status)
+
+	return problem
+}
@@ -1,9 +1,14 @@
 package controller
 
 import (
-	"database/sql"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/djudju12/ms-products/configs"
 	"github.com/djudju12/ms-products/model"
 	"github.com/djudju12/ms-products/service"
 	"github.com/gin-gonic/gin"
@@ -11,18 +16,23 @@ import (
 
 type ProductController interface {
 	getProduct(ctx *gin.Context)
+	batchGetProducts(ctx *gin.Context)
 	createProduct(ctx *gin.Context)
 	listProducts(ctx *gin.Context)
 	inactiveProduct(ctx *gin.Context)
 	updateProductStatus(ctx *gin.Context)
+	bulkProducts(ctx *gin.Context)
+	exportProducts(ctx *gin.Context)
 }
 
 type productController struct {
+	config  configs.Config
 	service service.ProductService
 }
 
-func New(service service.ProductService) ProductController {
+func New(config configs.Config, service service.ProductService) ProductController {
 	return &productController{
+		config:  config,
 		service: service,
 	}
 }
@@ -30,54 +40,70 @@ func New(service service.ProductService) ProductController {
 func (pc *productController) getProduct(ctx *gin.Context) {
 	var req model.GetProductRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	p, err := pc.service.GetProduct(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, p)
 }
 
+func (pc *productController) batchGetProducts(ctx *gin.Context) {
+	var req model.BatchGetProductsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	if len(req.IDs) > pc.config.MaxBatchSize {
+		abortWithError(ctx, service.Validation("batch is too large", model.FieldProblem{
+			Field:   "ids",
+			Rule:    "max",
+			Message: fmt.Sprintf("must have at most %d items", pc.config.MaxBatchSize),
+		}))
+		return
+	}
+
+	result, err := pc.service.GetProductsByIDs(ctx, req)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
 func (pc *productController) createProduct(ctx *gin.Context) {
 	var req model.CreateProductRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	product, err := pc.service.CreateProduct(ctx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, product)
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
-}
-
 func (pc *productController) listProducts(ctx *gin.Context) {
 	var req model.ListProductsRquest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	products, err := pc.service.ListProducts(ctx, req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 
@@ -87,18 +113,13 @@ func (pc *productController) listProducts(ctx *gin.Context) {
 func (pc *productController) inactiveProduct(ctx *gin.Context) {
 	var req model.DeleteProductRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	err := pc.service.InactiveProduct(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 
@@ -108,20 +129,90 @@ func (pc *productController) inactiveProduct(ctx *gin.Context) {
 func (pc *productController) updateProductStatus(ctx *gin.Context) {
 	var req model.UpdateProductStatusRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	product, err := pc.service.UpdateProductStatus(ctx, req)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, product)
 }
+
+func (pc *productController) bulkProducts(ctx *gin.Context) {
+	var req model.BulkProductsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	if len(req.Operations) > pc.config.MaxBulkSize {
+		abortWithError(ctx, service.Validation("bulk is too large", model.FieldProblem{
+			Field:   "operations",
+			Rule:    "max",
+			Message: fmt.Sprintf("must have at most %d items", pc.config.MaxBulkSize),
+		}))
+		return
+	}
+
+	result, err := pc.service.BulkProducts(ctx, req)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+var exportContentTypes = map[string]string{
+	model.ExportFormatCSV:   "text/csv",
+	model.ExportFormatJSONL: "application/x-ndjson",
+	model.ExportFormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func (pc *productController) exportProducts(ctx *gin.Context) {
+	var req model.ExportProductsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	ctx.Header("Content-Type", exportContentTypes[req.Format])
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=products.%s", req.Format))
+
+	var w io.Writer = ctx.Writer
+	var gz *gzip.Writer
+	if strings.Contains(ctx.GetHeader("Accept-Encoding"), "gzip") {
+		ctx.Header("Content-Encoding", "gzip")
+		ctx.Header("Vary", "Accept-Encoding")
+
+		gz = gzip.NewWriter(ctx.Writer)
+		w = gz
+	}
+
+	err := pc.service.ExportProducts(ctx, req, w)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+
+	if err != nil {
+		// once the body has started the status can no longer change, so the
+		// truncated stream is the only signal left to the client
+		if ctx.Writer.Written() {
+			slog.ErrorContext(ctx, "export aborted", "error", err)
+			return
+		}
+
+		ctx.Header("Content-Encoding", "")
+		ctx.Header("Content-Disposition", "")
+		abortWithError(ctx, err)
+	}
+}
@@ -2,6 +2,8 @@ package controller
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,8 +11,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/service"
 	mockservice "github.com/djudju12/ms-products/service/mock"
 	"github.com/djudju12/ms-products/utils"
 	"github.com/stretchr/testify/require"
@@ -43,11 +47,11 @@ func TestGetProduct(t *testing.T) {
 		{
 			name:      "Not Found",
 			productID: product.ID,
-			buildStubs: func(service *mockservice.MockProductService) {
-				service.EXPECT().
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().
 					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
 					Times(1).
-					Return(&model.Product{}, sql.ErrNoRows)
+					Return(nil, service.NotFound(service.CodeProductNotFound, "product not found"))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -98,6 +102,121 @@ func TestGetProduct(t *testing.T) {
 	}
 }
 
+func TestBatchGetProducts(t *testing.T) {
+	product := RandomProduct()
+	missingID := product.ID + 1
+	request := model.BatchGetProductsRequest{
+		IDs: []int32{product.ID, missingID},
+	}
+
+	tooLarge := model.BatchGetProductsRequest{}
+	for i := int32(1); i <= 11; i++ {
+		tooLarge.IDs = append(tooLarge.IDs, i)
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		request       model.BatchGetProductsRequest
+		buildStubs    func(service *mockservice.MockProductService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			url:     "/products:batchGet",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(&model.BatchGetProductsResponse{
+						Products: []*model.Product{product},
+						Missing:  []int32{missingID},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response model.BatchGetProductsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Equal(t, []*model.Product{product}, response.Products)
+				require.Equal(t, []int32{missingID}, response.Missing)
+			},
+		},
+		{
+			name:    "Bad Request",
+			url:     "/products:batchGet",
+			request: model.BatchGetProductsRequest{IDs: []int32{0}},
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Batch Too Large",
+			url:     "/products:batchGet",
+			request: tooLarge,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Unknown Method",
+			url:     "/products:batchDelete",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "Internal Server Error",
+			url:     "/products:batchGet",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, tC.url)
+			tC.buildStubs(test.productService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, toReader(t, tC.request))
+			require.NoError(t, err)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
 func TestCreateProduct(t *testing.T) {
 	product := RandomProduct()
 	request := model.CreateProductRequest{
@@ -162,6 +281,7 @@ func TestCreateProduct(t *testing.T) {
 
 			request, err := http.NewRequest(http.MethodPost, test.url, toReader(t, tC.request))
 			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
 
 			// when
 			test.server.router.ServeHTTP(test.recorder, request)
@@ -291,11 +411,11 @@ func TestInactiveProduct(t *testing.T) {
 		{
 			name:      "Not Found",
 			productID: productID,
-			buildStubs: func(service *mockservice.MockProductService) {
-				service.EXPECT().
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().
 					InactiveProduct(gomock.Any(), gomock.Eq(productID)).
 					Times(1).
-					Return(sql.ErrNoRows)
+					Return(service.NotFound(service.CodeProductNotFound, "product not found"))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -338,6 +458,7 @@ func TestInactiveProduct(t *testing.T) {
 
 			request, err := http.NewRequest(http.MethodDelete, test.url, nil)
 			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
 
 			// when
 			test.server.router.ServeHTTP(test.recorder, request)
@@ -392,11 +513,11 @@ func TestUpdateProductStatus(t *testing.T) {
 		{
 			name:    "Not Found",
 			request: request,
-			buildStubs: func(service *mockservice.MockProductService) {
-				service.EXPECT().
+			buildStubs: func(mock *mockservice.MockProductService) {
+				mock.EXPECT().
 					UpdateProductStatus(gomock.Any(), gomock.Eq(request)).
 					Times(1).
-					Return(&model.Product{}, sql.ErrNoRows)
+					Return(nil, service.NotFound(service.CodeProductNotFound, "product not found"))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -427,6 +548,251 @@ func TestUpdateProductStatus(t *testing.T) {
 
 			request, err := http.NewRequest(http.MethodPatch, test.url, toReader(t, tC.request))
 			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestBulkProducts(t *testing.T) {
+	product := RandomProduct()
+	request := model.BulkProductsRequest{
+		Operations: []model.BulkProductOperation{
+			{
+				Operation:   model.BulkOperationCreate,
+				Name:        product.Name,
+				Price:       product.Price,
+				Description: product.Description,
+			},
+		},
+	}
+
+	atomicRequest := request
+	atomicRequest.Atomic = true
+
+	tooLarge := model.BulkProductsRequest{}
+	for i := 0; i < 11; i++ {
+		tooLarge.Operations = append(tooLarge.Operations, request.Operations[0])
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		request       model.BulkProductsRequest
+		buildStubs    func(service *mockservice.MockProductService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(&model.BulkProductsResponse{
+						Results: []model.BulkProductResult{
+							{Index: 0, Status: model.BulkStatusCreated, Product: product},
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var response model.BulkProductsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &response)
+				require.NoError(t, err)
+				require.Len(t, response.Results, 1)
+				require.Equal(t, model.BulkStatusCreated, response.Results[0].Status)
+			},
+		},
+		{
+			name:    "Atomic",
+			query:   "?atomic=true",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Eq(atomicRequest)).
+					Times(1).
+					Return(&model.BulkProductsResponse{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "Bad Request",
+			request: model.BulkProductsRequest{},
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Bad Atomic Flag",
+			query:   "?atomic=maybe",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Bulk Too Large",
+			request: tooLarge,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "Internal Server Error",
+			request: request,
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					BulkProducts(gomock.Any(), gomock.Eq(request)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/products:bulk"+tC.query)
+			tC.buildStubs(test.productService)
+
+			request, err := http.NewRequest(http.MethodPost, test.url, toReader(t, tC.request))
+			require.NoError(t, err)
+			addAuthorization(t, request, test.tokenMaker, authorizationTypeBearer, "order-service", "admin", time.Minute)
+
+			// when
+			test.server.router.ServeHTTP(test.recorder, request)
+
+			// then
+			tC.checkResponse(t, test.recorder)
+		})
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	const csvBody = "id,name\n1,foo\n"
+
+	writeCSV := func(ctx context.Context, req model.ExportProductsRequest, w io.Writer) error {
+		_, err := io.WriteString(w, csvBody)
+		return err
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		header        http.Header
+		buildStubs    func(service *mockservice.MockProductService)
+		checkResponse func(t *testing.T, recored *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?format=csv&status=available",
+			buildStubs: func(service *mockservice.MockProductService) {
+				req := model.ExportProductsRequest{
+					Format: model.ExportFormatCSV,
+					Status: model.ProductStatusAvailable,
+				}
+
+				service.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Eq(req), gomock.Any()).
+					Times(1).
+					DoAndReturn(writeCSV)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "attachment; filename=products.csv", recorder.Header().Get("Content-Disposition"))
+				require.Equal(t, csvBody, recorder.Body.String())
+			},
+		},
+		{
+			name:   "Gzip",
+			query:  "?format=csv",
+			header: http.Header{"Accept-Encoding": []string{"gzip, deflate"}},
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(writeCSV)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+
+				gz, err := gzip.NewReader(recorder.Body)
+				require.NoError(t, err)
+
+				data, err := io.ReadAll(gz)
+				require.NoError(t, err)
+				require.Equal(t, csvBody, string(data))
+			},
+		},
+		{
+			name:  "Bad Request",
+			query: "?format=pdf",
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "Internal Server Error",
+			query:  "?format=jsonl",
+			header: http.Header{"Accept-Encoding": []string{"gzip"}},
+			buildStubs: func(service *mockservice.MockProductService) {
+				service.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Content-Encoding"))
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			test := NewTest(t, "/products/export"+tC.query)
+			tC.buildStubs(test.productService)
+
+			request, err := http.NewRequest(http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+			for key, values := range tC.header {
+				request.Header[key] = values
+			}
 
 			// when
 			test.server.router.ServeHTTP(test.recorder, request)
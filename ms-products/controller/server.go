@@ -1,42 +1,176 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
 	"strings"
+	"sync/atomic"
 
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/metrics"
 	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/policy"
+	"github.com/djudju12/ms-products/ratelimit"
+	"github.com/djudju12/ms-products/service"
+	"github.com/djudju12/ms-products/token"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
+type Controllers struct {
+	Product     ProductController
+	Import      ImportController
+	Feed        FeedController
+	Auth        AuthController
+	APIKey      APIKeyController
+	Idempotency IdempotencyController
+	Health      HealthController
+	Config      ConfigController
+}
+
 type Server struct {
-	controller ProductController
-	router     *gin.Engine
+	config      configs.Config
+	settings    *atomic.Pointer[configs.Config]
+	controllers Controllers
+	tokenMaker  token.Maker
+	policy      *policy.Policy
+	router      *gin.Engine
+	httpServer  *http.Server
 }
 
-func NewServer(controller ProductController) *Server {
-	router := gin.Default()
+func NewServer(config configs.Config, tokenMaker token.Maker, p *policy.Policy, limiter *ratelimit.Limiter, m *metrics.Metrics, controllers Controllers) *Server {
+	router := gin.New()
+	// lets the gin context handed to services carry the request ID, trace and
+	// cancellation of the underlying request context
+	router.ContextWithFallback = true
+	router.Use(tracingMiddleware(), requestLogMiddleware(), clientMiddleware(), metricsMiddleware(m), gin.CustomRecoveryWithWriter(io.Discard, recoverPanic))
+
+	// settings holds the reloadable part of the configuration the
+	// middlewares read on every request
+	settings := new(atomic.Pointer[configs.Config])
+	settings.Store(&config)
+	router.Use(corsMiddleware(func() []string { return settings.Load().CORSOrigins }))
+	imports := featureMiddleware(func() bool { return settings.Load().FeatureImports })
+	feeds := featureMiddleware(func() bool { return settings.Load().FeatureFeeds })
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("price", model.ValidPrice)
-		v.RegisterValidation("status", model.ValidStatus)
+		model.RegisterValidations(v)
+	}
+
+	auth := authMiddleware(tokenMaker, controllers.APIKey.authenticate)
+	identify := identifyMiddleware(auth)
+	limit := rateLimitMiddleware(limiter)
+	can := func(permissions ...string) gin.HandlerFunc {
+		return permissionMiddleware(p, permissions...)
 	}
+	idempotent := controllers.Idempotency.idempotent
+
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+	router.GET("/healthz", controllers.Health.liveness)
+	router.GET("/readyz", controllers.Health.readiness)
+	router.GET("/admin/config", auth, limit, can(policy.ConfigRead), controllers.Config.getConfig)
+	router.POST("/tokens", limit, controllers.Auth.issueToken)
 
 	const productsPath = "/products"
-	router.GET(joinPath(productsPath, "/:id"), controller.getProduct)
-	router.GET(productsPath, controller.listProducts)
-	router.POST(productsPath, controller.createProduct)
-	router.DELETE(joinPath(productsPath, "/:id"), controller.inactiveProduct)
-	router.PATCH(productsPath, controller.updateProductStatus)
+	product := controllers.Product
+	router.GET(joinPath(productsPath, "/export"), identify, limit, product.exportProducts)
+	router.GET(joinPath(productsPath, "/:id"), identify, limit, product.getProduct)
+	router.GET(productsPath, identify, limit, product.listProducts)
+	router.POST(productsPath, auth, limit, can(policy.ProductsCreate), idempotent(product.createProduct))
+	router.POST(joinPath(productsPath, ":method"), customMethods(map[string]gin.HandlersChain{
+		"batchGet": {identify, limit, product.batchGetProducts},
+		"bulk":     {auth, limit, can(policy.ProductsCreate, policy.ProductsUpdate), idempotent(product.bulkProducts)},
+	}))
+	router.DELETE(joinPath(productsPath, "/:id"), auth, limit, can(policy.ProductsDeactivate), product.inactiveProduct)
+	router.PATCH(productsPath, auth, limit, can(policy.ProductsUpdate), idempotent(product.updateProductStatus))
+
+	const importsPath = "/imports"
+	router.POST(importsPath, imports, auth, limit, can(policy.ImportsCreate), idempotent(controllers.Import.createImport))
+	router.GET(joinPath(importsPath, "/:id"), imports, auth, limit, can(policy.ImportsRead), controllers.Import.getImport)
+	router.GET(joinPath(importsPath, "/:id/errors"), imports, auth, limit, can(policy.ImportsRead), controllers.Import.getImportErrors)
+
+	const apiKeysPath = "/api-keys"
+	apiKey := controllers.APIKey
+	router.POST(apiKeysPath, auth, limit, can(policy.APIKeysManage), idempotent(apiKey.createAPIKey))
+	router.GET(apiKeysPath, auth, limit, can(policy.APIKeysManage), apiKey.listAPIKeys)
+	router.DELETE(joinPath(apiKeysPath, "/:id"), auth, limit, can(policy.APIKeysManage), apiKey.revokeAPIKey)
+	router.POST(joinPath(apiKeysPath, "/:id/rotate"), auth, limit, can(policy.APIKeysManage), idempotent(apiKey.rotateAPIKey))
+
+	const feedsPath = "/feeds"
+	router.GET(joinPath(feedsPath, "/google.xml"), feeds, limit, controllers.Feed.googleFeed(model.FeedFormatRSS))
+	router.GET(joinPath(feedsPath, "/google.atom"), feeds, limit, controllers.Feed.googleFeed(model.FeedFormatAtom))
 
 	return &Server{
-		controller: controller,
-		router:     router,
+		config:      config,
+		settings:    settings,
+		controllers: controllers,
+		tokenMaker:  tokenMaker,
+		policy:      p,
+		router:      router,
+		httpServer: &http.Server{
+			Handler:           router.Handler(),
+			ReadHeaderTimeout: config.HTTPReadHeaderTimeout,
+			ReadTimeout:       config.HTTPReadTimeout,
+			WriteTimeout:      config.HTTPWriteTimeout,
+			IdleTimeout:       config.HTTPIdleTimeout,
+		},
 	}
 }
 
+// SetTrustedProxies sets the proxies whose forwarding headers are used to
+// find the client IP, which requests without credentials are rate limited by.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	return s.router.SetTrustedProxies(proxies)
+}
+
+// Reload applies the CORS origins and feature toggles of config to the
+// requests that follow.
+func (s *Server) Reload(config configs.Config) {
+	s.settings.Store(&config)
+}
+
+// Start serves requests on address, over TLS when a certificate is configured,
+// until Shutdown is called, after which it returns nil.
 func (s *Server) Start(address string) error {
-	return s.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return s.serve(listener)
+}
+
+func (s *Server) serve(listener net.Listener) error {
+	var err error
+	if s.config.TLSCertFile != "" {
+		err = s.httpServer.ServeTLS(listener, s.config.TLSCertFile, s.config.TLSKeyFile)
+	} else {
+		err = s.httpServer.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// recoverPanic logs a panic raised by a handler and answers it like any other
+// internal error.
+func recoverPanic(ctx *gin.Context, recovered any) {
+	slog.ErrorContext(ctx, "panic while handling request", "panic", recovered, "stack", string(debug.Stack()))
+	abortWithError(ctx, service.NewError(service.KindInternal, service.CodeInternal, "internal server error"))
 }
 
 func joinPath(path ...string) string {
@@ -47,3 +181,29 @@ func joinPath(path ...string) string {
 
 	return sb.String()
 }
+
+// customMethods dispatches custom methods like /products:batchGet. Gin reads
+// the colon as the start of a path parameter, so the method name is matched here
+// and its handlers run in order until one aborts.
+func customMethods(methods map[string]gin.HandlersChain) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		name, ok := strings.CutPrefix(ctx.Param("method"), ":")
+		if !ok {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		handlers, ok := methods[name]
+		if !ok {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+
+		for _, handler := range handlers {
+			handler(ctx)
+			if ctx.IsAborted() {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSlow serves test.url with a handler blocked until release is closed and
+// returns once a request is being handled.
+func serveSlow(t *testing.T, test *TestProductController, release chan struct{}) (serverErr chan error, responses chan *http.Response) {
+	started := make(chan struct{})
+	test.server.router.GET(test.url, func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serverErr = make(chan error, 1)
+	go func() { serverErr <- test.server.serve(listener) }()
+
+	responses = make(chan *http.Response, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String() + test.url)
+		if err != nil {
+			close(responses)
+			return
+		}
+		response.Body.Close()
+		responses <- response
+	}()
+
+	<-started
+	return serverErr, responses
+}
+
+func TestServerShutdown(t *testing.T) {
+	// given
+	test := NewTest(t, "/slow")
+	release := make(chan struct{})
+	serverErr, responses := serveSlow(t, test, release)
+
+	// when
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- test.server.Shutdown(context.Background()) }()
+
+	// then
+	select {
+	case <-shutdownErr:
+		t.Fatal("shutdown returned before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdownErr)
+	require.NoError(t, <-serverErr)
+
+	response, ok := <-responses
+	require.True(t, ok)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	// given
+	test := NewTest(t, "/slow")
+	release := make(chan struct{})
+	defer close(release)
+	serveSlow(t, test, release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := test.server.Shutdown(ctx)
+
+	// then
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
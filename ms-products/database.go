@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/db/sqlite"
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/djudju12/ms-products/replica"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// database is the store of the configured driver with what else main needs
+// from its pool.
+type database struct {
+	store db.Store
+	// replicas routes the catalog reads, nil without DB_REPLICAS
+	replicas *replica.Router
+	stats    prometheus.Collector
+	// sql runs the migrations, which golang-migrate drives through database/sql.
+	// On Postgres the migrator closes it when done.
+	sql   *sql.DB
+	close func() error
+}
+
+func openDatabase(config configs.Config) (*database, error) {
+	if config.DBDriver == sqlite.DriverName {
+		return openSQLite(config)
+	}
+
+	return openPostgres(config)
+}
+
+// openPostgres opens a pgx pool, connecting lazily. A pgx pool keeps its idle
+// connections up to MaxConns, so DB_MAX_IDLE_CONNS only applies to SQLite.
+// With DB_REPLICAS, the store is a router that reads the catalog from them.
+func openPostgres(config configs.Config) (*database, error) {
+	pool, err := openPool(config, config.DBSource)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := []*pgxpool.Pool{pool}
+	closePools := func() {
+		for _, p := range pools {
+			p.Close()
+		}
+	}
+
+	var store db.Store = db.NewStore(pool)
+	var router *replica.Router
+	if len(config.DBReplicas) > 0 {
+		replicas := make([]replica.Replica, 0, len(config.DBReplicas))
+		for _, source := range config.DBReplicas {
+			replicaPool, err := openPool(config, source)
+			if err != nil {
+				closePools()
+				return nil, fmt.Errorf("cannot open replica: %w", err)
+			}
+			pools = append(pools, replicaPool)
+
+			connConfig := replicaPool.Config().ConnConfig
+			replicas = append(replicas, replica.Replica{
+				Name:    net.JoinHostPort(connConfig.Host, strconv.Itoa(int(connConfig.Port))),
+				Querier: db.NewReplica(replicaPool),
+			})
+		}
+
+		router = replica.NewRouter(store, replica.Options{
+			MaxLag:        config.DBReplicaMaxLag,
+			CheckInterval: config.DBReplicaCheckInterval,
+			CheckTimeout:  config.HealthCheckTimeout,
+			StickyWindow:  config.DBReadYourWritesWindow,
+		}, replicas...)
+		store = router
+	}
+
+	conn := stdlib.OpenDBFromPool(pool)
+	return &database{
+		store:    store,
+		replicas: router,
+		stats:    metrics.PoolStats(pool),
+		sql:      conn,
+		close: func() error {
+			err := conn.Close()
+			closePools()
+			return err
+		},
+	}, nil
+}
+
+func openPool(config configs.Config, source string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DBMaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(config.DBMaxOpenConns)
+	}
+	if config.DBConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.DBConnMaxLifetime
+	}
+	if config.DBConnMaxIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DBConnMaxIdleTime
+	}
+
+	return pgxpool.NewWithConfig(context.Background(), poolConfig)
+}
+
+func openSQLite(config configs.Config) (*database, error) {
+	conn, err := sql.Open(sqlite.DriverName, sqlite.DataSource(config.DBSource))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(config.DBMaxOpenConns)
+	conn.SetMaxIdleConns(config.DBMaxIdleConns)
+	conn.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	conn.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+
+	return &database{
+		store: sqlite.NewStore(conn),
+		stats: metrics.SQLStats(conn),
+		sql:   conn,
+		close: conn.Close,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+// touchInterval is how stale last_used_at must be before TouchAPIKey writes it.
+const touchInterval = time.Minute
+
+// CreateAPIKey checks the unique key hash before rotated_from, the order
+// Postgres checks an index and a foreign key in.
+func (q *Queries) CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ApiKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	q.apiKeySeq++
+	id := q.apiKeySeq
+
+	for _, key := range q.apiKeys {
+		if bytes.Equal(key.KeyHash, arg.KeyHash) {
+			return db.ApiKey{}, uniqueViolation("api_keys", "api_keys_key_hash_key",
+				fmt.Sprintf("Key (key_hash)=(\\x%x) already exists.", arg.KeyHash))
+		}
+	}
+
+	if arg.RotatedFrom.Valid {
+		if _, ok := q.apiKeys[arg.RotatedFrom.Int32]; !ok {
+			return db.ApiKey{}, foreignKeyViolation("api_keys", "api_keys_rotated_from_fkey",
+				fmt.Sprintf("Key (rotated_from)=(%d) is not present in table \"api_keys\".", arg.RotatedFrom.Int32))
+		}
+	}
+
+	key := db.ApiKey{
+		ID:          id,
+		Name:        arg.Name,
+		Prefix:      arg.Prefix,
+		KeyHash:     clone(arg.KeyHash),
+		Scopes:      append([]string{}, arg.Scopes...),
+		RateLimit:   arg.RateLimit,
+		RotatedFrom: arg.RotatedFrom,
+		ExpiresAt:   nullTimestamp(arg.ExpiresAt),
+		CreatedAt:   now,
+	}
+	q.apiKeys[id] = key
+
+	return copyAPIKey(key), nil
+}
+
+func (q *Queries) GetAPIKey(ctx context.Context, id int32) (db.ApiKey, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.ApiKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	key, ok := q.apiKeys[id]
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+
+	return copyAPIKey(key), nil
+}
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash []byte) (db.ApiKey, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.ApiKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	for _, key := range q.apiKeys {
+		if bytes.Equal(key.KeyHash, keyHash) {
+			return copyAPIKey(key), nil
+		}
+	}
+
+	return db.ApiKey{}, sql.ErrNoRows
+}
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]db.ApiKey, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer q.mu.Unlock()
+
+	keys := make([]db.ApiKey, 0, len(q.apiKeys))
+	for _, key := range q.apiKeys {
+		keys = append(keys, copyAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys, nil
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (db.ApiKey, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ApiKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	key, ok := q.apiKeys[id]
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+
+	if !key.RevokedAt.Valid {
+		key.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		q.apiKeys[id] = key
+	}
+
+	return copyAPIKey(key), nil
+}
+
+// ExpireAPIKey only brings the expiry forward. LEAST ignores a NULL expires_at.
+func (q *Queries) ExpireAPIKey(ctx context.Context, arg db.ExpireAPIKeyParams) (db.ApiKey, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.ApiKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	key, ok := q.apiKeys[arg.ID]
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+
+	expiresAt := timestamp(arg.ExpiresAt)
+	if !key.ExpiresAt.Valid || expiresAt.Before(key.ExpiresAt.Time) {
+		key.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+		q.apiKeys[arg.ID] = key
+	}
+
+	return copyAPIKey(key), nil
+}
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int32) error {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	key, ok := q.apiKeys[id]
+	if !ok {
+		return nil
+	}
+
+	if !key.LastUsedAt.Valid || key.LastUsedAt.Time.Before(now.Add(-touchInterval)) {
+		key.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		q.apiKeys[id] = key
+	}
+
+	return nil
+}
+
+func copyAPIKey(key db.ApiKey) db.ApiKey {
+	key.KeyHash = clone(key.KeyHash)
+	key.Scopes = append([]string{}, key.Scopes...)
+	return key
+}
@@ -0,0 +1,118 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const (
+	idempotencyStatusInProgress = "in_progress"
+	idempotencyStatusCompleted  = "completed"
+)
+
+type idempotencyKeyID struct {
+	scope string
+	key   string
+}
+
+// CreateIdempotencyKey claims the key, or takes over an expired or abandoned
+// one. Any other existing key fails the ON CONFLICT ... WHERE and, like the
+// query, returns sql.ErrNoRows.
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.IdempotencyKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	id := idempotencyKeyID{scope: arg.Scope, key: arg.Key}
+	if existing, ok := q.idempotencyKeys[id]; ok {
+		expired := existing.ExpiresAt.Before(now)
+		abandoned := existing.Status == idempotencyStatusInProgress && existing.CreatedAt.Before(timestamp(arg.AbandonedBefore))
+		if !expired && !abandoned {
+			return db.IdempotencyKey{}, sql.ErrNoRows
+		}
+	}
+
+	key := db.IdempotencyKey{
+		Scope:        arg.Scope,
+		Key:          arg.Key,
+		RequestHash:  clone(arg.RequestHash),
+		Status:       idempotencyStatusInProgress,
+		ResponseBody: []byte{},
+		CreatedAt:    now,
+		ExpiresAt:    timestamp(arg.ExpiresAt),
+	}
+	q.idempotencyKeys[id] = key
+
+	return copyIdempotencyKey(key), nil
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.IdempotencyKey{}, err
+	}
+	defer q.mu.Unlock()
+
+	key, ok := q.idempotencyKeys[idempotencyKeyID{scope: arg.Scope, key: arg.Key}]
+	if !ok {
+		return db.IdempotencyKey{}, sql.ErrNoRows
+	}
+
+	return copyIdempotencyKey(key), nil
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg db.CompleteIdempotencyKeyParams) error {
+	if _, err := q.begin(ctx); err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	id := idempotencyKeyID{scope: arg.Scope, key: arg.Key}
+	key, ok := q.idempotencyKeys[id]
+	if !ok {
+		return nil
+	}
+
+	key.Status = idempotencyStatusCompleted
+	key.ResponseStatus = arg.ResponseStatus
+	key.ResponseContentType = arg.ResponseContentType
+	key.ResponseBody = clone(arg.ResponseBody)
+	q.idempotencyKeys[id] = key
+
+	return nil
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg db.DeleteIdempotencyKeyParams) error {
+	if _, err := q.begin(ctx); err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	delete(q.idempotencyKeys, idempotencyKeyID{scope: arg.Scope, key: arg.Key})
+	return nil
+}
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	for id, key := range q.idempotencyKeys {
+		if key.ExpiresAt.Before(now) {
+			delete(q.idempotencyKeys, id)
+		}
+	}
+
+	return nil
+}
+
+func copyIdempotencyKey(key db.IdempotencyKey) db.IdempotencyKey {
+	key.RequestHash = clone(key.RequestHash)
+	key.ResponseBody = clone(key.ResponseBody)
+	return key
+}
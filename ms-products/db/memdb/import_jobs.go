@@ -0,0 +1,250 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const (
+	importStatusPending = "pending"
+	importStatusRunning = "running"
+)
+
+func (q *Queries) CreateImportJob(ctx context.Context, arg db.CreateImportJobParams) (db.ImportJob, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ImportJob{}, err
+	}
+	defer q.mu.Unlock()
+
+	q.importJobSeq++
+	job := db.ImportJob{
+		ID:        q.importJobSeq,
+		Format:    arg.Format,
+		Status:    importStatusPending,
+		Payload:   clone(arg.Payload),
+		TotalRows: arg.TotalRows,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	q.importJobs[job.ID] = job
+
+	return copyImportJob(job), nil
+}
+
+func (q *Queries) GetImportJob(ctx context.Context, id int32) (db.ImportJob, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.ImportJob{}, err
+	}
+	defer q.mu.Unlock()
+
+	job, ok := q.importJobs[id]
+	if !ok {
+		return db.ImportJob{}, sql.ErrNoRows
+	}
+
+	return copyImportJob(job), nil
+}
+
+// ClaimImportJob hands out the oldest job that is pending or whose worker let
+// its lease expire.
+func (q *Queries) ClaimImportJob(ctx context.Context, arg db.ClaimImportJobParams) (db.ImportJob, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ImportJob{}, err
+	}
+	defer q.mu.Unlock()
+
+	var claimed *db.ImportJob
+	for _, job := range q.importJobs {
+		job := job
+		if !claimable(job, now) || (claimed != nil && claimed.ID < job.ID) {
+			continue
+		}
+		claimed = &job
+	}
+	if claimed == nil {
+		return db.ImportJob{}, sql.ErrNoRows
+	}
+
+	job := *claimed
+	job.Status = importStatusRunning
+	job.ClaimedBy = arg.ClaimedBy
+	job.LeaseExpiresAt = leaseExpiry(now, arg.LeaseSeconds)
+	job.UpdatedAt = now
+	q.importJobs[job.ID] = job
+
+	return copyImportJob(job), nil
+}
+
+func claimable(job db.ImportJob, now time.Time) bool {
+	switch job.Status {
+	case importStatusPending:
+		return true
+	case importStatusRunning:
+		return !job.LeaseExpiresAt.Valid || !job.LeaseExpiresAt.Time.After(now)
+	}
+
+	return false
+}
+
+func leaseExpiry(now time.Time, seconds int32) sql.NullTime {
+	return sql.NullTime{Time: now.Add(time.Duration(seconds) * time.Second), Valid: true}
+}
+
+func (q *Queries) UpdateImportJobStatus(ctx context.Context, arg db.UpdateImportJobStatusParams) (db.ImportJob, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ImportJob{}, err
+	}
+	defer q.mu.Unlock()
+
+	job, ok := q.importJobs[arg.ID]
+	if !ok || job.ClaimedBy != arg.ClaimedBy {
+		return db.ImportJob{}, sql.ErrNoRows
+	}
+
+	job.Status = arg.Status
+	job.Error = arg.Error
+	job.LeaseExpiresAt = sql.NullTime{}
+	job.UpdatedAt = now
+	q.importJobs[arg.ID] = job
+
+	return copyImportJob(job), nil
+}
+
+func (q *Queries) UpdateImportJobProgress(ctx context.Context, arg db.UpdateImportJobProgressParams) (db.ImportJob, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.ImportJob{}, err
+	}
+	defer q.mu.Unlock()
+
+	job, ok := q.importJobs[arg.ID]
+	if !ok || job.ClaimedBy != arg.ClaimedBy || job.Status != importStatusRunning {
+		return db.ImportJob{}, sql.ErrNoRows
+	}
+
+	job.ProcessedRows = arg.ProcessedRows
+	job.CreatedRows = arg.CreatedRows
+	job.UpdatedRows = arg.UpdatedRows
+	job.RejectedRows = arg.RejectedRows
+	job.LeaseExpiresAt = leaseExpiry(now, arg.LeaseSeconds)
+	job.UpdatedAt = now
+	q.importJobs[arg.ID] = job
+
+	return copyImportJob(job), nil
+}
+
+// CreateImportJobErrors adds all the rows or, like COPY, none of them.
+func (q *Queries) CreateImportJobErrors(ctx context.Context, arg []db.CreateImportJobErrorsParams) (int64, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return 0, err
+	}
+	defer q.mu.Unlock()
+
+	for _, row := range arg {
+		if _, ok := q.importJobs[row.JobID]; !ok {
+			return 0, foreignKeyViolation("import_job_errors", "import_job_errors_job_id_fkey",
+				fmt.Sprintf("Key (job_id)=(%d) is not present in table \"import_jobs\".", row.JobID))
+		}
+	}
+
+	for _, row := range arg {
+		q.importJobErrorSeq++
+		q.importJobErrors = append(q.importJobErrors, db.ImportJobError{
+			ID:      q.importJobErrorSeq,
+			JobID:   row.JobID,
+			Line:    row.Line,
+			Raw:     row.Raw,
+			Message: row.Message,
+		})
+	}
+
+	return int64(len(arg)), nil
+}
+
+// CreateImportStaging coerces every price before it adds the rows, so like
+// COPY it adds all of them or none.
+func (q *Queries) CreateImportStaging(ctx context.Context, arg []db.CreateImportStagingParams) (int64, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return 0, err
+	}
+	defer q.mu.Unlock()
+
+	rows := make([]db.CreateImportStagingParams, len(arg))
+	for i, row := range arg {
+		price, err := db.Numeric(row.Price, 12, 2)
+		if err != nil {
+			return 0, err
+		}
+
+		row.Price = price
+		rows[i] = row
+	}
+	q.importStaging = append(q.importStaging, rows...)
+
+	return int64(len(rows)), nil
+}
+
+// UpsertStagedProducts moves the staged rows of the job into products in line
+// order, drawing an id for every row as INSERT ... SELECT does.
+func (q *Queries) UpsertStagedProducts(ctx context.Context, jobID int32) (db.UpsertStagedProductsRow, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.UpsertStagedProductsRow{}, err
+	}
+	defer q.mu.Unlock()
+
+	var staged, kept []db.CreateImportStagingParams
+	for _, row := range q.importStaging {
+		if row.JobID == jobID {
+			staged = append(staged, row)
+		} else {
+			kept = append(kept, row)
+		}
+	}
+	q.importStaging = kept
+
+	sort.SliceStable(staged, func(i, j int) bool {
+		return staged[i].Line < staged[j].Line
+	})
+
+	var counts db.UpsertStagedProductsRow
+	for _, row := range staged {
+		if _, inserted := q.upsertProduct(now, row.Name, row.Price, row.Description); inserted {
+			counts.CreatedRows++
+		} else {
+			counts.UpdatedRows++
+		}
+	}
+
+	return counts, nil
+}
+
+func (q *Queries) ListImportJobErrors(ctx context.Context, jobID int32) ([]db.ImportJobError, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer q.mu.Unlock()
+
+	errors := []db.ImportJobError{}
+	for _, e := range q.importJobErrors {
+		if e.JobID == jobID {
+			errors = append(errors, e)
+		}
+	}
+	sort.SliceStable(errors, func(i, j int) bool { return errors[i].Line < errors[j].Line })
+
+	return errors, nil
+}
+
+func copyImportJob(job db.ImportJob) db.ImportJob {
+	job.Payload = clone(job.Payload)
+	return job
+}
@@ -0,0 +1,108 @@
+// Package memdb is an in-memory db.Querier for local development and tests
+// that should not need Postgres. It reproduces the semantics of the queries in
+// db/query: serial ids that are consumed even by failed or conflicting
+// inserts, unique and foreign key constraints reported as *db.Error with the
+// Postgres codes and constraint names, sql.ErrNoRows for missing rows,
+// decimal(12, 2) prices and timestamps with microsecond precision.
+//
+// now() reads the clock given to New, once per query, like a statement in
+// Postgres sees a single now(). The conformance suite in db/querytest runs
+// against both this package and Postgres.
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+type Queries struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	products   map[int32]db.Product
+	productSeq int32
+
+	importJobs        map[int32]db.ImportJob
+	importJobSeq      int32
+	importJobErrors   []db.ImportJobError
+	importJobErrorSeq int64
+	importStaging     []db.CreateImportStagingParams
+
+	apiKeys   map[int32]db.ApiKey
+	apiKeySeq int32
+
+	rateLimitBuckets map[string]db.RateLimitBucket
+	idempotencyKeys  map[idempotencyKeyID]db.IdempotencyKey
+}
+
+var _ db.Querier = (*Queries)(nil)
+
+// New returns empty tables. clock stands in for now(), nil means time.Now.
+func New(clock func() time.Time) *Queries {
+	if clock == nil {
+		clock = time.Now
+	}
+
+	return &Queries{
+		now:              clock,
+		products:         make(map[int32]db.Product),
+		importJobs:       make(map[int32]db.ImportJob),
+		apiKeys:          make(map[int32]db.ApiKey),
+		rateLimitBuckets: make(map[string]db.RateLimitBucket),
+		idempotencyKeys:  make(map[idempotencyKeyID]db.IdempotencyKey),
+	}
+}
+
+// begin locks the tables for one query and returns the statement time. A
+// canceled context fails the query before it runs, as it does with Postgres.
+func (q *Queries) begin(ctx context.Context) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	q.mu.Lock()
+	return timestamp(q.now()), nil
+}
+
+// timestamp keeps what a timestamptz column keeps, microseconds.
+func timestamp(t time.Time) time.Time {
+	return t.Round(time.Microsecond)
+}
+
+func nullTimestamp(t sql.NullTime) sql.NullTime {
+	if t.Valid {
+		t.Time = timestamp(t.Time)
+	}
+	return t
+}
+
+// clone copies bytea values so callers cannot change stored rows. Like pq,
+// empty values come back as empty, not nil, slices.
+func clone(b []byte) []byte {
+	return append([]byte{}, b...)
+}
+
+func uniqueViolation(table, constraint, detail string) error {
+	return &db.Error{
+		Code:       db.CodeUniqueViolation,
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Detail:     detail,
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint, detail string) error {
+	return &db.Error{
+		Code:       db.CodeForeignKeyViolation,
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Detail:     detail,
+		Table:      table,
+		Constraint: constraint,
+	}
+}
@@ -0,0 +1,130 @@
+package memdb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/db/querytest"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	querytest.Run(t, func(t *testing.T) db.Querier {
+		return New(nil)
+	})
+}
+
+// fakeClock is advanced by the tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 10, 0, 0, 123456789, time.UTC)}
+	q := New(clock.Now)
+	ctx := context.Background()
+
+	product, err := q.CreateProduct(ctx, db.CreateProductParams{Name: "pen", Price: "1.00"})
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 10, 0, 0, 123457000, time.UTC), product.CreatedAt)
+
+	clock.Advance(time.Hour)
+	updated, err := q.UpdateProductStatus(ctx, db.UpdateProductStatusParams{ID: product.ID, Status: "inactive"})
+	require.NoError(t, err)
+	require.Equal(t, product.CreatedAt, updated.CreatedAt)
+	require.Equal(t, product.UpdatedAt.Add(time.Hour), updated.UpdatedAt)
+
+	key, err := q.CreateAPIKey(ctx, db.CreateAPIKeyParams{KeyHash: []byte("hash")})
+	require.NoError(t, err)
+	require.NoError(t, q.TouchAPIKey(ctx, key.ID))
+	touched, err := q.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+
+	clock.Advance(touchInterval + time.Second)
+	require.NoError(t, q.TouchAPIKey(ctx, key.ID))
+	retouched, err := q.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, touched.LastUsedAt.Time.Add(touchInterval+time.Second), retouched.LastUsedAt.Time)
+
+	_, err = q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{Scope: "s", Key: "k", ExpiresAt: clock.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	clock.Advance(2 * time.Minute)
+	require.NoError(t, q.DeleteExpiredIdempotencyKeys(ctx))
+	_, err = q.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Scope: "s", Key: "k"})
+	require.Error(t, err)
+}
+
+func TestConcurrentInserts(t *testing.T) {
+	q := New(nil)
+	n := 50
+
+	var wg sync.WaitGroup
+	ids := make(chan int32, n)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			row, err := q.UpsertProduct(context.Background(), db.UpsertProductParams{Name: string(rune('a' + i%10)), Price: "1"})
+			errs <- err
+			ids <- row.ID
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	distinct := make(map[int32]bool)
+	for id := range ids {
+		distinct[id] = true
+	}
+	require.Len(t, distinct, 10)
+
+	products, err := q.ListProducts(context.Background(), db.ListProductsParams{Limit: 100})
+	require.NoError(t, err)
+	require.Len(t, products, 10)
+	require.Equal(t, int32(n), q.productSeq)
+}
+
+func TestReturnedRowsAreCopies(t *testing.T) {
+	q := New(nil)
+	ctx := context.Background()
+
+	key, err := q.CreateAPIKey(ctx, db.CreateAPIKeyParams{KeyHash: []byte("hash"), Scopes: []string{"read"}})
+	require.NoError(t, err)
+	key.KeyHash[0] = 'X'
+	key.Scopes[0] = "write"
+
+	stored, err := q.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hash"), stored.KeyHash)
+	require.Equal(t, []string{"read"}, stored.Scopes)
+}
+
+func TestCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := New(nil).CreateProduct(ctx, db.CreateProductParams{Name: "pen", Price: "1"})
+	require.ErrorIs(t, err, context.Canceled)
+}
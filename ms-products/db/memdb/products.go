@@ -0,0 +1,261 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+// productStatusDefault is the column default from the migrations. The service
+// always sets one of the model statuses after creating a product.
+const productStatusDefault = "ACTIVE"
+
+func (q *Queries) CreateProduct(ctx context.Context, arg db.CreateProductParams) (db.Product, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.Product{}, err
+	}
+	defer q.mu.Unlock()
+
+	// parameters are coerced before the sequence is drawn
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.Product{}, err
+	}
+
+	q.productSeq++
+	id := q.productSeq
+
+	if _, ok := q.productByName(arg.Name); ok {
+		return db.Product{}, productNameViolation(arg.Name)
+	}
+
+	product := db.Product{
+		ID:          id,
+		Name:        arg.Name,
+		Price:       price,
+		Description: arg.Description,
+		Status:      productStatusDefault,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.products[id] = product
+
+	return product, nil
+}
+
+func (q *Queries) GetProduct(ctx context.Context, id int32) (db.Product, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return db.Product{}, err
+	}
+	defer q.mu.Unlock()
+
+	product, ok := q.products[id]
+	if !ok {
+		return db.Product{}, sql.ErrNoRows
+	}
+
+	return product, nil
+}
+
+func (q *Queries) ListProducts(ctx context.Context, arg db.ListProductsParams) ([]db.Product, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer q.mu.Unlock()
+
+	if arg.Limit < 0 {
+		return nil, &db.Error{Code: db.CodeNegativeLimit, Message: "LIMIT must not be negative"}
+	}
+	if arg.Offset < 0 {
+		return nil, &db.Error{Code: db.CodeNegativeOffset, Message: "OFFSET must not be negative"}
+	}
+
+	products := q.sortedProducts()
+	if int(arg.Offset) >= len(products) {
+		return []db.Product{}, nil
+	}
+
+	products = products[arg.Offset:]
+	if int(arg.Limit) < len(products) {
+		products = products[:arg.Limit]
+	}
+
+	return products, nil
+}
+
+func (q *Queries) UpdateProductStatus(ctx context.Context, arg db.UpdateProductStatusParams) (db.Product, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.Product{}, err
+	}
+	defer q.mu.Unlock()
+
+	product, ok := q.products[arg.ID]
+	if !ok {
+		return db.Product{}, sql.ErrNoRows
+	}
+
+	product.Status = arg.Status
+	product.UpdatedAt = now
+	q.products[arg.ID] = product
+
+	return product, nil
+}
+
+func (q *Queries) GetProductsByIDs(ctx context.Context, ids []int32) ([]db.Product, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer q.mu.Unlock()
+
+	wanted := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	products := []db.Product{}
+	for _, product := range q.sortedProducts() {
+		if wanted[product.ID] {
+			products = append(products, product)
+		}
+	}
+
+	return products, nil
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg db.UpdateProductParams) (db.Product, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.Product{}, err
+	}
+	defer q.mu.Unlock()
+
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.Product{}, err
+	}
+
+	product, ok := q.products[arg.ID]
+	if !ok {
+		return db.Product{}, sql.ErrNoRows
+	}
+
+	if other, ok := q.productByName(arg.Name); ok && other.ID != arg.ID {
+		return db.Product{}, productNameViolation(arg.Name)
+	}
+
+	product.Name = arg.Name
+	product.Price = price
+	product.Description = arg.Description
+	product.UpdatedAt = now
+	q.products[arg.ID] = product
+
+	return product, nil
+}
+
+// UpsertProduct draws an id even when the name exists and the row is updated,
+// as INSERT ... ON CONFLICT does.
+func (q *Queries) UpsertProduct(ctx context.Context, arg db.UpsertProductParams) (db.UpsertProductRow, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.UpsertProductRow{}, err
+	}
+	defer q.mu.Unlock()
+
+	// parameters are coerced before the sequence is drawn
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.UpsertProductRow{}, err
+	}
+
+	product, inserted := q.upsertProduct(now, arg.Name, price, arg.Description)
+	return db.UpsertProductRow{
+		ID:          product.ID,
+		Name:        product.Name,
+		Price:       product.Price,
+		Description: product.Description,
+		Status:      product.Status,
+		CreatedAt:   product.CreatedAt,
+		UpdatedAt:   product.UpdatedAt,
+		Inserted:    inserted,
+	}, nil
+}
+
+// upsertProduct writes one row of an INSERT ... ON CONFLICT (name) DO UPDATE
+// with a coerced price, and tells whether it was inserted. The caller holds
+// the lock.
+func (q *Queries) upsertProduct(now time.Time, name, price, description string) (db.Product, bool) {
+	q.productSeq++
+	id := q.productSeq
+
+	product, exists := q.productByName(name)
+	if exists {
+		product.Price = price
+		product.Description = description
+		product.UpdatedAt = now
+	} else {
+		product = db.Product{
+			ID:          id,
+			Name:        name,
+			Price:       price,
+			Description: description,
+			Status:      productStatusDefault,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+	q.products[product.ID] = product
+
+	return product, !exists
+}
+
+// CountProductsByStatus orders by byte value, which agrees with the database
+// collation for the statuses the service uses.
+func (q *Queries) CountProductsByStatus(ctx context.Context) ([]db.CountProductsByStatusRow, error) {
+	if _, err := q.begin(ctx); err != nil {
+		return nil, err
+	}
+	defer q.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, product := range q.products {
+		counts[product.Status]++
+	}
+
+	rows := make([]db.CountProductsByStatusRow, 0, len(counts))
+	for status, count := range counts {
+		rows = append(rows, db.CountProductsByStatusRow{Status: status, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Status < rows[j].Status })
+
+	return rows, nil
+}
+
+func (q *Queries) sortedProducts() []db.Product {
+	products := make([]db.Product, 0, len(q.products))
+	for _, product := range q.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+
+	return products
+}
+
+func (q *Queries) productByName(name string) (db.Product, bool) {
+	for _, product := range q.products {
+		if product.Name == name {
+			return product, true
+		}
+	}
+
+	return db.Product{}, false
+}
+
+func productNameViolation(name string) error {
+	return uniqueViolation("products", "products_name_key", fmt.Sprintf("Key (name)=(%s) already exists.", name))
+}
@@ -0,0 +1,64 @@
+package memdb
+
+import (
+	"context"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+// LockRateLimitBucket creates the bucket when it is missing. Every query holds
+// the tables' mutex, so there is no row lock to take beyond that.
+func (q *Queries) LockRateLimitBucket(ctx context.Context, arg db.LockRateLimitBucketParams) (db.LockRateLimitBucketRow, error) {
+	now, err := q.begin(ctx)
+	if err != nil {
+		return db.LockRateLimitBucketRow{}, err
+	}
+	defer q.mu.Unlock()
+
+	bucket, ok := q.rateLimitBuckets[arg.Key]
+	if !ok {
+		bucket = db.RateLimitBucket{Key: arg.Key, Tokens: arg.Tokens, UpdatedAt: now, ExpiresAt: now}
+		q.rateLimitBuckets[arg.Key] = bucket
+	}
+
+	return db.LockRateLimitBucketRow{
+		Tokens:    bucket.Tokens,
+		UpdatedAt: bucket.UpdatedAt,
+		Now:       now,
+	}, nil
+}
+
+func (q *Queries) UpdateRateLimitBucket(ctx context.Context, arg db.UpdateRateLimitBucketParams) error {
+	if _, err := q.begin(ctx); err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	bucket, ok := q.rateLimitBuckets[arg.Key]
+	if !ok {
+		return nil
+	}
+
+	bucket.Tokens = arg.Tokens
+	bucket.UpdatedAt = timestamp(arg.UpdatedAt)
+	bucket.ExpiresAt = timestamp(arg.ExpiresAt)
+	q.rateLimitBuckets[arg.Key] = bucket
+
+	return nil
+}
+
+func (q *Queries) DeleteExpiredRateLimitBuckets(ctx context.Context, expiresAt time.Time) error {
+	if _, err := q.begin(ctx); err != nil {
+		return err
+	}
+	defer q.mu.Unlock()
+
+	for key, bucket := range q.rateLimitBuckets {
+		if bucket.ExpiresAt.Before(expiresAt) {
+			delete(q.rateLimitBuckets, key)
+		}
+	}
+
+	return nil
+}
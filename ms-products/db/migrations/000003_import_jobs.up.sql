@@ -0,0 +1,25 @@
+CREATE TABLE "import_jobs" (
+    "id" serial PRIMARY KEY,
+    "format" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "payload" bytea NOT NULL,
+    "total_rows" integer NOT NULL DEFAULT 0,
+    "processed_rows" integer NOT NULL DEFAULT 0,
+    "created_rows" integer NOT NULL DEFAULT 0,
+    "updated_rows" integer NOT NULL DEFAULT 0,
+    "rejected_rows" integer NOT NULL DEFAULT 0,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "import_job_errors" (
+    "id" bigserial PRIMARY KEY,
+    "job_id" integer NOT NULL REFERENCES "import_jobs" ("id") ON DELETE CASCADE,
+    "line" integer NOT NULL,
+    "raw" varchar NOT NULL,
+    "message" varchar NOT NULL
+);
+
+CREATE INDEX ON "import_jobs" ("status");
+CREATE INDEX ON "import_job_errors" ("job_id", "line");
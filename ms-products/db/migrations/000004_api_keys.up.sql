@@ -0,0 +1,13 @@
+CREATE TABLE "api_keys" (
+    "id" serial PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "prefix" varchar NOT NULL,
+    "key_hash" bytea UNIQUE NOT NULL,
+    "scopes" varchar[] NOT NULL,
+    "rate_limit" integer NOT NULL DEFAULT 0,
+    "rotated_from" integer REFERENCES "api_keys" ("id"),
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,14 @@
+CREATE TABLE "idempotency_keys" (
+    "scope" varchar NOT NULL,
+    "key" varchar NOT NULL,
+    "request_hash" bytea NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'in_progress',
+    "response_status" integer NOT NULL DEFAULT 0,
+    "response_content_type" varchar NOT NULL DEFAULT '',
+    "response_body" bytea NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS import_staging;
\ No newline at end of file
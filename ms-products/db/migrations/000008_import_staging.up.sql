@@ -0,0 +1,9 @@
+-- an import chunk COPYs its rows here and moves them into products within the
+-- same transaction, so the table holds nothing worth logging
+CREATE UNLOGGED TABLE "import_staging" (
+    "job_id" integer NOT NULL,
+    "line" integer NOT NULL,
+    "name" varchar NOT NULL,
+    "price" decimal(12, 2) NOT NULL,
+    "description" varchar NOT NULL
+);
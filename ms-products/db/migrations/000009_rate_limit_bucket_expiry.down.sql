@@ -0,0 +1,3 @@
+ALTER TABLE rate_limit_buckets DROP COLUMN IF EXISTS "expires_at";
+
+CREATE INDEX ON "rate_limit_buckets" ("updated_at");
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE rate_limit_buckets ADD COLUMN "expires_at" timestamptz NOT NULL DEFAULT (now());
+
+DROP INDEX IF EXISTS rate_limit_buckets_updated_at_idx;
+CREATE INDEX ON "rate_limit_buckets" ("expires_at");
@@ -0,0 +1,178 @@
+// Package migrations embeds the schema migrations in the binary and applies
+// them with golang-migrate, sharing the schema_migrations table of the CLI.
+// SQLite has its own set in sqlite/, numbered like the Postgres one so both
+// schemas report the same version.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+var latest = latestVersion(files)
+
+// Latest returns the version of the newest embedded migration.
+func Latest() uint {
+	return latest
+}
+
+type Migrator struct {
+	migrate *migrate.Migrate
+}
+
+// New prepares the migrations for driver, postgres or sqlite, against conn.
+// On Postgres the migrator holds one connection of the pool until Close, which
+// closes conn as well.
+func New(ctx context.Context, driver string, conn *sql.DB, lockTimeout time.Duration) (*Migrator, error) {
+	if driver == "sqlite" {
+		return newSQLite(conn)
+	}
+
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.PingContext(ctx); err != nil {
+		return nil, err
+	}
+
+	instance, err := pgxmigrate.WithInstance(conn, &pgxmigrate.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "pgx5", instance)
+	if err != nil {
+		instance.Close()
+		return nil, err
+	}
+	m.LockTimeout = lockTimeout
+	m.Log = logger{}
+
+	return &Migrator{migrate: m}, nil
+}
+
+// newSQLite migrates through the pool, which stays open after Close. The
+// golang-migrate lock only covers this process, SQLite itself serializes
+// writers to the file.
+func newSQLite(conn *sql.DB) (*Migrator, error) {
+	source, err := iofs.New(sqliteFiles, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := sqlite.WithInstance(conn, &sqlite.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", keepOpen{instance})
+	if err != nil {
+		return nil, err
+	}
+	m.Log = logger{}
+
+	return &Migrator{migrate: m}, nil
+}
+
+// keepOpen stops the sqlite driver from closing the pool it was given.
+type keepOpen struct {
+	database.Driver
+}
+
+func (keepOpen) Close() error {
+	return nil
+}
+
+// Up applies the pending migrations. On Postgres every change runs under an
+// advisory lock, so replicas starting together migrate one at a time and the
+// later ones find nothing left to do.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down rolls back the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	return ignoreNoChange(m.migrate.Steps(-steps))
+}
+
+// Force sets the version without running migrations, to recover from a dirty
+// schema once it was fixed by hand.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Version returns 0 for a database that was never migrated.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.migrate.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+func latestVersion(fsys fs.FS) uint {
+	names, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		panic(err)
+	}
+
+	var latest uint
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("migration %s has no version: %v", name, err))
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	return latest
+}
+
+// logger reports the migrations golang-migrate applies.
+type logger struct{}
+
+func (logger) Printf(format string, v ...any) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "migrate")
+}
+
+func (logger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestLatest(t *testing.T) {
+	names, err := files.ReadDir(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	require.Equal(t, uint(9), Latest())
+
+	sqlite, err := fs.Sub(sqliteFiles, "sqlite")
+	require.NoError(t, err)
+	require.Equal(t, Latest(), latestVersion(sqlite))
+}
+
+func TestMigratorUp(t *testing.T) {
+	config, err := configs.LoadConfig("../..", nil)
+	require.NoError(t, err)
+
+	conn, err := sql.Open("pgx", config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	migrator, err := New(context.Background(), config.DBDriver, conn, time.Minute)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// up twice, as a second replica would, finds nothing left to apply
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Up())
+
+	version, dirty, err := migrator.Version()
+	require.NoError(t, err)
+	require.Equal(t, Latest(), version)
+	require.False(t, dirty)
+}
+
+func TestSQLiteMigrator(t *testing.T) {
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "products.db"))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	migrator, err := New(context.Background(), "sqlite", conn, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up())
+	version, dirty, err := migrator.Version()
+	require.NoError(t, err)
+	require.Equal(t, Latest(), version)
+	require.False(t, dirty)
+
+	// every down migration undoes its up
+	require.NoError(t, migrator.Down(int(Latest())))
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Close())
+
+	// the pool outlives the migrator
+	var count int
+	require.NoError(t, conn.QueryRow("SELECT count(*) FROM products").Scan(&count))
+	require.Zero(t, count)
+}
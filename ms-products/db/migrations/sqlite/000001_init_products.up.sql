@@ -0,0 +1,9 @@
+-- price is text, a numeric column would turn 10.00 into the number 10
+CREATE TABLE "products" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" varchar UNIQUE NOT NULL,
+    "price" text NOT NULL,
+    "description" varchar NOT NULL
+);
+
+CREATE INDEX "products_name_idx" ON "products" ("name");
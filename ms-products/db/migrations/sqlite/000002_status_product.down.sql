@@ -0,0 +1,3 @@
+ALTER TABLE products DROP COLUMN "status";
+ALTER TABLE products DROP COLUMN "created_at";
+ALTER TABLE products DROP COLUMN "updated_at";
@@ -0,0 +1,7 @@
+ALTER TABLE products ADD COLUMN "status" varchar NOT NULL DEFAULT 'ACTIVE';
+-- ADD COLUMN only takes constant defaults, existing rows get the time of the migration
+ALTER TABLE products ADD COLUMN "created_at" timestamp NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN "updated_at" timestamp NOT NULL DEFAULT '';
+UPDATE products SET
+    "created_at" = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    "updated_at" = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
@@ -0,0 +1,25 @@
+CREATE TABLE "import_jobs" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "format" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "payload" blob NOT NULL,
+    "total_rows" integer NOT NULL DEFAULT 0,
+    "processed_rows" integer NOT NULL DEFAULT 0,
+    "created_rows" integer NOT NULL DEFAULT 0,
+    "updated_rows" integer NOT NULL DEFAULT 0,
+    "rejected_rows" integer NOT NULL DEFAULT 0,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    "updated_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE "import_job_errors" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "job_id" integer NOT NULL REFERENCES "import_jobs" ("id") ON DELETE CASCADE,
+    "line" integer NOT NULL,
+    "raw" varchar NOT NULL,
+    "message" varchar NOT NULL
+);
+
+CREATE INDEX "import_jobs_status_idx" ON "import_jobs" ("status");
+CREATE INDEX "import_job_errors_job_id_line_idx" ON "import_job_errors" ("job_id", "line");
@@ -0,0 +1,14 @@
+-- scopes hold a JSON array
+CREATE TABLE "api_keys" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" varchar NOT NULL,
+    "prefix" varchar NOT NULL,
+    "key_hash" blob UNIQUE NOT NULL,
+    "scopes" text NOT NULL,
+    "rate_limit" integer NOT NULL DEFAULT 0,
+    "rotated_from" integer REFERENCES "api_keys" ("id"),
+    "expires_at" timestamp,
+    "revoked_at" timestamp,
+    "last_used_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
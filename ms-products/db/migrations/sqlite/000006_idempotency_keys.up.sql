@@ -0,0 +1,14 @@
+CREATE TABLE "idempotency_keys" (
+    "scope" varchar NOT NULL,
+    "key" varchar NOT NULL,
+    "request_hash" blob NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'in_progress',
+    "response_status" integer NOT NULL DEFAULT 0,
+    "response_content_type" varchar NOT NULL DEFAULT '',
+    "response_body" blob NOT NULL DEFAULT x'',
+    "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    "expires_at" timestamp NOT NULL,
+    PRIMARY KEY ("scope", "key")
+);
+
+CREATE INDEX "idempotency_keys_expires_at_idx" ON "idempotency_keys" ("expires_at");
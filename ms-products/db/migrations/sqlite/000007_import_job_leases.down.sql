@@ -0,0 +1,2 @@
+ALTER TABLE import_jobs DROP COLUMN "claimed_by";
+ALTER TABLE import_jobs DROP COLUMN "lease_expires_at";
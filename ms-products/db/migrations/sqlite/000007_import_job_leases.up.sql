@@ -0,0 +1,2 @@
+ALTER TABLE import_jobs ADD COLUMN "claimed_by" varchar NOT NULL DEFAULT '';
+ALTER TABLE import_jobs ADD COLUMN "lease_expires_at" timestamp;
@@ -0,0 +1,7 @@
+CREATE TABLE "import_staging" (
+    "job_id" integer NOT NULL,
+    "line" integer NOT NULL,
+    "name" varchar NOT NULL,
+    "price" text NOT NULL,
+    "description" varchar NOT NULL
+);
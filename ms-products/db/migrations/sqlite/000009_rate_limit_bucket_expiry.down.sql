@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+CREATE TABLE "rate_limit_buckets" (
+    "key" varchar PRIMARY KEY,
+    "tokens" double precision NOT NULL,
+    "updated_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX "rate_limit_buckets_updated_at_idx" ON "rate_limit_buckets" ("updated_at");
@@ -0,0 +1,12 @@
+-- SQLite cannot add a column defaulting to the current time, and buckets only
+-- hold transient quota, so the table is created again
+DROP TABLE IF EXISTS rate_limit_buckets;
+
+CREATE TABLE "rate_limit_buckets" (
+    "key" varchar PRIMARY KEY,
+    "tokens" double precision NOT NULL,
+    "updated_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    "expires_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX "rate_limit_buckets_expires_at_idx" ON "rate_limit_buckets" ("expires_at");
@@ -0,0 +1,42 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name,
+  prefix,
+  key_hash,
+  scopes,
+  rate_limit,
+  rotated_from,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetAPIKey :one
+SELECT * FROM api_keys
+WHERE id = $1;
+
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY id;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpireAPIKey :one
+UPDATE api_keys
+SET expires_at = LEAST(expires_at, $1::timestamptz)
+WHERE id = $2
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
@@ -0,0 +1,40 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  scope,
+  key,
+  request_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (scope, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status = 'in_progress',
+    response_status = 0,
+    response_content_type = '',
+    response_body = '',
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+   OR (idempotency_keys.status = 'in_progress' AND idempotency_keys.created_at < sqlc.arg(abandoned_before))
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status = 'completed',
+    response_status = $1,
+    response_content_type = $2,
+    response_body = $3
+WHERE scope = $4 AND key = $5;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at < now();
@@ -0,0 +1,95 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (
+  format,
+  total_rows,
+  payload
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetImportJob :one
+SELECT * FROM import_jobs
+WHERE id = $1;
+
+-- name: ClaimImportJob :one
+UPDATE import_jobs
+SET status = 'running',
+    claimed_by = sqlc.arg(claimed_by),
+    lease_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::integer),
+    updated_at = now()
+WHERE id = (
+  SELECT id FROM import_jobs
+  WHERE status = 'pending'
+     OR (status = 'running' AND (lease_expires_at IS NULL OR lease_expires_at <= now()))
+  ORDER BY id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateImportJobStatus :one
+UPDATE import_jobs
+SET status = sqlc.arg(status),
+    error = sqlc.arg(error),
+    lease_expires_at = NULL,
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND claimed_by = sqlc.arg(claimed_by)
+RETURNING *;
+
+-- name: UpdateImportJobProgress :one
+UPDATE import_jobs
+SET processed_rows = sqlc.arg(processed_rows),
+    created_rows = sqlc.arg(created_rows),
+    updated_rows = sqlc.arg(updated_rows),
+    rejected_rows = sqlc.arg(rejected_rows),
+    lease_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::integer),
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND claimed_by = sqlc.arg(claimed_by) AND status = 'running'
+RETURNING *;
+
+-- name: CreateImportJobErrors :copyfrom
+INSERT INTO import_job_errors (
+  job_id,
+  line,
+  raw,
+  message
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ListImportJobErrors :many
+SELECT * FROM import_job_errors
+WHERE job_id = $1
+ORDER BY line;
+
+-- name: CreateImportStaging :copyfrom
+INSERT INTO import_staging (
+  job_id,
+  line,
+  name,
+  price,
+  description
+) VALUES (
+  $1, $2, $3, $4, $5
+);
+
+-- name: UpsertStagedProducts :one
+WITH staged AS (
+  DELETE FROM import_staging
+  WHERE job_id = $1
+  RETURNING line, name, price, description
+), upserted AS (
+  INSERT INTO products (
+    name,
+    price,
+    description
+  )
+  SELECT name, price, description FROM staged
+  ORDER BY line
+  ON CONFLICT (name) DO UPDATE
+  SET price = EXCLUDED.price, description = EXCLUDED.description, updated_at = now()
+  RETURNING (xmax = 0) AS inserted
+)
+SELECT count(*) FILTER (WHERE inserted) AS created_rows,
+       count(*) FILTER (WHERE NOT inserted) AS updated_rows
+FROM upserted;
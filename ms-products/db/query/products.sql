@@ -21,4 +21,31 @@ OFFSET $2;
 UPDATE products
 SET status = $1, updated_at = now()
 WHERE id = $2 
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetProductsByIDs :many
+SELECT * FROM products
+WHERE id = ANY(sqlc.arg(ids)::int[]);
+
+-- name: UpdateProduct :one
+UPDATE products
+SET name = $1, price = $2, description = $3, updated_at = now()
+WHERE id = $4
+RETURNING *;
+
+-- name: UpsertProduct :one
+INSERT INTO products (
+   name,
+   price,
+   description
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (name) DO UPDATE
+SET price = EXCLUDED.price, description = EXCLUDED.description, updated_at = now()
+RETURNING id, name, price, description, status, created_at, updated_at, (xmax = 0)::boolean AS inserted;
+
+-- name: CountProductsByStatus :many
+SELECT status, count(*) AS count FROM products
+GROUP BY status
+ORDER BY status;
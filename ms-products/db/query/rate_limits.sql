@@ -0,0 +1,18 @@
+-- name: LockRateLimitBucket :one
+INSERT INTO rate_limit_buckets (
+  key,
+  tokens
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+RETURNING tokens, updated_at, now()::timestamptz AS now;
+
+-- name: UpdateRateLimitBucket :exec
+UPDATE rate_limit_buckets
+SET tokens = $1, updated_at = $2, expires_at = $3
+WHERE key = $4;
+
+-- name: DeleteExpiredRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE expires_at < $1;
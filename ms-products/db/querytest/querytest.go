@@ -0,0 +1,656 @@
+// Package querytest is the conformance suite for db.Querier implementations.
+// It runs against Postgres in db/sqlc, SQLite in db/sqlite and the in-memory
+// querier in db/memdb, so all of them answer every query the same way.
+package querytest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the suite. newQuerier returns a querier over empty tables whose
+// sequences start at 1, one per case. A failed statement aborts a Postgres
+// transaction, so a case that expects an error ends with it.
+func Run(t *testing.T, newQuerier func(t *testing.T) db.Querier) {
+	cases := map[string]func(t *testing.T, q db.Querier){
+		"ProductSerialIDs":          testProductSerialIDs,
+		"ProductPriceScale":         testProductPriceScale,
+		"ProductNotFound":           testProductNotFound,
+		"ProductUniqueName":         testProductUniqueName,
+		"ProductRenameUniqueName":   testProductRenameUniqueName,
+		"ListProducts":              testListProducts,
+		"ListProductsNegativeLimit": testListProductsNegativeLimit,
+		"UpdateProduct":             testUpdateProduct,
+		"UpdateProductStatus":       testUpdateProductStatus,
+		"UpsertProduct":             testUpsertProduct,
+		"GetProductsByIDs":          testGetProductsByIDs,
+		"CountProductsByStatus":     testCountProductsByStatus,
+		"ImportJobs":                testImportJobs,
+		"ImportJobLeaseExpiry":      testImportJobLeaseExpiry,
+		"ImportJobErrors":           testImportJobErrors,
+		"ImportJobErrorUnknownJob":  testImportJobErrorUnknownJob,
+		"UpsertStagedProducts":      testUpsertStagedProducts,
+		"ImportStagingPriceScale":   testImportStagingPriceScale,
+		"APIKeys":                   testAPIKeys,
+		"APIKeyUniqueHash":          testAPIKeyUniqueHash,
+		"APIKeyUnknownRotatedFrom":  testAPIKeyUnknownRotatedFrom,
+		"IdempotencyKeys":           testIdempotencyKeys,
+		"IdempotencyKeyTakeover":    testIdempotencyKeyTakeover,
+		"RateLimitBuckets":          testRateLimitBuckets,
+	}
+
+	for name, run := range cases {
+		run := run
+		t.Run(name, func(t *testing.T) {
+			run(t, newQuerier(t))
+		})
+	}
+}
+
+func createProduct(t *testing.T, q db.Querier, name, price string) db.Product {
+	product, err := q.CreateProduct(context.Background(), db.CreateProductParams{
+		Name:        name,
+		Price:       price,
+		Description: name + " description",
+	})
+	require.NoError(t, err)
+
+	return product
+}
+
+// requireDBError checks the constraint only when given, SQLite cannot name
+// the foreign keys.
+func requireDBError(t *testing.T, err error, code string, constraint string) {
+	var dbErr *db.Error
+	require.True(t, errors.As(err, &dbErr), "want *db.Error, got %v", err)
+	require.Equal(t, code, dbErr.Code)
+	if constraint != "" {
+		require.Equal(t, constraint, dbErr.Constraint)
+	}
+}
+
+func testProductSerialIDs(t *testing.T, q db.Querier) {
+	for i, name := range []string{"pen", "ink", "paper"} {
+		product := createProduct(t, q, name, "1.00")
+		require.Equal(t, int32(i+1), product.ID)
+		require.Equal(t, "ACTIVE", product.Status)
+		require.Equal(t, product.CreatedAt, product.UpdatedAt)
+	}
+}
+
+func testProductPriceScale(t *testing.T, q db.Querier) {
+	prices := map[string]string{
+		"10":      "10.00",
+		"1.005":   "1.01",
+		"-2.345":  "-2.35",
+		"0.001":   "0.00",
+		"1e3":     "1000.00",
+		"9999.99": "9999.99",
+	}
+
+	for price, want := range prices {
+		product := createProduct(t, q, "product "+price, price)
+		require.Equal(t, want, product.Price)
+
+		stored, err := q.GetProduct(context.Background(), product.ID)
+		require.NoError(t, err)
+		require.Equal(t, want, stored.Price)
+	}
+}
+
+func testProductNotFound(t *testing.T, q db.Querier) {
+	_, err := q.GetProduct(context.Background(), 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = q.UpdateProductStatus(context.Background(), db.UpdateProductStatusParams{ID: 1, Status: "inactive"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = q.UpdateProduct(context.Background(), db.UpdateProductParams{ID: 1, Name: "pen", Price: "1.00"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testProductUniqueName(t *testing.T, q db.Querier) {
+	createProduct(t, q, "pen", "1.00")
+
+	_, err := q.CreateProduct(context.Background(), db.CreateProductParams{Name: "pen", Price: "2.00"})
+	requireDBError(t, err, db.CodeUniqueViolation, "products_name_key")
+}
+
+func testProductRenameUniqueName(t *testing.T, q db.Querier) {
+	createProduct(t, q, "pen", "1.00")
+	ink := createProduct(t, q, "ink", "1.00")
+
+	_, err := q.UpdateProduct(context.Background(), db.UpdateProductParams{ID: ink.ID, Name: "pen", Price: "1.00"})
+	requireDBError(t, err, db.CodeUniqueViolation, "products_name_key")
+}
+
+func testListProducts(t *testing.T, q db.Querier) {
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		createProduct(t, q, name, "1.00")
+	}
+
+	products, err := q.ListProducts(context.Background(), db.ListProductsParams{Limit: 3, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, products, 3)
+	for i, product := range products {
+		require.Equal(t, int32(i+3), product.ID)
+	}
+
+	products, err = q.ListProducts(context.Background(), db.ListProductsParams{Limit: 5, Offset: 5})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+
+	products, err = q.ListProducts(context.Background(), db.ListProductsParams{Limit: 5, Offset: 10})
+	require.NoError(t, err)
+	require.Empty(t, products)
+
+	products, err = q.ListProducts(context.Background(), db.ListProductsParams{Limit: 0, Offset: 0})
+	require.NoError(t, err)
+	require.Empty(t, products)
+}
+
+func testListProductsNegativeLimit(t *testing.T, q db.Querier) {
+	_, err := q.ListProducts(context.Background(), db.ListProductsParams{Limit: -1})
+	requireDBError(t, err, db.CodeNegativeLimit, "")
+}
+
+func testUpdateProduct(t *testing.T, q db.Querier) {
+	product := createProduct(t, q, "pen", "1.00")
+
+	updated, err := q.UpdateProduct(context.Background(), db.UpdateProductParams{
+		ID:          product.ID,
+		Name:        "blue pen",
+		Price:       "2.5",
+		Description: "a blue pen",
+	})
+	require.NoError(t, err)
+	require.Equal(t, product.ID, updated.ID)
+	require.Equal(t, "blue pen", updated.Name)
+	require.Equal(t, "2.50", updated.Price)
+	require.Equal(t, "a blue pen", updated.Description)
+	require.Equal(t, product.Status, updated.Status)
+	require.True(t, product.CreatedAt.Equal(updated.CreatedAt))
+	require.False(t, updated.UpdatedAt.Before(product.UpdatedAt))
+
+	// keeping its own name is not a conflict
+	_, err = q.UpdateProduct(context.Background(), db.UpdateProductParams{ID: product.ID, Name: "blue pen", Price: "3.00"})
+	require.NoError(t, err)
+}
+
+func testUpdateProductStatus(t *testing.T, q db.Querier) {
+	product := createProduct(t, q, "pen", "1.00")
+
+	updated, err := q.UpdateProductStatus(context.Background(), db.UpdateProductStatusParams{ID: product.ID, Status: "inactive"})
+	require.NoError(t, err)
+	require.Equal(t, "inactive", updated.Status)
+	require.False(t, updated.UpdatedAt.Before(product.UpdatedAt))
+
+	stored, err := q.GetProduct(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, stored)
+}
+
+func testUpsertProduct(t *testing.T, q db.Querier) {
+	inserted, err := q.UpsertProduct(context.Background(), db.UpsertProductParams{Name: "pen", Price: "1", Description: "pen"})
+	require.NoError(t, err)
+	require.True(t, inserted.Inserted)
+	require.Equal(t, int32(1), inserted.ID)
+	require.Equal(t, "1.00", inserted.Price)
+
+	updated, err := q.UpsertProduct(context.Background(), db.UpsertProductParams{Name: "pen", Price: "2", Description: "blue pen"})
+	require.NoError(t, err)
+	require.False(t, updated.Inserted)
+	require.Equal(t, int32(1), updated.ID)
+	require.Equal(t, "2.00", updated.Price)
+	require.Equal(t, "blue pen", updated.Description)
+	require.True(t, inserted.CreatedAt.Equal(updated.CreatedAt))
+
+	// Postgres draws an id for the conflicting insert, SQLite does not
+	product := createProduct(t, q, "ink", "1.00")
+	require.Greater(t, product.ID, updated.ID)
+}
+
+func testGetProductsByIDs(t *testing.T, q db.Querier) {
+	pen := createProduct(t, q, "pen", "1.00")
+	createProduct(t, q, "ink", "1.00")
+	paper := createProduct(t, q, "paper", "1.00")
+
+	products, err := q.GetProductsByIDs(context.Background(), []int32{paper.ID, pen.ID, pen.ID, 99})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []db.Product{pen, paper}, products)
+
+	products, err = q.GetProductsByIDs(context.Background(), []int32{})
+	require.NoError(t, err)
+	require.Empty(t, products)
+}
+
+func testCountProductsByStatus(t *testing.T, q db.Querier) {
+	statuses := []string{"available", "out_of_stock", "available", "inactive"}
+	for i, status := range statuses {
+		product := createProduct(t, q, string(rune('a'+i)), "1.00")
+		_, err := q.UpdateProductStatus(context.Background(), db.UpdateProductStatusParams{ID: product.ID, Status: status})
+		require.NoError(t, err)
+	}
+
+	rows, err := q.CountProductsByStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []db.CountProductsByStatusRow{
+		{Status: "available", Count: 2},
+		{Status: "inactive", Count: 1},
+		{Status: "out_of_stock", Count: 1},
+	}, rows)
+}
+
+func testImportJobs(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	job, err := q.CreateImportJob(ctx, db.CreateImportJobParams{Format: "csv", TotalRows: 3, Payload: []byte("a,b\n")})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), job.ID)
+	require.Equal(t, "pending", job.Status)
+	require.Equal(t, []byte("a,b\n"), job.Payload)
+	require.Equal(t, int32(3), job.TotalRows)
+	require.Zero(t, job.ProcessedRows)
+	require.Empty(t, job.Error)
+
+	second, err := q.CreateImportJob(ctx, db.CreateImportJobParams{Format: "jsonl", Payload: []byte("{}")})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), second.ID)
+
+	claimed, err := q.ClaimImportJob(ctx, db.ClaimImportJobParams{ClaimedBy: "a", LeaseSeconds: 60})
+	require.NoError(t, err)
+	require.Equal(t, job.ID, claimed.ID)
+	require.Equal(t, "running", claimed.Status)
+	require.Equal(t, "a", claimed.ClaimedBy)
+	require.True(t, claimed.LeaseExpiresAt.Valid)
+
+	// the first job is leased to a
+	claimed, err = q.ClaimImportJob(ctx, db.ClaimImportJobParams{ClaimedBy: "b", LeaseSeconds: 60})
+	require.NoError(t, err)
+	require.Equal(t, second.ID, claimed.ID)
+
+	_, err = q.ClaimImportJob(ctx, db.ClaimImportJobParams{ClaimedBy: "c", LeaseSeconds: 60})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = q.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{ID: job.ID, ClaimedBy: "b", ProcessedRows: 3})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	progress, err := q.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{
+		ID:            job.ID,
+		ClaimedBy:     "a",
+		ProcessedRows: 3,
+		CreatedRows:   1,
+		UpdatedRows:   1,
+		RejectedRows:  1,
+		LeaseSeconds:  60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), progress.ProcessedRows)
+	require.Equal(t, int32(1), progress.RejectedRows)
+	require.True(t, progress.LeaseExpiresAt.Valid)
+	require.False(t, progress.UpdatedAt.Before(job.UpdatedAt))
+
+	_, err = q.UpdateImportJobStatus(ctx, db.UpdateImportJobStatusParams{ID: job.ID, ClaimedBy: "b", Status: "completed"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	failed, err := q.UpdateImportJobStatus(ctx, db.UpdateImportJobStatusParams{ID: job.ID, ClaimedBy: "a", Status: "failed", Error: "boom"})
+	require.NoError(t, err)
+	require.Equal(t, "failed", failed.Status)
+	require.Equal(t, "boom", failed.Error)
+	require.Equal(t, int32(3), failed.ProcessedRows)
+	require.False(t, failed.LeaseExpiresAt.Valid)
+
+	stored, err := q.GetImportJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, failed, stored)
+
+	// a finished job takes no more progress, nor is it claimed again
+	_, err = q.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{ID: job.ID, ClaimedBy: "a", ProcessedRows: 4})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = q.GetImportJob(ctx, 99)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = q.UpdateImportJobStatus(ctx, db.UpdateImportJobStatusParams{ID: 99, Status: "failed"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testImportJobLeaseExpiry(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	job, err := q.CreateImportJob(ctx, db.CreateImportJobParams{Format: "csv", Payload: []byte{}})
+	require.NoError(t, err)
+
+	_, err = q.ClaimImportJob(ctx, db.ClaimImportJobParams{ClaimedBy: "a", LeaseSeconds: 0})
+	require.NoError(t, err)
+
+	// a let its lease expire, so b takes the job over
+	claimed, err := q.ClaimImportJob(ctx, db.ClaimImportJobParams{ClaimedBy: "b", LeaseSeconds: 60})
+	require.NoError(t, err)
+	require.Equal(t, job.ID, claimed.ID)
+	require.Equal(t, "b", claimed.ClaimedBy)
+
+	_, err = q.UpdateImportJobProgress(ctx, db.UpdateImportJobProgressParams{ID: job.ID, ClaimedBy: "a", ProcessedRows: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testImportJobErrors(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	job, err := q.CreateImportJob(ctx, db.CreateImportJobParams{Format: "csv", Payload: []byte{}})
+	require.NoError(t, err)
+	other, err := q.CreateImportJob(ctx, db.CreateImportJobParams{Format: "csv", Payload: []byte{}})
+	require.NoError(t, err)
+
+	n, err := q.CreateImportJobErrors(ctx, []db.CreateImportJobErrorsParams{
+		{JobID: job.ID, Line: 7, Raw: "raw", Message: "bad"},
+		{JobID: job.ID, Line: 2, Raw: "raw", Message: "bad"},
+		{JobID: job.ID, Line: 5, Raw: "raw", Message: "bad"},
+		{JobID: other.ID, Line: 1, Raw: "raw", Message: "bad"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), n)
+
+	n, err = q.CreateImportJobErrors(ctx, nil)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	errs, err := q.ListImportJobErrors(ctx, job.ID)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+	for i, line := range []int32{2, 5, 7} {
+		require.Equal(t, line, errs[i].Line)
+		require.Equal(t, job.ID, errs[i].JobID)
+	}
+	require.Equal(t, int64(1), errs[2].ID)
+
+	errs, err = q.ListImportJobErrors(ctx, 99)
+	require.NoError(t, err)
+	require.Empty(t, errs)
+}
+
+func testImportJobErrorUnknownJob(t *testing.T, q db.Querier) {
+	job, err := q.CreateImportJob(context.Background(), db.CreateImportJobParams{Format: "csv", Payload: []byte{}})
+	require.NoError(t, err)
+
+	_, err = q.CreateImportJobErrors(context.Background(), []db.CreateImportJobErrorsParams{
+		{JobID: job.ID, Line: 1},
+		{JobID: 99, Line: 2},
+	})
+	requireDBError(t, err, db.CodeForeignKeyViolation, "")
+}
+
+func testUpsertStagedProducts(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+	pen := createProduct(t, q, "pen", "1.00")
+
+	n, err := q.CreateImportStaging(ctx, []db.CreateImportStagingParams{
+		{JobID: 1, Line: 3, Name: "ink", Price: "2.5", Description: "ink"},
+		{JobID: 1, Line: 2, Name: "pen", Price: "3", Description: "blue pen"},
+		{JobID: 2, Line: 1, Name: "cap", Price: "1", Description: "cap"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	counts, err := q.UpsertStagedProducts(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, db.UpsertStagedProductsRow{CreatedRows: 1, UpdatedRows: 1}, counts)
+
+	products, err := q.ListProducts(ctx, db.ListProductsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	require.Equal(t, pen.ID, products[0].ID)
+	require.Equal(t, "3.00", products[0].Price)
+	require.Equal(t, "blue pen", products[0].Description)
+	require.True(t, pen.CreatedAt.Equal(products[0].CreatedAt))
+	require.Equal(t, "ink", products[1].Name)
+	require.Equal(t, "2.50", products[1].Price)
+
+	// the staged rows of a job are consumed, those of other jobs are kept
+	counts, err = q.UpsertStagedProducts(ctx, 1)
+	require.NoError(t, err)
+	require.Zero(t, counts)
+
+	counts, err = q.UpsertStagedProducts(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, db.UpsertStagedProductsRow{CreatedRows: 1}, counts)
+}
+
+func testImportStagingPriceScale(t *testing.T, q db.Querier) {
+	_, err := q.CreateImportStaging(context.Background(), []db.CreateImportStagingParams{
+		{JobID: 1, Line: 1, Name: "pen", Price: "1", Description: "pen"},
+		{JobID: 1, Line: 2, Name: "ink", Price: "1e12", Description: "ink"},
+	})
+	requireDBError(t, err, db.CodeNumericOverflow, "")
+}
+
+func createAPIKey(t *testing.T, q db.Querier, hash string, rotatedFrom sql.NullInt32) db.ApiKey {
+	key, err := q.CreateAPIKey(context.Background(), db.CreateAPIKeyParams{
+		Name:        "key " + hash,
+		Prefix:      "pk_" + hash,
+		KeyHash:     []byte(hash),
+		Scopes:      []string{"products:read"},
+		RateLimit:   10,
+		RotatedFrom: rotatedFrom,
+	})
+	require.NoError(t, err)
+
+	return key
+}
+
+func testAPIKeys(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	first := createAPIKey(t, q, "first", sql.NullInt32{})
+	require.Equal(t, int32(1), first.ID)
+	require.Equal(t, []string{"products:read"}, first.Scopes)
+	require.False(t, first.ExpiresAt.Valid)
+	require.False(t, first.RevokedAt.Valid)
+	require.False(t, first.LastUsedAt.Valid)
+
+	second := createAPIKey(t, q, "second", sql.NullInt32{Int32: first.ID, Valid: true})
+	require.Equal(t, first.ID, second.RotatedFrom.Int32)
+
+	byHash, err := q.GetAPIKeyByHash(ctx, []byte("second"))
+	require.NoError(t, err)
+	require.Equal(t, second, byHash)
+
+	_, err = q.GetAPIKeyByHash(ctx, []byte("missing"))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	keys, err := q.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []db.ApiKey{first, second}, keys)
+
+	// revoking twice keeps the first revocation time
+	revoked, err := q.RevokeAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+	again, err := q.RevokeAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Time.Equal(again.RevokedAt.Time))
+
+	// expiry only moves earlier
+	soon := time.Now().Add(time.Hour)
+	expired, err := q.ExpireAPIKey(ctx, db.ExpireAPIKeyParams{ID: second.ID, ExpiresAt: soon})
+	require.NoError(t, err)
+	require.WithinDuration(t, soon, expired.ExpiresAt.Time, time.Millisecond)
+	later, err := q.ExpireAPIKey(ctx, db.ExpireAPIKeyParams{ID: second.ID, ExpiresAt: soon.Add(time.Hour)})
+	require.NoError(t, err)
+	require.True(t, expired.ExpiresAt.Time.Equal(later.ExpiresAt.Time))
+
+	// a recent touch is not written again
+	require.NoError(t, q.TouchAPIKey(ctx, second.ID))
+	touched, err := q.GetAPIKey(ctx, second.ID)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+	require.NoError(t, q.TouchAPIKey(ctx, second.ID))
+	retouched, err := q.GetAPIKey(ctx, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, touched, retouched)
+
+	require.NoError(t, q.TouchAPIKey(ctx, 99))
+	_, err = q.RevokeAPIKey(ctx, 99)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testAPIKeyUniqueHash(t *testing.T, q db.Querier) {
+	createAPIKey(t, q, "hash", sql.NullInt32{})
+
+	_, err := q.CreateAPIKey(context.Background(), db.CreateAPIKeyParams{KeyHash: []byte("hash"), Scopes: []string{}})
+	requireDBError(t, err, db.CodeUniqueViolation, "api_keys_key_hash_key")
+}
+
+func testAPIKeyUnknownRotatedFrom(t *testing.T, q db.Querier) {
+	_, err := q.CreateAPIKey(context.Background(), db.CreateAPIKeyParams{
+		KeyHash:     []byte("hash"),
+		Scopes:      []string{},
+		RotatedFrom: sql.NullInt32{Int32: 99, Valid: true},
+	})
+	requireDBError(t, err, db.CodeForeignKeyViolation, "")
+}
+
+func testIdempotencyKeys(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+	id := db.GetIdempotencyKeyParams{Scope: "client", Key: "key"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	key, err := q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		Scope:           id.Scope,
+		Key:             id.Key,
+		RequestHash:     []byte("hash"),
+		ExpiresAt:       expiresAt,
+		AbandonedBefore: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "in_progress", key.Status)
+	require.Zero(t, key.ResponseStatus)
+	require.Empty(t, key.ResponseBody)
+	require.WithinDuration(t, expiresAt, key.ExpiresAt, time.Millisecond)
+
+	// a live key cannot be claimed again
+	_, err = q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		Scope:           id.Scope,
+		Key:             id.Key,
+		RequestHash:     []byte("other"),
+		ExpiresAt:       expiresAt,
+		AbandonedBefore: time.Now().Add(-time.Minute),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = q.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Scope:               id.Scope,
+		Key:                 id.Key,
+		ResponseStatus:      201,
+		ResponseContentType: "application/json",
+		ResponseBody:        []byte(`{"id":1}`),
+	})
+	require.NoError(t, err)
+
+	completed, err := q.GetIdempotencyKey(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "completed", completed.Status)
+	require.Equal(t, int32(201), completed.ResponseStatus)
+	require.Equal(t, []byte(`{"id":1}`), completed.ResponseBody)
+	require.Equal(t, []byte("hash"), completed.RequestHash)
+
+	// completed keys are not abandoned, however old
+	_, err = q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		Scope:           id.Scope,
+		Key:             id.Key,
+		RequestHash:     []byte("other"),
+		ExpiresAt:       expiresAt,
+		AbandonedBefore: time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, q.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Scope: id.Scope, Key: id.Key}))
+	_, err = q.GetIdempotencyKey(ctx, id)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testIdempotencyKeyTakeover(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	create := func(key string, hash string, expiresAt, abandonedBefore time.Time) (db.IdempotencyKey, error) {
+		return q.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			Scope:           "client",
+			Key:             key,
+			RequestHash:     []byte(hash),
+			ExpiresAt:       expiresAt,
+			AbandonedBefore: abandonedBefore,
+		})
+	}
+
+	// an abandoned key, still in progress, is taken over
+	_, err := create("abandoned", "first", time.Now().Add(time.Hour), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	taken, err := create("abandoned", "second", time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []byte("second"), taken.RequestHash)
+
+	// an expired key is taken over, whatever its status
+	_, err = create("expired", "first", time.Now().Add(-time.Hour), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, q.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Scope:        "client",
+		Key:          "expired",
+		ResponseBody: []byte("done"),
+	}))
+	taken, err = create("expired", "second", time.Now().Add(time.Hour), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "in_progress", taken.Status)
+	require.Empty(t, taken.ResponseBody)
+
+	_, err = create("stale", "first", time.Now().Add(-time.Hour), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.NoError(t, q.DeleteExpiredIdempotencyKeys(ctx))
+
+	_, err = q.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Scope: "client", Key: "expired"})
+	require.NoError(t, err)
+	_, err = q.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Scope: "client", Key: "stale"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testRateLimitBuckets(t *testing.T, q db.Querier) {
+	ctx := context.Background()
+
+	bucket, err := q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "client", Tokens: 10})
+	require.NoError(t, err)
+	require.Equal(t, float64(10), bucket.Tokens)
+	require.True(t, bucket.UpdatedAt.Equal(bucket.Now))
+
+	// an existing bucket keeps its tokens
+	bucket, err = q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "client", Tokens: 20})
+	require.NoError(t, err)
+	require.Equal(t, float64(10), bucket.Tokens)
+
+	updatedAt := time.Now().Add(-time.Hour)
+	require.NoError(t, q.UpdateRateLimitBucket(ctx, db.UpdateRateLimitBucketParams{Key: "client", Tokens: 4.5, UpdatedAt: updatedAt, ExpiresAt: updatedAt.Add(time.Minute)}))
+
+	bucket, err = q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "client", Tokens: 10})
+	require.NoError(t, err)
+	require.Equal(t, 4.5, bucket.Tokens)
+	require.WithinDuration(t, updatedAt, bucket.UpdatedAt, time.Millisecond)
+
+	// a bucket with a longer period outlives one used at the same time
+	_, err = q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "other", Tokens: 10})
+	require.NoError(t, err)
+	require.NoError(t, q.UpdateRateLimitBucket(ctx, db.UpdateRateLimitBucketParams{Key: "other", Tokens: 10, UpdatedAt: updatedAt, ExpiresAt: updatedAt.Add(2 * time.Hour)}))
+
+	require.NoError(t, q.DeleteExpiredRateLimitBuckets(ctx, time.Now()))
+
+	// a deleted bucket starts full again
+	bucket, err = q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "client", Tokens: 10})
+	require.NoError(t, err)
+	require.Equal(t, float64(10), bucket.Tokens)
+	bucket, err = q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "other", Tokens: 20})
+	require.NoError(t, err)
+	require.Equal(t, float64(10), bucket.Tokens)
+}
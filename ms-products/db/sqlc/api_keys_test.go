@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T) ApiKey {
+	hash := sha256.Sum256([]byte(utils.RandomString(32, utils.DefaultAlphabet)))
+	arg := CreateAPIKeyParams{
+		Name:      utils.RandomProductName(),
+		Prefix:    utils.RandomString(8, utils.DefaultAlphabet),
+		KeyHash:   hash[:],
+		Scopes:    []string{"products:create", "imports:read"},
+		RateLimit: 60,
+	}
+
+	key, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, key.ID)
+	require.Equal(t, arg.Name, key.Name)
+	require.Equal(t, arg.Prefix, key.Prefix)
+	require.Equal(t, arg.KeyHash, key.KeyHash)
+	require.Equal(t, arg.Scopes, key.Scopes)
+	require.Equal(t, arg.RateLimit, key.RateLimit)
+	require.False(t, key.ExpiresAt.Valid)
+	require.False(t, key.RevokedAt.Valid)
+	require.False(t, key.LastUsedAt.Valid)
+
+	return key
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	createRandomAPIKey(t)
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	key := createRandomAPIKey(t)
+
+	key2, err := testQueries.GetAPIKeyByHash(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, key2.ID)
+
+	_, err = testQueries.GetAPIKeyByHash(context.Background(), []byte("unknown"))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	key := createRandomAPIKey(t)
+
+	key2, err := testQueries.RevokeAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, key2.RevokedAt.Valid)
+
+	key3, err := testQueries.RevokeAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.Equal(t, key2.RevokedAt, key3.RevokedAt)
+}
+
+func TestExpireAPIKey(t *testing.T) {
+	key := createRandomAPIKey(t)
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	key2, err := testQueries.ExpireAPIKey(context.Background(), ExpireAPIKeyParams{ID: key.ID, ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	require.WithinDuration(t, expiresAt, key2.ExpiresAt.Time, time.Second)
+
+	key3, err := testQueries.ExpireAPIKey(context.Background(), ExpireAPIKeyParams{ID: key.ID, ExpiresAt: expiresAt.Add(time.Hour)})
+	require.NoError(t, err)
+	require.WithinDuration(t, expiresAt, key3.ExpiresAt.Time, time.Second)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	key := createRandomAPIKey(t)
+
+	err := testQueries.TouchAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+
+	key2, err := testQueries.GetAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, key2.LastUsedAt.Valid)
+	require.WithinDuration(t, time.Now(), key2.LastUsedAt.Time, time.Minute)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	key := createRandomAPIKey(t)
+
+	keys, err := testQueries.ListAPIKeys(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, keys, key)
+}
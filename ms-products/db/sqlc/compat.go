@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// conn is what both a pool and a transaction offer.
+type conn interface {
+	DBTX
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// newConn wraps a pool or a transaction for the queries.
+func newConn(c conn) conn {
+	return instrument(compat(c))
+}
+
+// compatDBTX returns the errors memdb and sqlite return too: sql.ErrNoRows
+// for a missing row and *Error for an error raised by the server.
+type compatDBTX struct {
+	db conn
+}
+
+func compat(db conn) conn {
+	return &compatDBTX{db: db}
+}
+
+func (c *compatDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := c.db.Exec(ctx, query, args...)
+	return tag, compatError(err)
+}
+
+func (c *compatDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := c.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, compatError(err)
+	}
+
+	return compatRows{rows}, nil
+}
+
+func (c *compatDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return compatRow{c.db.QueryRow(ctx, query, args...)}
+}
+
+func (c *compatDBTX) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	n, err := c.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return n, compatError(err)
+}
+
+func (c *compatDBTX) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return compatBatch{c.db.SendBatch(ctx, b)}
+}
+
+type compatRows struct {
+	pgx.Rows
+}
+
+func (r compatRows) Err() error {
+	return compatError(r.Rows.Err())
+}
+
+func (r compatRows) Scan(dest ...any) error {
+	return compatError(r.Rows.Scan(dest...))
+}
+
+type compatRow struct {
+	row pgx.Row
+}
+
+func (r compatRow) Scan(dest ...any) error {
+	return compatError(r.row.Scan(dest...))
+}
+
+type compatBatch struct {
+	results pgx.BatchResults
+}
+
+func (b compatBatch) Exec() (pgconn.CommandTag, error) {
+	tag, err := b.results.Exec()
+	return tag, compatError(err)
+}
+
+func (b compatBatch) Query() (pgx.Rows, error) {
+	rows, err := b.results.Query()
+	if err != nil {
+		return nil, compatError(err)
+	}
+
+	return compatRows{rows}, nil
+}
+
+func (b compatBatch) QueryRow() pgx.Row {
+	return compatRow{b.results.QueryRow()}
+}
+
+func (b compatBatch) Close() error {
+	return compatError(b.results.Close())
+}
+
+func compatError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	return &Error{
+		Code:       pgErr.Code,
+		Message:    pgErr.Message,
+		Detail:     pgErr.Detail,
+		Table:      pgErr.TableName,
+		Constraint: pgErr.ConstraintName,
+	}
+}
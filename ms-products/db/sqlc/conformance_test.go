@@ -0,0 +1,30 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/djudju12/ms-products/db/querytest"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// truncateTables empties the tables and restarts their sequences inside the
+// case's transaction, so the rollback leaves the data of other tests alone.
+const truncateTables = `
+TRUNCATE products, import_jobs, import_job_errors, import_staging, api_keys, rate_limit_buckets, idempotency_keys
+RESTART IDENTITY CASCADE
+`
+
+func TestConformance(t *testing.T) {
+	querytest.Run(t, func(t *testing.T) db.Querier {
+		tx, err := db.PoolForTest().Begin(context.Background())
+		require.NoError(t, err)
+		t.Cleanup(func() { tx.Rollback(context.Background()) })
+
+		_, err = tx.Exec(context.Background(), truncateTables)
+		require.NoError(t, err)
+
+		return db.NewForTest(tx)
+	})
+}
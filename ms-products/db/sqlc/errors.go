@@ -0,0 +1,42 @@
+package db
+
+import "errors"
+
+// SQLSTATE codes of the errors the services act on. memdb and sqlite report
+// their failures with the code Postgres would have used.
+const (
+	CodeNotNullViolation    = "23502"
+	CodeForeignKeyViolation = "23503"
+	CodeUniqueViolation     = "23505"
+	CodeCheckViolation      = "23514"
+	CodeInvalidText         = "22P02"
+	CodeNumericOverflow     = "22003"
+	CodeNegativeLimit       = "2201W"
+	CodeNegativeOffset      = "2201X"
+)
+
+// Error is an error raised by the database, whatever the backend. Constraint
+// is the Postgres name of the violated constraint, like products_name_key,
+// when the backend can tell it.
+type Error struct {
+	Code       string
+	Message    string
+	Detail     string
+	Table      string
+	Constraint string
+}
+
+func (e *Error) Error() string {
+	return "db: " + e.Message
+}
+
+// ErrorCode returns the SQLSTATE code of err, or an empty string when err was
+// not raised by the database.
+func ErrorCode(err error) string {
+	var dbErr *Error
+	if errors.As(err, &dbErr) {
+		return dbErr.Code
+	}
+
+	return ""
+}
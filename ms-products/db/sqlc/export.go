@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const declareExportCursor = `
+DECLARE export_products NO SCROLL CURSOR FOR
+SELECT id, name, price, description, status, created_at, updated_at FROM products
+WHERE ($1::varchar = '' OR status = $1)
+  AND ($2::timestamptz IS NULL OR updated_at >= $2)
+ORDER BY id
+`
+
+type ExportProductsParams struct {
+	Status       string       `json:"status"`
+	UpdatedSince sql.NullTime `json:"updated_since"`
+	FetchSize    int32        `json:"fetch_size"`
+}
+
+// ExportProducts walks the filtered catalog through a server side cursor,
+// FetchSize rows at a time, inside a read only repeatable read transaction so
+// the whole export sees a single snapshot.
+func (store *SQLStore) ExportProducts(ctx context.Context, arg ExportProductsParams, fn func(Product) error) error {
+	tx, err := store.db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	c := compat(tx)
+	_, err = c.Exec(ctx, declareExportCursor, arg.Status, arg.UpdatedSince)
+	if err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_products", max(arg.FetchSize, 1))
+	for {
+		n, err := fetchExportRows(ctx, c, fetch, fn)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			break
+		}
+	}
+
+	return compatError(tx.Commit(ctx))
+}
+
+func fetchExportRows(ctx context.Context, c conn, fetch string, fn func(Product) error) (int, error) {
+	rows, err := c.Query(ctx, fetch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Description,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return n, err
+		}
+
+		if err := fn(i); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, rows.Err()
+}
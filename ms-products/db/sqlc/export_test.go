@@ -0,0 +1,13 @@
+package db
+
+import "github.com/jackc/pgx/v5/pgxpool"
+
+// PoolForTest exposes the pool opened by TestMain to the external tests.
+func PoolForTest() *pgxpool.Pool {
+	return testPool
+}
+
+// NewForTest returns queries over tx that answer like the store's.
+func NewForTest(tx conn) *Queries {
+	return New(newConn(tx))
+}
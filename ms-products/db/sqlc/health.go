@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/djudju12/ms-products/db/migrations"
+)
+
+// ExpectedSchemaVersion is the version of the newest embedded migration.
+var ExpectedSchemaVersion = int64(migrations.Latest())
+
+const schemaVersion = `-- name: SchemaVersion :one
+SELECT version, dirty FROM schema_migrations
+LIMIT 1
+`
+
+// replicationLag is zero on a primary and on a replica that has replayed all
+// the WAL it received, so an idle primary does not make its replicas look
+// stale.
+const replicationLag = `-- name: ReplicationLag :one
+SELECT CASE
+    WHEN NOT pg_is_in_recovery() THEN 0
+    WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+    ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8
+`
+
+// SchemaVersion is the state golang-migrate leaves in schema_migrations. A
+// dirty version means a migration failed halfway and needs fixing by hand.
+type SchemaVersion struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.Ping(ctx)
+}
+
+func (store *SQLStore) SchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	row := store.Queries.db.QueryRow(ctx, schemaVersion)
+	var i SchemaVersion
+	err := row.Scan(&i.Version, &i.Dirty)
+	return i, err
+}
+
+// ReplicationLag reports how far behind the primary a read replica is.
+func (store *SQLStore) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	row := store.Queries.db.QueryRow(ctx, replicationLag)
+	var seconds float64
+	err := row.Scan(&seconds)
+	return time.Duration(seconds * float64(time.Second)), err
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotencyKey(t *testing.T) IdempotencyKey {
+	now := time.Now()
+	arg := CreateIdempotencyKeyParams{
+		Scope:           "user:" + utils.RandomString(8, utils.DefaultAlphabet),
+		Key:             utils.RandomString(16, utils.DefaultAlphabet),
+		RequestHash:     []byte(utils.RandomString(32, utils.DefaultAlphabet)),
+		ExpiresAt:       now.Add(time.Hour),
+		AbandonedBefore: now.Add(-5 * time.Minute),
+	}
+
+	key, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Scope, key.Scope)
+	require.Equal(t, arg.Key, key.Key)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.Equal(t, "in_progress", key.Status)
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+
+	return key
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	createRandomIdempotencyKey(t)
+}
+
+func TestCreateIdempotencyKeyTaken(t *testing.T) {
+	key := createRandomIdempotencyKey(t)
+
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Scope:           key.Scope,
+		Key:             key.Key,
+		RequestHash:     key.RequestHash,
+		ExpiresAt:       time.Now().Add(time.Hour),
+		AbandonedBefore: time.Now().Add(-5 * time.Minute),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateIdempotencyKeyAbandoned(t *testing.T) {
+	key := createRandomIdempotencyKey(t)
+
+	key2, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Scope:           key.Scope,
+		Key:             key.Key,
+		RequestHash:     []byte("other"),
+		ExpiresAt:       time.Now().Add(time.Hour),
+		AbandonedBefore: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("other"), key2.RequestHash)
+}
+
+func TestCompleteIdempotencyKey(t *testing.T) {
+	key := createRandomIdempotencyKey(t)
+
+	err := testQueries.CompleteIdempotencyKey(context.Background(), CompleteIdempotencyKeyParams{
+		Scope:               key.Scope,
+		Key:                 key.Key,
+		ResponseStatus:      201,
+		ResponseContentType: "application/json",
+		ResponseBody:        []byte(`{"id":1}`),
+	})
+	require.NoError(t, err)
+
+	key2, err := testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{Scope: key.Scope, Key: key.Key})
+	require.NoError(t, err)
+	require.Equal(t, "completed", key2.Status)
+	require.Equal(t, int32(201), key2.ResponseStatus)
+	require.Equal(t, "application/json", key2.ResponseContentType)
+	require.Equal(t, []byte(`{"id":1}`), key2.ResponseBody)
+
+	// a completed key is kept even past the lock timeout
+	_, err = testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Scope:           key.Scope,
+		Key:             key.Key,
+		RequestHash:     key.RequestHash,
+		ExpiresAt:       time.Now().Add(time.Hour),
+		AbandonedBefore: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDeleteIdempotencyKey(t *testing.T) {
+	key := createRandomIdempotencyKey(t)
+
+	err := testQueries.DeleteIdempotencyKey(context.Background(), DeleteIdempotencyKeyParams{Scope: key.Scope, Key: key.Key})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{Scope: key.Scope, Key: key.Key})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
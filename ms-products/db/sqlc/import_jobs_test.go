@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomImportJob(t *testing.T) ImportJob {
+	arg := CreateImportJobParams{
+		Format:    "csv",
+		TotalRows: 2,
+		Payload:   []byte(utils.RandomProductDescription()),
+	}
+
+	job, err := testQueries.CreateImportJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, job.ID)
+	require.Equal(t, arg.Format, job.Format)
+	require.Equal(t, arg.TotalRows, job.TotalRows)
+	require.Equal(t, arg.Payload, job.Payload)
+	require.Equal(t, "pending", job.Status)
+	require.Zero(t, job.ProcessedRows)
+
+	return job
+}
+
+func TestCreateImportJob(t *testing.T) {
+	createRandomImportJob(t)
+}
+
+// claimRandomImportJob claims jobs until it gets job, the other tests leave
+// pending jobs behind.
+func claimRandomImportJob(t *testing.T, job ImportJob) ImportJob {
+	arg := ClaimImportJobParams{
+		ClaimedBy:    utils.RandomProductName(),
+		LeaseSeconds: 60,
+	}
+
+	for {
+		claimed, err := testQueries.ClaimImportJob(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, "running", claimed.Status)
+		require.Equal(t, arg.ClaimedBy, claimed.ClaimedBy)
+		require.True(t, claimed.LeaseExpiresAt.Valid)
+
+		if claimed.ID == job.ID {
+			return claimed
+		}
+	}
+}
+
+func TestUpdateImportJobProgress(t *testing.T) {
+	job := claimRandomImportJob(t, createRandomImportJob(t))
+
+	arg := UpdateImportJobProgressParams{
+		ID:            job.ID,
+		ClaimedBy:     job.ClaimedBy,
+		ProcessedRows: 2,
+		CreatedRows:   1,
+		RejectedRows:  1,
+		LeaseSeconds:  120,
+	}
+
+	job2, err := testQueries.UpdateImportJobProgress(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ProcessedRows, job2.ProcessedRows)
+	require.Equal(t, arg.CreatedRows, job2.CreatedRows)
+	require.Equal(t, arg.RejectedRows, job2.RejectedRows)
+	require.True(t, job2.LeaseExpiresAt.Time.After(job.LeaseExpiresAt.Time))
+
+	job3, err := testQueries.GetImportJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, job2, job3)
+
+	arg.ClaimedBy = "someone else"
+	_, err = testQueries.UpdateImportJobProgress(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestClaimImportJobConcurrently(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		createRandomImportJob(t)
+	}
+
+	n := 10
+	errs := make(chan error)
+	ids := make(chan int32)
+	for i := 0; i < n; i++ {
+		go func(worker string) {
+			job, err := testQueries.ClaimImportJob(context.Background(), ClaimImportJobParams{
+				ClaimedBy:    worker,
+				LeaseSeconds: 60,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				err = nil
+			}
+			errs <- err
+			ids <- job.ID
+		}(utils.RandomProductName())
+	}
+
+	claimed := make(map[int32]bool)
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+
+		id := <-ids
+		if id == 0 {
+			continue
+		}
+		require.False(t, claimed[id], "job %d claimed twice", id)
+		claimed[id] = true
+	}
+	require.NotEmpty(t, claimed)
+}
+
+func TestImportJobErrors(t *testing.T) {
+	job := createRandomImportJob(t)
+
+	rows := []CreateImportJobErrorsParams{}
+	for _, line := range []int32{5, 2} {
+		rows = append(rows, CreateImportJobErrorsParams{
+			JobID:   job.ID,
+			Line:    line,
+			Raw:     utils.RandomProductDescription(),
+			Message: "price: failed on price",
+		})
+	}
+
+	n, err := testQueries.CreateImportJobErrors(context.Background(), rows)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	importErrors, err := testQueries.ListImportJobErrors(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Len(t, importErrors, 2)
+	require.Equal(t, int32(2), importErrors[0].Line)
+	require.Equal(t, int32(5), importErrors[1].Line)
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/djudju12/ms-products/db/sqlc")
+
+// instrumentedDBTX traces every query as a span named after its sqlc query and
+// logs it at debug level with its duration, so both share the request ID and
+// trace of the context they ran with. Rows are traced until they are closed
+// and a single row until it is scanned.
+type instrumentedDBTX struct {
+	db conn
+}
+
+func instrument(db conn) conn {
+	return &instrumentedDBTX{db: db}
+}
+
+func (i *instrumentedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, end := StartQuery(ctx, query, semconv.DBSystemPostgreSQL)
+	tag, err := i.db.Exec(ctx, query, args...)
+	end(err)
+	return tag, err
+}
+
+func (i *instrumentedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ctx, end := StartQuery(ctx, query, semconv.DBSystemPostgreSQL)
+	rows, err := i.db.Query(ctx, query, args...)
+	if err != nil {
+		end(err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, end: end}, nil
+}
+
+func (i *instrumentedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, end := StartQuery(ctx, query, semconv.DBSystemPostgreSQL)
+	return &instrumentedRow{row: i.db.QueryRow(ctx, query, args...), end: end}
+}
+
+func (i *instrumentedDBTX) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	ctx, end := startOperation(ctx, "copy "+strings.Join(tableName, "."), semconv.DBSystemPostgreSQL)
+	n, err := i.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	end(err)
+	return n, err
+}
+
+func (i *instrumentedDBTX) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	ctx, end := startOperation(ctx, "batch", semconv.DBSystemPostgreSQL)
+	return &instrumentedBatch{BatchResults: i.db.SendBatch(ctx, b), end: end}
+}
+
+type instrumentedRows struct {
+	pgx.Rows
+	end  func(error)
+	once sync.Once
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(func() { r.end(r.Rows.Err()) })
+}
+
+type instrumentedRow struct {
+	row pgx.Row
+	end func(error)
+}
+
+func (r *instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.end(err)
+	return err
+}
+
+type instrumentedBatch struct {
+	pgx.BatchResults
+	end  func(error)
+	once sync.Once
+}
+
+func (b *instrumentedBatch) Close() error {
+	err := b.BatchResults.Close()
+	b.once.Do(func() { b.end(err) })
+	return err
+}
+
+// StartQuery starts the span and returns the function that ends it, for the
+// queries of other database systems.
+func StartQuery(ctx context.Context, query string, system attribute.KeyValue) (context.Context, func(error)) {
+	return startOperation(ctx, queryName(query), system)
+}
+
+func startOperation(ctx context.Context, name string, system attribute.KeyValue) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(system, semconv.DBOperation(name)),
+	)
+
+	return ctx, func(err error) {
+		failed := err != nil && !errors.Is(err, sql.ErrNoRows)
+		if failed {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+			return
+		}
+
+		attrs := []any{
+			slog.String("query", name),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if failed {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		slog.DebugContext(ctx, "db query", attrs...)
+	}
+}
+
+// queryName returns the name from the "-- name: GetProduct :one" header sqlc
+// puts on every query, or the first line of queries written by hand.
+func queryName(query string) string {
+	query = strings.TrimSpace(query)
+	if name, ok := strings.CutPrefix(query, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+
+	line, _, _ := strings.Cut(query, "\n")
+	return line
+}
@@ -1,31 +1,31 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"os"
 	"testing"
 
 	"github.com/djudju12/ms-products/configs"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
 	testQueries *Queries
-	testDB      *sql.DB
+	testPool    *pgxpool.Pool
 )
 
 func TestMain(m *testing.M) {
-	config, err := configs.LoadConfig("../..")
+	config, err := configs.LoadConfig("../..", nil)
 	if err != nil {
 		log.Fatal("cannot read configuration:", err)
 	}
 
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	testPool, err = pgxpool.New(context.Background(), config.DBSource)
 	if err != nil {
 		log.Fatal("cannot open db connection:", err)
 	}
 
-	testQueries = New(testDB)
+	testQueries = New(newConn(testPool))
 	os.Exit(m.Run())
 }
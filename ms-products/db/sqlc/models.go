@@ -5,9 +5,69 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
+type ApiKey struct {
+	ID          int32         `json:"id"`
+	Name        string        `json:"name"`
+	Prefix      string        `json:"prefix"`
+	KeyHash     []byte        `json:"key_hash"`
+	Scopes      []string      `json:"scopes"`
+	RateLimit   int32         `json:"rate_limit"`
+	RotatedFrom sql.NullInt32 `json:"rotated_from"`
+	ExpiresAt   sql.NullTime  `json:"expires_at"`
+	RevokedAt   sql.NullTime  `json:"revoked_at"`
+	LastUsedAt  sql.NullTime  `json:"last_used_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Scope               string    `json:"scope"`
+	Key                 string    `json:"key"`
+	RequestHash         []byte    `json:"request_hash"`
+	Status              string    `json:"status"`
+	ResponseStatus      int32     `json:"response_status"`
+	ResponseContentType string    `json:"response_content_type"`
+	ResponseBody        []byte    `json:"response_body"`
+	CreatedAt           time.Time `json:"created_at"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+type ImportJob struct {
+	ID             int32        `json:"id"`
+	Format         string       `json:"format"`
+	Status         string       `json:"status"`
+	Payload        []byte       `json:"payload"`
+	TotalRows      int32        `json:"total_rows"`
+	ProcessedRows  int32        `json:"processed_rows"`
+	CreatedRows    int32        `json:"created_rows"`
+	UpdatedRows    int32        `json:"updated_rows"`
+	RejectedRows   int32        `json:"rejected_rows"`
+	Error          string       `json:"error"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	ClaimedBy      string       `json:"claimed_by"`
+	LeaseExpiresAt sql.NullTime `json:"lease_expires_at"`
+}
+
+type ImportJobError struct {
+	ID      int64  `json:"id"`
+	JobID   int32  `json:"job_id"`
+	Line    int32  `json:"line"`
+	Raw     string `json:"raw"`
+	Message string `json:"message"`
+}
+
+type ImportStaging struct {
+	JobID       int32  `json:"job_id"`
+	Line        int32  `json:"line"`
+	Name        string `json:"name"`
+	Price       string `json:"price"`
+	Description string `json:"description"`
+}
+
 type Product struct {
 	ID          int32     `json:"id"`
 	Name        string    `json:"name"`
@@ -17,3 +77,10 @@ type Product struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+type RateLimitBucket struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
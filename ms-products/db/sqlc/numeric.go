@@ -0,0 +1,40 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var numericSyntax = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// Numeric rounds value the way a Postgres decimal(precision, scale) column
+// stores it, halves away from zero, for the databases that have no such type.
+func Numeric(value string, precision, scale int) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	r, ok := new(big.Rat).SetString(trimmed)
+	if !numericSyntax.MatchString(trimmed) || !ok {
+		return "", &Error{
+			Code:    CodeInvalidText,
+			Message: fmt.Sprintf("invalid input syntax for type numeric: %q", value),
+		}
+	}
+
+	rounded := r.FloatString(scale)
+	if strings.Trim(rounded, "-0.") == "" {
+		rounded = strings.TrimPrefix(rounded, "-")
+	}
+
+	integer, _, _ := strings.Cut(strings.TrimPrefix(rounded, "-"), ".")
+	if integer != "0" && len(integer) > precision-scale {
+		return "", &Error{
+			Code:    CodeNumericOverflow,
+			Message: "numeric field overflow",
+			Detail: fmt.Sprintf("A field with precision %d, scale %d must round to an absolute value less than 10^%d.",
+				precision, scale, precision-scale),
+		}
+	}
+
+	return rounded, nil
+}
@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createProduct = `-- name: CreateProduct :one
@@ -26,7 +27,7 @@ type CreateProductParams struct {
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
-	row := q.db.QueryRowContext(ctx, createProduct, arg.Name, arg.Price, arg.Description)
+	row := q.db.QueryRow(ctx, createProduct, arg.Name, arg.Price, arg.Description)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -46,7 +47,7 @@ WHERE id = $1
 `
 
 func (q *Queries) GetProduct(ctx context.Context, id int32) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProduct, id)
+	row := q.db.QueryRow(ctx, getProduct, id)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -73,7 +74,7 @@ type ListProductsParams struct {
 }
 
 func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, listProducts, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listProducts, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -94,9 +95,6 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -116,7 +114,7 @@ type UpdateProductStatusParams struct {
 }
 
 func (q *Queries) UpdateProductStatus(ctx context.Context, arg UpdateProductStatusParams) (Product, error) {
-	row := q.db.QueryRowContext(ctx, updateProductStatus, arg.Status, arg.ID)
+	row := q.db.QueryRow(ctx, updateProductStatus, arg.Status, arg.ID)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -129,3 +127,147 @@ func (q *Queries) UpdateProductStatus(ctx context.Context, arg UpdateProductStat
 	)
 	return i, err
 }
+
+const getProductsByIDs = `-- name: GetProductsByIDs :many
+SELECT id, name, price, description, status, created_at, updated_at FROM products
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) GetProductsByIDs(ctx context.Context, ids []int32) ([]Product, error) {
+	rows, err := q.db.Query(ctx, getProductsByIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Product{}
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Description,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET name = $1, price = $2, description = $3, updated_at = now()
+WHERE id = $4
+RETURNING id, name, price, description, status, created_at, updated_at
+`
+
+type UpdateProductParams struct {
+	Name        string `json:"name"`
+	Price       string `json:"price"`
+	Description string `json:"description"`
+	ID          int32  `json:"id"`
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProduct,
+		arg.Name,
+		arg.Price,
+		arg.Description,
+		arg.ID,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Description,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertProduct = `-- name: UpsertProduct :one
+INSERT INTO products (
+   name,
+   price,
+   description
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (name) DO UPDATE
+SET price = EXCLUDED.price, description = EXCLUDED.description, updated_at = now()
+RETURNING id, name, price, description, status, created_at, updated_at, (xmax = 0)::boolean AS inserted
+`
+
+type UpsertProductParams struct {
+	Name        string `json:"name"`
+	Price       string `json:"price"`
+	Description string `json:"description"`
+}
+
+type UpsertProductRow struct {
+	ID          int32     `json:"id"`
+	Name        string    `json:"name"`
+	Price       string    `json:"price"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Inserted    bool      `json:"inserted"`
+}
+
+func (q *Queries) UpsertProduct(ctx context.Context, arg UpsertProductParams) (UpsertProductRow, error) {
+	row := q.db.QueryRow(ctx, upsertProduct, arg.Name, arg.Price, arg.Description)
+	var i UpsertProductRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Description,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Inserted,
+	)
+	return i, err
+}
+
+const countProductsByStatus = `-- name: CountProductsByStatus :many
+SELECT status, count(*) AS count FROM products
+GROUP BY status
+ORDER BY status
+`
+
+type CountProductsByStatusRow struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountProductsByStatus(ctx context.Context) ([]CountProductsByStatusRow, error) {
+	rows, err := q.db.Query(ctx, countProductsByStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountProductsByStatusRow{}
+	for rows.Next() {
+		var i CountProductsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
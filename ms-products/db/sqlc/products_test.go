@@ -62,3 +62,74 @@ func TestListProduct(t *testing.T) {
 		require.NotEmpty(t, product)
 	}
 }
+
+func TestGetProductsByIDs(t *testing.T) {
+	product1 := createRandomProduct(t)
+	product2 := createRandomProduct(t)
+
+	products, err := testQueries.GetProductsByIDs(context.Background(), []int32{product1.ID, product2.ID, -1})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	require.ElementsMatch(t, []Product{product1, product2}, products)
+}
+
+func TestUpdateProduct(t *testing.T) {
+	product := createRandomProduct(t)
+
+	arg := UpdateProductParams{
+		ID:          product.ID,
+		Name:        utils.RandomProductName(),
+		Price:       utils.RandomProductPrice(),
+		Description: utils.RandomProductDescription(),
+	}
+
+	product2, err := testQueries.UpdateProduct(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, product.ID, product2.ID)
+	require.Equal(t, arg.Name, product2.Name)
+	require.Equal(t, arg.Price, product2.Price)
+	require.Equal(t, arg.Description, product2.Description)
+	require.True(t, product2.UpdatedAt.After(product.UpdatedAt))
+}
+
+func TestUpsertProduct(t *testing.T) {
+	arg := UpsertProductParams{
+		Name:        utils.RandomProductName(),
+		Price:       utils.RandomProductPrice(),
+		Description: utils.RandomProductDescription(),
+	}
+
+	product, err := testQueries.UpsertProduct(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, product.Inserted)
+
+	arg.Price = utils.RandomProductPrice()
+	product2, err := testQueries.UpsertProduct(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, product2.Inserted)
+	require.Equal(t, product.ID, product2.ID)
+	require.Equal(t, arg.Price, product2.Price)
+}
+
+func TestCountProductsByStatus(t *testing.T) {
+	product := createRandomProduct(t)
+
+	before, err := testQueries.CountProductsByStatus(context.Background())
+	require.NoError(t, err)
+
+	_, err = testQueries.UpdateProductStatus(context.Background(), UpdateProductStatusParams{ID: product.ID, Status: "inactive"})
+	require.NoError(t, err)
+
+	after, err := testQueries.CountProductsByStatus(context.Background())
+	require.NoError(t, err)
+
+	counts := func(rows []CountProductsByStatusRow) map[string]int64 {
+		result := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			result[row.Status] = row.Count
+		}
+		return result
+	}
+	require.Equal(t, counts(before)[product.Status]-1, counts(after)[product.Status])
+	require.Equal(t, counts(before)["inactive"]+1, counts(after)["inactive"])
+}
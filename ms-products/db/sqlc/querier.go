@@ -6,13 +6,42 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	ClaimImportJob(ctx context.Context, arg ClaimImportJobParams) (ImportJob, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	CountProductsByStatus(ctx context.Context) ([]CountProductsByStatusRow, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateImportJob(ctx context.Context, arg CreateImportJobParams) (ImportJob, error)
+	CreateImportJobErrors(ctx context.Context, arg []CreateImportJobErrorsParams) (int64, error)
+	CreateImportStaging(ctx context.Context, arg []CreateImportStagingParams) (int64, error)
 	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
+	DeleteExpiredRateLimitBuckets(ctx context.Context, expiresAt time.Time) error
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	ExpireAPIKey(ctx context.Context, arg ExpireAPIKeyParams) (ApiKey, error)
+	GetAPIKey(ctx context.Context, id int32) (ApiKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash []byte) (ApiKey, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetImportJob(ctx context.Context, id int32) (ImportJob, error)
 	GetProduct(ctx context.Context, id int32) (Product, error)
+	GetProductsByIDs(ctx context.Context, ids []int32) ([]Product, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	ListImportJobErrors(ctx context.Context, jobID int32) ([]ImportJobError, error)
 	ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error)
+	LockRateLimitBucket(ctx context.Context, arg LockRateLimitBucketParams) (LockRateLimitBucketRow, error)
+	RevokeAPIKey(ctx context.Context, id int32) (ApiKey, error)
+	TouchAPIKey(ctx context.Context, id int32) error
+	UpdateImportJobProgress(ctx context.Context, arg UpdateImportJobProgressParams) (ImportJob, error)
+	UpdateImportJobStatus(ctx context.Context, arg UpdateImportJobStatusParams) (ImportJob, error)
+	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
 	UpdateProductStatus(ctx context.Context, arg UpdateProductStatusParams) (Product, error)
+	UpdateRateLimitBucket(ctx context.Context, arg UpdateRateLimitBucketParams) error
+	UpsertProduct(ctx context.Context, arg UpsertProductParams) (UpsertProductRow, error)
+	UpsertStagedProducts(ctx context.Context, jobID int32) (UpsertStagedProductsRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
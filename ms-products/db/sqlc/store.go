@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Store interface {
+	Querier
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+	ExportProducts(ctx context.Context, arg ExportProductsParams, fn func(Product) error) error
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (SchemaVersion, error)
+}
+
+type SQLStore struct {
+	*Queries
+	db   *pgxpool.Pool
+	conn conn
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewStore(pool *pgxpool.Pool) Store {
+	return NewReplica(pool)
+}
+
+// NewReplica returns the store of a read replica, which unlike the Store
+// interface can tell its ReplicationLag.
+func NewReplica(pool *pgxpool.Pool) *SQLStore {
+	conn := newConn(pool)
+	return &SQLStore{
+		Queries: New(conn),
+		db:      pool,
+		conn:    conn,
+	}
+}
+
+func (store *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(New(newConn(tx)))
+	if err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+
+		return err
+	}
+
+	return compatError(tx.Commit(ctx))
+}
+
+// GetProductsByIDs sends a GetProduct for every distinct id in a single batch,
+// one round trip that skips the ids with no product.
+func (store *SQLStore) GetProductsByIDs(ctx context.Context, ids []int32) ([]Product, error) {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	items := []Product{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, id := range ids {
+		batch.Queue(getProduct, id)
+	}
+
+	results := store.conn.SendBatch(ctx, batch)
+	defer results.Close()
+
+	for range ids {
+		var i Product
+		err := results.QueryRow().Scan(
+			&i.ID,
+			&i.Name,
+			&i.Price,
+			&i.Description,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	return items, results.Close()
+}
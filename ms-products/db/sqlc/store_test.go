@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTx(t *testing.T) {
+	store := NewStore(testPool)
+
+	var product Product
+	err := store.ExecTx(context.Background(), func(q Querier) error {
+		var err error
+		product, err = q.CreateProduct(context.Background(), CreateProductParams{
+			Name:        utils.RandomProductName(),
+			Price:       utils.RandomProductPrice(),
+			Description: utils.RandomProductDescription(),
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	product2, err := store.GetProduct(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Equal(t, product, product2)
+}
+
+func TestExecTxRollback(t *testing.T) {
+	store := NewStore(testPool)
+	rollback := errors.New("rollback")
+
+	var product Product
+	err := store.ExecTx(context.Background(), func(q Querier) error {
+		var err error
+		product, err = q.CreateProduct(context.Background(), CreateProductParams{
+			Name:        utils.RandomProductName(),
+			Price:       utils.RandomProductPrice(),
+			Description: utils.RandomProductDescription(),
+		})
+		require.NoError(t, err)
+
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+
+	_, err = store.GetProduct(context.Background(), product.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExportProducts(t *testing.T) {
+	store := NewStore(testPool)
+
+	n := 5
+	created := make(map[int32]Product, n)
+	for i := 0; i < n; i++ {
+		product := createRandomProduct(t)
+		created[product.ID] = product
+	}
+
+	arg := ExportProductsParams{FetchSize: 2}
+
+	var lastID int32
+	exported := 0
+	err := store.ExportProducts(context.Background(), arg, func(product Product) error {
+		require.Greater(t, product.ID, lastID)
+		lastID = product.ID
+
+		if want, ok := created[product.ID]; ok {
+			require.Equal(t, want, product)
+			exported++
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, n, exported)
+}
+
+func TestExportProductsStopsOnError(t *testing.T) {
+	store := NewStore(testPool)
+	createRandomProduct(t)
+	stop := errors.New("stop")
+
+	err := store.ExportProducts(context.Background(), ExportProductsParams{FetchSize: 1}, func(product Product) error {
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+}
+
+func TestPing(t *testing.T) {
+	store := NewStore(testPool)
+	require.NoError(t, store.Ping(context.Background()))
+}
+
+func TestSchemaVersion(t *testing.T) {
+	store := NewStore(testPool)
+
+	version, err := store.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ExpectedSchemaVersion, version.Version)
+	require.False(t, version.Dirty)
+}
+
+func TestReplicationLag(t *testing.T) {
+	// the test database is a primary, which never lags
+	lag, err := NewReplica(testPool).ReplicationLag(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, lag)
+}
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, rate_limit, rotated_from, expires_at, revoked_at, last_used_at, created_at`
+
+func scanAPIKey(row rowScanner) (db.ApiKey, error) {
+	var i db.ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		scanStrings(&i.Scopes),
+		&i.RateLimit,
+		&i.RotatedFrom,
+		scanNullTime(&i.ExpiresAt),
+		scanNullTime(&i.RevokedAt),
+		scanNullTime(&i.LastUsedAt),
+		scanTime(&i.CreatedAt),
+	)
+	return i, convertError(err)
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name,
+  prefix,
+  key_hash,
+  scopes,
+  rate_limit,
+  rotated_from,
+  expires_at,
+  created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+) RETURNING ` + apiKeyColumns
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	scopes, err := formatStrings(arg.Scopes)
+	if err != nil {
+		return db.ApiKey{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.Name,
+		arg.Prefix,
+		blob(arg.KeyHash),
+		scopes,
+		arg.RateLimit,
+		arg.RotatedFrom,
+		formatNullTime(arg.ExpiresAt),
+		formatTime(now()),
+	)
+	return scanAPIKey(row)
+}
+
+const expireAPIKey = `-- name: ExpireAPIKey :one
+UPDATE api_keys
+SET expires_at = CASE WHEN expires_at IS NULL OR expires_at > ?1 THEN ?1 ELSE expires_at END
+WHERE id = ?2
+RETURNING ` + apiKeyColumns
+
+func (q *Queries) ExpireAPIKey(ctx context.Context, arg db.ExpireAPIKeyParams) (db.ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, expireAPIKey, formatTime(arg.ExpiresAt), arg.ID)
+	return scanAPIKey(row)
+}
+
+const getAPIKey = `-- name: GetAPIKey :one
+SELECT ` + apiKeyColumns + ` FROM api_keys
+WHERE id = ?1`
+
+func (q *Queries) GetAPIKey(ctx context.Context, id int32) (db.ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKey, id)
+	return scanAPIKey(row)
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT ` + apiKeyColumns + ` FROM api_keys
+WHERE key_hash = ?1`
+
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash []byte) (db.ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByHash, blob(keyHash))
+	return scanAPIKey(row)
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT ` + apiKeyColumns + ` FROM api_keys
+ORDER BY id`
+
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]db.ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeys)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer rows.Close()
+
+	items := []db.ApiKey{}
+	for rows.Next() {
+		i, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertError(err)
+	}
+
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = COALESCE(revoked_at, ?1)
+WHERE id = ?2
+RETURNING ` + apiKeyColumns
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int32) (db.ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeAPIKey, formatTime(now()), id)
+	return scanAPIKey(row)
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = ?1
+WHERE id = ?2
+  AND (last_used_at IS NULL OR last_used_at < ?3)`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int32) error {
+	t := now()
+	_, err := q.db.ExecContext(ctx, touchAPIKey, formatTime(t), id, formatTime(t.Add(-time.Minute)))
+	return convertError(err)
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const idempotencyKeyColumns = `scope, key, request_hash, status, response_status, response_content_type, response_body, created_at, expires_at`
+
+func scanIdempotencyKey(row rowScanner) (db.IdempotencyKey, error) {
+	var i db.IdempotencyKey
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.RequestHash,
+		&i.Status,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		scanTime(&i.CreatedAt),
+		scanTime(&i.ExpiresAt),
+	)
+	if i.RequestHash == nil {
+		i.RequestHash = []byte{}
+	}
+	if i.ResponseBody == nil {
+		i.ResponseBody = []byte{}
+	}
+	return i, convertError(err)
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status = 'completed',
+    response_status = ?1,
+    response_content_type = ?2,
+    response_body = ?3
+WHERE scope = ?4 AND key = ?5`
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg db.CompleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.ResponseStatus,
+		arg.ResponseContentType,
+		blob(arg.ResponseBody),
+		arg.Scope,
+		arg.Key,
+	)
+	return convertError(err)
+}
+
+// createIdempotencyKey returns no row, and so sql.ErrNoRows, when the key is
+// held by a request that has neither expired nor been abandoned.
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  scope,
+  key,
+  request_hash,
+  created_at,
+  expires_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5
+)
+ON CONFLICT (scope, key) DO UPDATE
+SET request_hash = excluded.request_hash,
+    status = 'in_progress',
+    response_status = 0,
+    response_content_type = '',
+    response_body = x'',
+    created_at = excluded.created_at,
+    expires_at = excluded.expires_at
+WHERE idempotency_keys.expires_at < ?4
+   OR (idempotency_keys.status = 'in_progress' AND idempotency_keys.created_at < ?6)
+RETURNING ` + idempotencyKeyColumns
+
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey,
+		arg.Scope,
+		arg.Key,
+		blob(arg.RequestHash),
+		formatTime(now()),
+		formatTime(arg.ExpiresAt),
+		formatTime(arg.AbandonedBefore),
+	)
+	return scanIdempotencyKey(row)
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at < ?1`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKeys, formatTime(now()))
+	return convertError(err)
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = ?1 AND key = ?2`
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg db.DeleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteIdempotencyKey, arg.Scope, arg.Key)
+	return convertError(err)
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT ` + idempotencyKeyColumns + ` FROM idempotency_keys
+WHERE scope = ?1 AND key = ?2`
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Scope, arg.Key)
+	return scanIdempotencyKey(row)
+}
@@ -0,0 +1,282 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const importJobColumns = `id, format, status, payload, total_rows, processed_rows, created_rows, updated_rows, rejected_rows, error, created_at, updated_at, claimed_by, lease_expires_at`
+
+func scanImportJob(row rowScanner) (db.ImportJob, error) {
+	var i db.ImportJob
+	err := row.Scan(
+		&i.ID,
+		&i.Format,
+		&i.Status,
+		&i.Payload,
+		&i.TotalRows,
+		&i.ProcessedRows,
+		&i.CreatedRows,
+		&i.UpdatedRows,
+		&i.RejectedRows,
+		&i.Error,
+		scanTime(&i.CreatedAt),
+		scanTime(&i.UpdatedAt),
+		&i.ClaimedBy,
+		scanNullTime(&i.LeaseExpiresAt),
+	)
+	if i.Payload == nil {
+		i.Payload = []byte{}
+	}
+	return i, convertError(err)
+}
+
+// claimImportJob needs no SKIP LOCKED, SQLite runs one write at a time.
+const claimImportJob = `-- name: ClaimImportJob :one
+UPDATE import_jobs
+SET status = 'running',
+    claimed_by = ?1,
+    lease_expires_at = ?2,
+    updated_at = ?3
+WHERE id = (
+  SELECT id FROM import_jobs
+  WHERE status = 'pending'
+     OR (status = 'running' AND (lease_expires_at IS NULL OR lease_expires_at <= ?3))
+  ORDER BY id
+  LIMIT 1
+)
+RETURNING ` + importJobColumns
+
+func (q *Queries) ClaimImportJob(ctx context.Context, arg db.ClaimImportJobParams) (db.ImportJob, error) {
+	t := now()
+	row := q.db.QueryRowContext(ctx, claimImportJob,
+		arg.ClaimedBy,
+		formatTime(t.Add(time.Duration(arg.LeaseSeconds)*time.Second)),
+		formatTime(t),
+	)
+	return scanImportJob(row)
+}
+
+const createImportJob = `-- name: CreateImportJob :one
+INSERT INTO import_jobs (
+  format,
+  total_rows,
+  payload,
+  created_at,
+  updated_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?4
+) RETURNING ` + importJobColumns
+
+func (q *Queries) CreateImportJob(ctx context.Context, arg db.CreateImportJobParams) (db.ImportJob, error) {
+	row := q.db.QueryRowContext(ctx, createImportJob, arg.Format, arg.TotalRows, blob(arg.Payload), formatTime(now()))
+	return scanImportJob(row)
+}
+
+const createImportJobError = `-- name: CreateImportJobErrors :copyfrom
+INSERT INTO import_job_errors (
+  job_id,
+  line,
+  raw,
+  message
+) VALUES (
+  ?1, ?2, ?3, ?4
+)`
+
+// CreateImportJobErrors inserts row by row, which costs little within the
+// transaction the import runs in. SQLite has no COPY.
+func (q *Queries) CreateImportJobErrors(ctx context.Context, arg []db.CreateImportJobErrorsParams) (int64, error) {
+	var n int64
+	for _, row := range arg {
+		_, err := q.db.ExecContext(ctx, createImportJobError,
+			row.JobID,
+			row.Line,
+			row.Raw,
+			row.Message,
+		)
+		if err != nil {
+			return n, convertError(err)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+const createImportStaging = `-- name: CreateImportStaging :copyfrom
+INSERT INTO import_staging (
+  job_id,
+  line,
+  name,
+  price,
+  description
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5
+)`
+
+// CreateImportStaging coerces every price before it inserts the rows one by
+// one, so a bad price adds none of them.
+func (q *Queries) CreateImportStaging(ctx context.Context, arg []db.CreateImportStagingParams) (int64, error) {
+	prices := make([]string, len(arg))
+	for i, row := range arg {
+		price, err := db.Numeric(row.Price, 12, 2)
+		if err != nil {
+			return 0, err
+		}
+		prices[i] = price
+	}
+
+	var n int64
+	for i, row := range arg {
+		_, err := q.db.ExecContext(ctx, createImportStaging,
+			row.JobID,
+			row.Line,
+			row.Name,
+			prices[i],
+			row.Description,
+		)
+		if err != nil {
+			return n, convertError(err)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+const getImportJob = `-- name: GetImportJob :one
+SELECT ` + importJobColumns + ` FROM import_jobs
+WHERE id = ?1`
+
+func (q *Queries) GetImportJob(ctx context.Context, id int32) (db.ImportJob, error) {
+	row := q.db.QueryRowContext(ctx, getImportJob, id)
+	return scanImportJob(row)
+}
+
+const listImportJobErrors = `-- name: ListImportJobErrors :many
+SELECT id, job_id, line, raw, message FROM import_job_errors
+WHERE job_id = ?1
+ORDER BY line, id`
+
+func (q *Queries) ListImportJobErrors(ctx context.Context, jobID int32) ([]db.ImportJobError, error) {
+	rows, err := q.db.QueryContext(ctx, listImportJobErrors, jobID)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer rows.Close()
+
+	items := []db.ImportJobError{}
+	for rows.Next() {
+		var i db.ImportJobError
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Line,
+			&i.Raw,
+			&i.Message,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertError(err)
+	}
+
+	return items, nil
+}
+
+const updateImportJobProgress = `-- name: UpdateImportJobProgress :one
+UPDATE import_jobs
+SET processed_rows = ?1,
+    created_rows = ?2,
+    updated_rows = ?3,
+    rejected_rows = ?4,
+    lease_expires_at = ?5,
+    updated_at = ?6
+WHERE id = ?7 AND claimed_by = ?8 AND status = 'running'
+RETURNING ` + importJobColumns
+
+func (q *Queries) UpdateImportJobProgress(ctx context.Context, arg db.UpdateImportJobProgressParams) (db.ImportJob, error) {
+	t := now()
+	row := q.db.QueryRowContext(ctx, updateImportJobProgress,
+		arg.ProcessedRows,
+		arg.CreatedRows,
+		arg.UpdatedRows,
+		arg.RejectedRows,
+		formatTime(t.Add(time.Duration(arg.LeaseSeconds)*time.Second)),
+		formatTime(t),
+		arg.ID,
+		arg.ClaimedBy,
+	)
+	return scanImportJob(row)
+}
+
+const updateImportJobStatus = `-- name: UpdateImportJobStatus :one
+UPDATE import_jobs
+SET status = ?1, error = ?2, lease_expires_at = NULL, updated_at = ?3
+WHERE id = ?4 AND claimed_by = ?5
+RETURNING ` + importJobColumns
+
+func (q *Queries) UpdateImportJobStatus(ctx context.Context, arg db.UpdateImportJobStatusParams) (db.ImportJob, error) {
+	row := q.db.QueryRowContext(ctx, updateImportJobStatus, arg.Status, arg.Error, formatTime(now()), arg.ID, arg.ClaimedBy)
+	return scanImportJob(row)
+}
+
+// upsertStagedProducts tells an insert from an update by the creation time, as
+// upsertProduct does. SQLite only parses an ON CONFLICT after a SELECT with a
+// WHERE, and has no data-modifying WITH, so the rows are counted here and the
+// staging rows deleted by a second statement.
+const upsertStagedProducts = `-- name: UpsertStagedProducts :one
+INSERT INTO products (
+  name,
+  price,
+  description,
+  created_at,
+  updated_at
+)
+SELECT name, price, description, ?2, ?2 FROM import_staging
+WHERE job_id = ?1
+ORDER BY line
+ON CONFLICT (name) DO UPDATE
+SET price = excluded.price, description = excluded.description, updated_at = excluded.updated_at
+RETURNING created_at = ?2 AS inserted`
+
+const deleteImportStaging = `DELETE FROM import_staging
+WHERE job_id = ?1`
+
+func (q *Queries) UpsertStagedProducts(ctx context.Context, jobID int32) (db.UpsertStagedProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, upsertStagedProducts, jobID, formatTime(now()))
+	if err != nil {
+		return db.UpsertStagedProductsRow{}, convertError(err)
+	}
+	defer rows.Close()
+
+	var i db.UpsertStagedProductsRow
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return db.UpsertStagedProductsRow{}, convertError(err)
+		}
+
+		if inserted {
+			i.CreatedRows++
+		} else {
+			i.UpdatedRows++
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return db.UpsertStagedProductsRow{}, convertError(err)
+	}
+	if err := rows.Err(); err != nil {
+		return db.UpsertStagedProductsRow{}, convertError(err)
+	}
+
+	_, err = q.db.ExecContext(ctx, deleteImportStaging, jobID)
+	return i, convertError(err)
+}
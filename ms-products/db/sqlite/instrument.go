@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// instrumentedDBTX traces and logs the queries like the Postgres ones.
+type instrumentedDBTX struct {
+	db DBTX
+}
+
+func instrument(db DBTX) DBTX {
+	return &instrumentedDBTX{db: db}
+}
+
+func (i *instrumentedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := db.StartQuery(ctx, query, semconv.DBSystemSqlite)
+	result, err := i.db.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
+}
+
+func (i *instrumentedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := db.StartQuery(ctx, query, semconv.DBSystemSqlite)
+	rows, err := i.db.QueryContext(ctx, query, args...)
+	end(err)
+	return rows, err
+}
+
+func (i *instrumentedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, end := db.StartQuery(ctx, query, semconv.DBSystemSqlite)
+	row := i.db.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const productColumns = `id, name, price, description, status, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(row rowScanner) (db.Product, error) {
+	var i db.Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Description,
+		&i.Status,
+		scanTime(&i.CreatedAt),
+		scanTime(&i.UpdatedAt),
+	)
+	return i, convertError(err)
+}
+
+func (q *Queries) queryProducts(ctx context.Context, query string, args ...any) ([]db.Product, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer rows.Close()
+
+	items := []db.Product{}
+	for rows.Next() {
+		i, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertError(err)
+	}
+
+	return items, nil
+}
+
+const createProduct = `-- name: CreateProduct :one
+INSERT INTO products (
+  name,
+  price,
+  description,
+  created_at,
+  updated_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?4
+) RETURNING ` + productColumns
+
+func (q *Queries) CreateProduct(ctx context.Context, arg db.CreateProductParams) (db.Product, error) {
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.Product{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, createProduct, arg.Name, price, arg.Description, formatTime(now()))
+	return scanProduct(row)
+}
+
+const getProduct = `-- name: GetProduct :one
+SELECT ` + productColumns + ` FROM products
+WHERE id = ?1`
+
+func (q *Queries) GetProduct(ctx context.Context, id int32) (db.Product, error) {
+	row := q.db.QueryRowContext(ctx, getProduct, id)
+	return scanProduct(row)
+}
+
+const listProducts = `-- name: ListProducts :many
+SELECT ` + productColumns + ` FROM products
+ORDER BY id
+LIMIT ?1
+OFFSET ?2`
+
+func (q *Queries) ListProducts(ctx context.Context, arg db.ListProductsParams) ([]db.Product, error) {
+	if err := limits(arg.Limit, arg.Offset); err != nil {
+		return nil, err
+	}
+
+	return q.queryProducts(ctx, listProducts, arg.Limit, arg.Offset)
+}
+
+const updateProductStatus = `-- name: UpdateProductStatus :one
+UPDATE products
+SET status = ?1, updated_at = ?2
+WHERE id = ?3
+RETURNING ` + productColumns
+
+func (q *Queries) UpdateProductStatus(ctx context.Context, arg db.UpdateProductStatusParams) (db.Product, error) {
+	row := q.db.QueryRowContext(ctx, updateProductStatus, arg.Status, formatTime(now()), arg.ID)
+	return scanProduct(row)
+}
+
+const getProductsByIDs = `-- name: GetProductsByIDs :many
+SELECT ` + productColumns + ` FROM products
+WHERE id IN (SELECT value FROM json_each(?1))
+ORDER BY id`
+
+func (q *Queries) GetProductsByIDs(ctx context.Context, ids []int32) ([]db.Product, error) {
+	encoded, err := json.Marshal(append([]int32{}, ids...))
+	if err != nil {
+		return nil, err
+	}
+
+	return q.queryProducts(ctx, getProductsByIDs, string(encoded))
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products
+SET name = ?1, price = ?2, description = ?3, updated_at = ?4
+WHERE id = ?5
+RETURNING ` + productColumns
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg db.UpdateProductParams) (db.Product, error) {
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.Product{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, updateProduct, arg.Name, price, arg.Description, formatTime(now()), arg.ID)
+	return scanProduct(row)
+}
+
+// upsertProduct tells an insert from an update by the creation time, which
+// only a row inserted by this statement can share with it.
+const upsertProduct = `-- name: UpsertProduct :one
+INSERT INTO products (
+  name,
+  price,
+  description,
+  created_at,
+  updated_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?4
+)
+ON CONFLICT (name) DO UPDATE
+SET price = excluded.price, description = excluded.description, updated_at = excluded.updated_at
+RETURNING ` + productColumns + `, created_at = ?4 AS inserted`
+
+func (q *Queries) UpsertProduct(ctx context.Context, arg db.UpsertProductParams) (db.UpsertProductRow, error) {
+	price, err := db.Numeric(arg.Price, 12, 2)
+	if err != nil {
+		return db.UpsertProductRow{}, err
+	}
+
+	row := q.db.QueryRowContext(ctx, upsertProduct, arg.Name, price, arg.Description, formatTime(now()))
+	var i db.UpsertProductRow
+	err = row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Price,
+		&i.Description,
+		&i.Status,
+		scanTime(&i.CreatedAt),
+		scanTime(&i.UpdatedAt),
+		&i.Inserted,
+	)
+	return i, convertError(err)
+}
+
+const countProductsByStatus = `-- name: CountProductsByStatus :many
+SELECT status, count(*) AS count FROM products
+GROUP BY status
+ORDER BY status`
+
+func (q *Queries) CountProductsByStatus(ctx context.Context) ([]db.CountProductsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, countProductsByStatus)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	defer rows.Close()
+
+	items := []db.CountProductsByStatusRow{}
+	for rows.Next() {
+		var i db.CountProductsByStatusRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, convertError(err)
+	}
+
+	return items, nil
+}
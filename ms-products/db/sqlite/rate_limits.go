@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const deleteExpiredRateLimitBuckets = `-- name: DeleteExpiredRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE expires_at < ?1`
+
+func (q *Queries) DeleteExpiredRateLimitBuckets(ctx context.Context, expiresAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredRateLimitBuckets, formatTime(expiresAt))
+	return convertError(err)
+}
+
+// lockRateLimitBucket needs no row lock: a transaction holds the database's
+// write lock from BEGIN, see DataSource.
+const lockRateLimitBucket = `-- name: LockRateLimitBucket :one
+INSERT INTO rate_limit_buckets (
+  key,
+  tokens,
+  updated_at,
+  expires_at
+) VALUES (
+  ?1, ?2, ?3, ?3
+)
+ON CONFLICT (key) DO UPDATE SET key = excluded.key
+RETURNING tokens, updated_at`
+
+func (q *Queries) LockRateLimitBucket(ctx context.Context, arg db.LockRateLimitBucketParams) (db.LockRateLimitBucketRow, error) {
+	t := now()
+	row := q.db.QueryRowContext(ctx, lockRateLimitBucket, arg.Key, arg.Tokens, formatTime(t))
+	i := db.LockRateLimitBucketRow{Now: t}
+	err := row.Scan(&i.Tokens, scanTime(&i.UpdatedAt))
+	return i, convertError(err)
+}
+
+const updateRateLimitBucket = `-- name: UpdateRateLimitBucket :exec
+UPDATE rate_limit_buckets
+SET tokens = ?1, updated_at = ?2, expires_at = ?3
+WHERE key = ?4`
+
+func (q *Queries) UpdateRateLimitBucket(ctx context.Context, arg db.UpdateRateLimitBucketParams) error {
+	_, err := q.db.ExecContext(ctx, updateRateLimitBucket, arg.Tokens, formatTime(arg.UpdatedAt), formatTime(arg.ExpiresAt), arg.Key)
+	return convertError(err)
+}
@@ -0,0 +1,259 @@
+// Package sqlite stores the catalog in a SQLite file, for development and edge
+// deployments that run ms-products as a single binary. Its queries are written
+// by hand against the schema in db/migrations/sqlite and answer like the
+// Postgres ones in db/sqlc: constraint failures come back as *db.Error with the
+// Postgres codes, so the services need not know which database they run on.
+//
+// Times are stored as UTC text of fixed width, so they compare correctly as
+// strings, and prices as text rounded like decimal(12, 2).
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DriverName is the database/sql driver and the DB_DRIVER value for SQLite.
+const DriverName = "sqlite"
+
+const timeFormat = "2006-01-02 15:04:05.000000-07:00"
+
+// parseFormat also reads the millisecond times SQLite's strftime writes for
+// column defaults.
+const parseFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+type Queries struct {
+	db DBTX
+}
+
+var _ db.Querier = (*Queries)(nil)
+
+func New(conn DBTX) *Queries {
+	return &Queries{db: conn}
+}
+
+// DataSource adds what the queries rely on to a DB_SOURCE such as
+// file:products.db, unless it sets them itself: foreign keys, which SQLite
+// turns off per connection, a busy timeout and WAL so readers and the writer
+// do not block each other, and immediate transactions so two transactions
+// never deadlock upgrading their locks.
+func DataSource(source string) string {
+	base, query, _ := strings.Cut(source, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return source
+	}
+
+	pragmas := strings.Join(values["_pragma"], ",")
+	for _, pragma := range []string{"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"} {
+		name, _, _ := strings.Cut(pragma, "(")
+		if !strings.Contains(pragmas, name) {
+			values.Add("_pragma", pragma)
+		}
+	}
+
+	if values.Get("_txlock") == "" {
+		values.Set("_txlock", "immediate")
+	}
+
+	return base + "?" + values.Encode()
+}
+
+// clock hands out statement times that are unique within the process, so a
+// row whose created_at is the statement time was inserted by that statement.
+var clock struct {
+	sync.Mutex
+	last time.Time
+}
+
+func now() time.Time {
+	clock.Lock()
+	defer clock.Unlock()
+
+	t := time.Now().UTC().Round(time.Microsecond)
+	if !t.After(clock.last) {
+		t = clock.last.Add(time.Microsecond)
+	}
+	clock.last = t
+
+	return t
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Round(time.Microsecond).Format(timeFormat)
+}
+
+func formatNullTime(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return formatTime(t.Time)
+}
+
+// timeValue scans the text times back, whether or not the driver already
+// parsed them from the column type.
+type timeValue struct {
+	time     *time.Time
+	nullTime *sql.NullTime
+}
+
+func scanTime(t *time.Time) sql.Scanner {
+	return timeValue{time: t}
+}
+
+func scanNullTime(t *sql.NullTime) sql.Scanner {
+	return timeValue{nullTime: t}
+}
+
+func (v timeValue) Scan(src any) error {
+	var t time.Time
+	switch value := src.(type) {
+	case nil:
+		if v.nullTime == nil {
+			return errors.New("sqlite: NULL time in a NOT NULL column")
+		}
+		*v.nullTime = sql.NullTime{}
+		return nil
+	case time.Time:
+		t = value
+	case string:
+		parsed, err := time.Parse(parseFormat, value)
+		if err != nil {
+			return fmt.Errorf("sqlite: cannot parse time %q: %w", value, err)
+		}
+		t = parsed
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into a time", src)
+	}
+
+	t = t.UTC()
+	if v.nullTime != nil {
+		*v.nullTime = sql.NullTime{Time: t, Valid: true}
+	} else {
+		*v.time = t
+	}
+
+	return nil
+}
+
+// stringsValue keeps a varchar[] as a JSON array.
+type stringsValue struct {
+	strings *[]string
+}
+
+func scanStrings(s *[]string) sql.Scanner {
+	return stringsValue{strings: s}
+}
+
+func (v stringsValue) Scan(src any) error {
+	var data []byte
+	switch value := src.(type) {
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into strings", src)
+	}
+
+	values := []string{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v.strings = values
+
+	return nil
+}
+
+func formatStrings(values []string) (string, error) {
+	data, err := json.Marshal(append([]string{}, values...))
+	return string(data), err
+}
+
+// blob returns empty blobs as empty slices, like pq does for bytea.
+func blob(b []byte) []byte {
+	return append([]byte{}, b...)
+}
+
+// convertError reports constraint failures the way Postgres does. SQLite
+// only lists the columns of a unique failure, and nothing of a foreign key
+// one, so only the unique constraints are named.
+func convertError(err error) error {
+	var sqliteErr *driver.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	dbErr := &db.Error{Message: sqliteErr.Error()}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		dbErr.Code = db.CodeUniqueViolation
+		dbErr.Table, dbErr.Constraint = uniqueConstraint(dbErr.Message, false)
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		dbErr.Code = db.CodeUniqueViolation
+		dbErr.Table, dbErr.Constraint = uniqueConstraint(dbErr.Message, true)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		dbErr.Code = db.CodeForeignKeyViolation
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		dbErr.Code = db.CodeNotNullViolation
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		dbErr.Code = db.CodeCheckViolation
+	default:
+		return err
+	}
+
+	return dbErr
+}
+
+// uniqueConstraint names the constraint of a unique failure like Postgres
+// names it, products_name_key for "UNIQUE constraint failed: products.name",
+// or the primary key of the table.
+func uniqueConstraint(message string, primaryKey bool) (string, string) {
+	_, columns, ok := strings.Cut(message, "UNIQUE constraint failed: ")
+	if !ok {
+		return "", ""
+	}
+	columns, _, _ = strings.Cut(columns, " (")
+
+	var table string
+	names := []string{}
+	for _, column := range strings.Split(columns, ", ") {
+		var name string
+		table, name, _ = strings.Cut(column, ".")
+		names = append(names, name)
+	}
+
+	if primaryKey {
+		return table, table + "_pkey"
+	}
+
+	return table, table + "_" + strings.Join(names, "_") + "_key"
+}
+
+// limits rejects what SQLite would read as no limit or no offset.
+func limits(limit, offset int32) error {
+	if limit < 0 {
+		return &db.Error{Code: db.CodeNegativeLimit, Message: "LIMIT must not be negative"}
+	}
+	if offset < 0 {
+		return &db.Error{Code: db.CodeNegativeOffset, Message: "OFFSET must not be negative"}
+	}
+	return nil
+}
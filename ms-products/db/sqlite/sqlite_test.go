@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/db/migrations"
+	"github.com/djudju12/ms-products/db/querytest"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	conn, err := sql.Open(DriverName, DataSource("file:"+filepath.Join(t.TempDir(), "products.db")))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	migrator, err := migrations.New(context.Background(), DriverName, conn, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Close())
+
+	return conn
+}
+
+func TestConformance(t *testing.T) {
+	querytest.Run(t, func(t *testing.T) db.Querier {
+		return New(openTestDB(t))
+	})
+}
+
+func TestDataSource(t *testing.T) {
+	source := DataSource("file:products.db")
+	base, query, ok := strings.Cut(source, "?")
+	require.True(t, ok)
+	require.Equal(t, "file:products.db", base)
+
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"}, values["_pragma"])
+	require.Equal(t, "immediate", values.Get("_txlock"))
+
+	// what the source sets itself is kept
+	values, err = url.ParseQuery(strings.SplitN(DataSource("products.db?_pragma=busy_timeout(100)&_txlock=deferred"), "?", 2)[1])
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"busy_timeout(100)", "foreign_keys(1)", "journal_mode(WAL)"}, values["_pragma"])
+	require.Equal(t, "deferred", values.Get("_txlock"))
+}
+
+func TestStoreExecTx(t *testing.T) {
+	store := NewStore(openTestDB(t))
+	ctx := context.Background()
+
+	errRollback := errors.New("rollback")
+	err := store.ExecTx(ctx, func(q db.Querier) error {
+		_, err := q.CreateProduct(ctx, db.CreateProductParams{Name: "pen", Price: "1.00"})
+		require.NoError(t, err)
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	_, err = store.GetProduct(ctx, 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = store.ExecTx(ctx, func(q db.Querier) error {
+		_, err := q.CreateProduct(ctx, db.CreateProductParams{Name: "pen", Price: "1.00"})
+		return err
+	})
+	require.NoError(t, err)
+
+	products, err := store.ListProducts(ctx, db.ListProductsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+}
+
+func TestStoreExportProducts(t *testing.T) {
+	store := NewStore(openTestDB(t))
+	ctx := context.Background()
+
+	for _, name := range []string{"pen", "ink", "paper"} {
+		_, err := store.CreateProduct(ctx, db.CreateProductParams{Name: name, Price: "1.00"})
+		require.NoError(t, err)
+	}
+	_, err := store.UpdateProductStatus(ctx, db.UpdateProductStatusParams{ID: 2, Status: "INACTIVE"})
+	require.NoError(t, err)
+	updated, err := store.UpdateProductStatus(ctx, db.UpdateProductStatusParams{ID: 3, Status: "ACTIVE"})
+	require.NoError(t, err)
+
+	export := func(arg db.ExportProductsParams) []string {
+		var names []string
+		err := store.ExportProducts(ctx, arg, func(product db.Product) error {
+			names = append(names, product.Name)
+			return nil
+		})
+		require.NoError(t, err)
+		return names
+	}
+
+	require.Equal(t, []string{"pen", "ink", "paper"}, export(db.ExportProductsParams{}))
+	require.Equal(t, []string{"pen", "paper"}, export(db.ExportProductsParams{Status: "ACTIVE"}))
+	require.Equal(t, []string{"paper"}, export(db.ExportProductsParams{
+		UpdatedSince: sql.NullTime{Time: updated.UpdatedAt, Valid: true},
+	}))
+
+	errStop := errors.New("stop")
+	err = store.ExportProducts(ctx, db.ExportProductsParams{}, func(db.Product) error { return errStop })
+	require.ErrorIs(t, err, errStop)
+}
+
+func TestStoreSchemaVersion(t *testing.T) {
+	store := NewStore(openTestDB(t))
+
+	require.NoError(t, store.Ping(context.Background()))
+
+	version, err := store.SchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, db.ExpectedSchemaVersion, version.Version)
+	require.False(t, version.Dirty)
+}
+
+func TestConcurrentTransactions(t *testing.T) {
+	store := NewStore(openTestDB(t))
+	ctx := context.Background()
+
+	// read then write in every transaction, which deadlocks deferred ones
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			errs <- store.ExecTx(ctx, func(q db.Querier) error {
+				bucket, err := q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "k", Tokens: 100})
+				if err != nil {
+					return err
+				}
+				return q.UpdateRateLimitBucket(ctx, db.UpdateRateLimitBucketParams{
+					Key:       "k",
+					Tokens:    bucket.Tokens - 1,
+					UpdatedAt: bucket.Now,
+				})
+			})
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		require.NoError(t, <-errs)
+	}
+
+	bucket, err := store.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{Key: "k"})
+	require.NoError(t, err)
+	require.Equal(t, float64(100-cap(errs)), bucket.Tokens)
+}
+
+func TestNotNullViolation(t *testing.T) {
+	conn := openTestDB(t)
+
+	_, err := conn.Exec("INSERT INTO products (name, price, description) VALUES (NULL, '1.00', '')")
+	err = convertError(err)
+
+	var dbErr *db.Error
+	require.True(t, errors.As(err, &dbErr))
+	require.Equal(t, db.CodeNotNullViolation, dbErr.Code)
+}
+
+func TestUniqueConstraint(t *testing.T) {
+	conn := openTestDB(t)
+
+	_, err := conn.Exec(`INSERT INTO idempotency_keys (scope, key, request_hash, status, created_at, expires_at)
+VALUES ('s', 'k', '', 'in_progress', '', ''), ('s', 'k', '', 'in_progress', '', '')`)
+	err = convertError(err)
+
+	var dbErr *db.Error
+	require.True(t, errors.As(err, &dbErr))
+	require.Equal(t, db.CodeUniqueViolation, dbErr.Code)
+	require.Equal(t, "idempotency_keys", dbErr.Table)
+	require.Equal(t, "idempotency_keys_pkey", dbErr.Constraint)
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	_ "modernc.org/sqlite"
+)
+
+type Store struct {
+	*Queries
+	db *sql.DB
+}
+
+var _ db.Store = (*Store)(nil)
+
+func NewStore(conn *sql.DB) db.Store {
+	return &Store{
+		Queries: New(instrument(conn)),
+		db:      conn,
+	}
+}
+
+func (store *Store) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return convertError(err)
+	}
+
+	err = fn(New(instrument(tx)))
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+
+		return err
+	}
+
+	return convertError(tx.Commit())
+}
+
+const exportProducts = `-- name: ExportProducts :many
+SELECT ` + productColumns + ` FROM products
+WHERE (?1 = '' OR status = ?1)
+  AND (?2 IS NULL OR updated_at >= ?2)
+ORDER BY id`
+
+// ExportProducts reads the filtered catalog with a single statement, which
+// SQLite runs against one snapshot. FetchSize has no use here.
+func (store *Store) ExportProducts(ctx context.Context, arg db.ExportProductsParams, fn func(db.Product) error) error {
+	rows, err := store.Queries.db.QueryContext(ctx, exportProducts, arg.Status, formatNullTime(arg.UpdatedSince))
+	if err != nil {
+		return convertError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		i, err := scanProduct(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	return convertError(rows.Err())
+}
+
+const schemaVersion = `-- name: SchemaVersion :one
+SELECT version, dirty FROM schema_migrations
+LIMIT 1`
+
+func (store *Store) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+func (store *Store) SchemaVersion(ctx context.Context) (db.SchemaVersion, error) {
+	row := store.Queries.db.QueryRowContext(ctx, schemaVersion)
+	var i db.SchemaVersion
+	err := row.Scan(&i.Version, &i.Dirty)
+	return i, err
+}
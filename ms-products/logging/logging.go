@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// currentLevel is shared by the loggers from New so SetLevel can change it at
+// runtime.
+var currentLevel = new(slog.LevelVar)
+
+const redactedValue = "REDACTED"
+
+var redactor atomic.Pointer[strings.Replacer]
+
+// New returns a JSON logger writing records at level and above to w. Records
+// logged with a context carrying a request ID include it as request_id, and
+// those logged within a span its trace_id and span_id.
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	if err := SetLevel(level); err != nil {
+		return nil, err
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: currentLevel, ReplaceAttr: scrub})
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// SetLevel changes the level of every logger returned by New.
+func SetLevel(name string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+
+	currentLevel.Set(lvl)
+	return nil
+}
+
+// Redact makes every logger from New replace the secrets wherever they show up
+// in messages, string attributes and errors.
+func Redact(secrets ...string) {
+	secrets = slices.DeleteFunc(slices.Clone(secrets), func(s string) bool { return s == "" })
+	// longer secrets first, so a DSN is masked whole rather than around its
+	// password
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, redactedValue)
+	}
+	redactor.Store(strings.NewReplacer(pairs...))
+}
+
+func scrub(_ []string, a slog.Attr) slog.Attr {
+	r := redactor.Load()
+	if r == nil {
+		return a
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.Replace(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(r.Replace(err.Error()))
+		}
+	}
+
+	return a
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
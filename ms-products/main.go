@@ -1,35 +1,210 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/djudju12/ms-products/configs"
 	"github.com/djudju12/ms-products/controller"
-	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/logging"
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/djudju12/ms-products/policy"
+	"github.com/djudju12/ms-products/ratelimit"
 	"github.com/djudju12/ms-products/service"
-	_ "github.com/lib/pq"
+	"github.com/djudju12/ms-products/token"
+	"github.com/djudju12/ms-products/tracing"
+	"github.com/spf13/pflag"
 	_ "go.uber.org/mock/mockgen/model"
 )
 
 func main() {
-	config, err := configs.LoadConfig(".")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	command, args := splitCommand(os.Args[1:])
+	config, err := configs.LoadConfig(".", args)
+	if errors.Is(err, pflag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("cannot read configurations", err)
+	}
+
+	logger, err := logging.New(os.Stdout, config.LogLevel)
+	if err != nil {
+		fatal("cannot create logger", err)
+	}
+	slog.SetDefault(logger)
+
+	err = config.Validate()
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	logging.Redact(config.Secrets()...)
+	slog.Info("effective configuration", "config", config.Redacted())
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter, config.TracingOTLPEndpoint, config.TracingSampleRatio)
+	if err != nil {
+		fatal("cannot set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	database, err := openDatabase(config)
+	if err != nil {
+		fatal("cannot open db connection", err)
+	}
+
+	if command != nil {
+		err = runMigrate(ctx, config.DBDriver, database.sql, config.MigrationLockTimeout, command)
+		if err != nil {
+			fatal("cannot migrate database", err)
+		}
+		return
+	}
+
+	if config.MigrateOnStart {
+		err = runMigrate(ctx, config.DBDriver, database.sql, config.MigrationLockTimeout, []string{"migrate", "up"})
+		if err != nil {
+			fatal("cannot migrate database", err)
+		}
+	}
+
+	store := database.store
+	healthService := service.NewHealthService(config, store)
+	err = healthService.WaitUntilReady(ctx)
+	if err != nil {
+		fatal("cannot start with unready dependencies", err)
+	}
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(database.stats, store)
+
+	productService := service.NewInstrumentedProductService(service.NewProductService(config, store), appMetrics)
+	importService := service.NewImportService(config, store)
+	feedService := service.NewFeedService(config, store)
+	apiKeyService := service.NewAPIKeyService(config, store)
+	idempotencyService := service.NewIdempotencyService(config, store)
+
+	// background workers share ctx and are waited for before the db is closed
+	// the import worker runs even with imports turned off, so jobs queued
+	// before a reload still finish
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		importService.Run(ctx)
+	}()
+	if database.replicas != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			database.replicas.Run(ctx)
+		}()
+	}
+
+	accessPolicy, err := policy.Load(config.PolicyFile)
+	if err != nil {
+		fatal("cannot load access policy", err)
+	}
+
+	ctrl := controller.New(config, productService)
+	importCtrl := controller.NewImportController(config, importService)
+	feedCtrl := controller.NewFeedController(config, feedService)
+
+	tokenMaker, err := token.NewMaker(config.TokenType, config.TokenSymmetricKey)
+	if err != nil {
+		fatal("cannot create token maker", err)
+	}
+
+	authService, err := service.NewAuthService(config, tokenMaker)
+	if err != nil {
+		fatal("cannot create auth service", err)
+	}
+
+	limits, err := ratelimit.ParseLimits(config.RateLimits)
 	if err != nil {
-		log.Fatal("cannot read configurations:", err)
+		fatal("cannot parse rate limits", err)
 	}
 
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	limitStore, err := ratelimit.NewStore(config.RateLimitBackend, store)
 	if err != nil {
-		log.Fatal("cannot open db connection:", err)
+		fatal("cannot create rate limit store", err)
 	}
 
-	repository := db.New(conn)
-	service := service.NewProductService(repository)
-	ctrl := controller.New(service)
-	server := controller.NewServer(ctrl)
+	liveConfig := configs.NewLive(config, func(c configs.Config) error {
+		_, err := ratelimit.ParseLimits(c.RateLimits)
+		return err
+	})
 
-	err = server.Start(config.ServerAddress)
+	limiter := ratelimit.NewLimiter(limitStore, limits)
+	server := controller.NewServer(config, tokenMaker, accessPolicy, limiter, appMetrics, controller.Controllers{
+		Product:     ctrl,
+		Import:      importCtrl,
+		Feed:        feedCtrl,
+		Auth:        controller.NewAuthController(authService),
+		APIKey:      controller.NewAPIKeyController(apiKeyService),
+		Idempotency: controller.NewIdempotencyController(config, idempotencyService),
+		Health:      controller.NewHealthController(healthService),
+		Config:      controller.NewConfigController(liveConfig),
+	})
+
+	liveConfig.Subscribe(func(c configs.Config) {
+		// both were checked before the reload was accepted
+		_ = logging.SetLevel(c.LogLevel)
+		limits, _ := ratelimit.ParseLimits(c.RateLimits)
+		limiter.SetLimits(limits)
+	})
+	liveConfig.Subscribe(server.Reload)
+	liveConfig.Watch(".", args)
+
+	err = server.SetTrustedProxies(config.TrustedProxies)
 	if err != nil {
-		log.Fatal("cannot start server:", err)
+		fatal("cannot set trusted proxies", err)
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "address", config.ServerAddress)
+		serverErr <- server.Start(config.ServerAddress)
+	}()
+
+	select {
+	case err = <-serverErr:
+		if err != nil {
+			fatal("cannot start server", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("shutting down", "delay", config.ShutdownDelay, "timeout", config.ShutdownTimeout)
+	healthService.Drain()
+	time.Sleep(config.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("cannot drain in-flight requests", "error", err)
+	}
+
+	workers.Wait()
+	err = database.close()
+	if err != nil {
+		slog.Error("cannot close db connection", "error", err)
+	}
+
+	slog.Info("server stopped")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
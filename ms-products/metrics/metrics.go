@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ms_products"
+
+// productsScrapeTimeout bounds the products by status query run on every scrape.
+const productsScrapeTimeout = 5 * time.Second
+
+// Metrics holds the collectors of the service in its own registry, so every
+// server, and every test, gets a clean set.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration *prometheus.HistogramVec
+	serviceCalls    *prometheus.CounterVec
+	serviceDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by method, route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		serviceCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "service_calls_total",
+			Help:      "Service method calls by outcome.",
+		}, []string{"service", "method", "outcome"}),
+		serviceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "service_call_duration_seconds",
+			Help:      "Duration of service method calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.serviceCalls,
+		m.serviceDuration,
+	)
+
+	return m
+}
+
+// RegisterDB adds the connection pool stats, from SQLStats or PoolStats, and
+// the number of products by status, read from repository on every scrape.
+func (m *Metrics) RegisterDB(pool prometheus.Collector, repository db.Querier) {
+	m.registry.MustRegister(
+		pool,
+		&productsCollector{repository: repository},
+	)
+}
+
+// Handler serves the metrics. A collector that fails, like the products count
+// while the database is down, is skipped so the rest are still scraped.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveCall records a call to method of service that took duration and
+// failed when err is not nil.
+func (m *Metrics) ObserveCall(service string, method string, duration time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+
+	m.serviceCalls.WithLabelValues(service, method, outcome).Inc()
+	m.serviceDuration.WithLabelValues(service, method).Observe(duration.Seconds())
+}
+
+var productsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "products"),
+	"Products in the catalog by status.",
+	[]string{"status"}, nil,
+)
+
+type productsCollector struct {
+	repository db.Querier
+}
+
+func (c *productsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- productsDesc
+}
+
+func (c *productsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), productsScrapeTimeout)
+	defer cancel()
+
+	rows, err := c.repository.CountProductsByStatus(ctx)
+	if err != nil {
+		slog.Error("cannot count products by status", "error", err)
+		ch <- prometheus.NewInvalidMetric(productsDesc, err)
+		return
+	}
+
+	for _, row := range rows {
+		ch <- prometheus.MustNewConstMetric(productsDesc, prometheus.GaugeValue, float64(row.Count), row.Status)
+	}
+}
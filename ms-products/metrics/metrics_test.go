@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func scrape(t *testing.T, m *Metrics) (int, string) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	m.Handler().ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestObserve(t *testing.T) {
+	m := New()
+	m.ObserveRequest(http.MethodGet, "/products/:id", http.StatusOK, 20*time.Millisecond)
+	m.ObserveCall("product", "GetProduct", time.Millisecond, nil)
+	m.ObserveCall("product", "GetProduct", time.Millisecond, sql.ErrConnDone)
+
+	code, body := scrape(t, m)
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `ms_products_http_request_duration_seconds_count{method="GET",route="/products/:id",status="200"} 1`)
+	require.Contains(t, body, `ms_products_service_calls_total{method="GetProduct",outcome="ok",service="product"} 1`)
+	require.Contains(t, body, `ms_products_service_calls_total{method="GetProduct",outcome="error",service="product"} 1`)
+	require.Contains(t, body, `ms_products_service_call_duration_seconds_count{method="GetProduct",service="product"} 2`)
+}
+
+func TestRegistriesAreIsolated(t *testing.T) {
+	m := New()
+	m.ObserveRequest(http.MethodGet, "/products", http.StatusOK, time.Millisecond)
+
+	_, body := scrape(t, New())
+	require.NotContains(t, body, "ms_products_http_request_duration_seconds")
+}
+
+func TestProductsCollector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+	repository.EXPECT().
+		CountProductsByStatus(gomock.Any()).
+		Times(1).
+		Return([]db.CountProductsByStatusRow{
+			{Status: "available", Count: 7},
+			{Status: "inactive", Count: 2},
+		}, nil)
+
+	conn, err := sql.Open("pgx", "")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	m := New()
+	m.RegisterDB(SQLStats(conn), repository)
+
+	code, body := scrape(t, m)
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `ms_products_products{status="available"} 7`)
+	require.Contains(t, body, `ms_products_products{status="inactive"} 2`)
+	require.Contains(t, body, `go_sql_open_connections{db_name="ms_products"} 0`)
+}
+
+func TestProductsCollectorError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+	repository.EXPECT().
+		CountProductsByStatus(gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+
+	conn, err := sql.Open("pgx", "")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	m := New()
+	m.RegisterDB(SQLStats(conn), repository)
+
+	code, body := scrape(t, m)
+	require.Equal(t, http.StatusOK, code)
+	require.NotContains(t, body, "ms_products_products{")
+	require.Contains(t, body, "go_sql_open_connections")
+}
+
+func TestPoolStats(t *testing.T) {
+	config, err := pgxpool.ParseConfig("postgres://localhost/ms_products")
+	require.NoError(t, err)
+	config.MaxConns = 7
+
+	// the pool connects lazily, so nothing is dialed here
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+	repository.EXPECT().
+		CountProductsByStatus(gomock.Any()).
+		Times(1).
+		Return([]db.CountProductsByStatusRow{}, nil)
+
+	m := New()
+	m.RegisterDB(PoolStats(pool), repository)
+
+	code, body := scrape(t, m)
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `go_sql_max_open_connections{db_name="ms_products"} 7`)
+	require.Contains(t, body, `go_sql_open_connections{db_name="ms_products"} 0`)
+	require.Contains(t, body, "ms_products_db_canceled_acquires_total 0")
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// SQLStats collects the stats of a database/sql pool.
+func SQLStats(conn *sql.DB) prometheus.Collector {
+	return collectors.NewDBStatsCollector(conn, namespace)
+}
+
+// PoolStats collects the stats of a pgx pool under the names SQLStats uses,
+// so the dashboards read the same for both.
+func PoolStats(pool *pgxpool.Pool) prometheus.Collector {
+	return &poolCollector{pool: pool}
+}
+
+var (
+	poolLabels = prometheus.Labels{"db_name": namespace}
+
+	poolMaxOpenDesc         = prometheus.NewDesc("go_sql_max_open_connections", "Maximum number of open connections to the database.", nil, poolLabels)
+	poolOpenDesc            = prometheus.NewDesc("go_sql_open_connections", "The number of established connections both in use and idle.", nil, poolLabels)
+	poolInUseDesc           = prometheus.NewDesc("go_sql_in_use_connections", "The number of connections currently in use.", nil, poolLabels)
+	poolIdleDesc            = prometheus.NewDesc("go_sql_idle_connections", "The number of idle connections.", nil, poolLabels)
+	poolWaitCountDesc       = prometheus.NewDesc("go_sql_wait_count_total", "The total number of connections waited for.", nil, poolLabels)
+	poolWaitDurationDesc    = prometheus.NewDesc("go_sql_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", nil, poolLabels)
+	poolIdleClosedDesc      = prometheus.NewDesc("go_sql_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.", nil, poolLabels)
+	poolLifetimeClosedDesc  = prometheus.NewDesc("go_sql_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", nil, poolLabels)
+	poolConstructingDesc    = prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", "constructing_connections"), "The number of connections being established.", nil, nil)
+	poolCanceledAcquireDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", "canceled_acquires_total"), "The total number of acquires canceled by their context.", nil, nil)
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolMaxOpenDesc
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolWaitCountDesc
+	ch <- poolWaitDurationDesc
+	ch <- poolIdleClosedDesc
+	ch <- poolLifetimeClosedDesc
+	ch <- poolConstructingDesc
+	ch <- poolCanceledAcquireDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolMaxOpenDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolWaitCountDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWaitDurationDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolIdleClosedDesc, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(poolLifetimeClosedDesc, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(poolConstructingDesc, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
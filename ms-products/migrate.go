@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/djudju12/ms-products/db/migrations"
+)
+
+const migrateUsage = "usage: ms-products migrate up|down [steps]|version|force <version> [flags]"
+
+// splitCommand separates `migrate <action> [number]` from the flags that
+// follow it.
+func splitCommand(args []string) (command []string, flags []string) {
+	if len(args) == 0 || args[0] != "migrate" {
+		return nil, args
+	}
+
+	n := 1
+	for n < len(args) && n < 4 && args[n] != "" && args[n][0] != '-' {
+		n++
+	}
+
+	return args[:n], args[n:]
+}
+
+func runMigrate(ctx context.Context, driver string, conn *sql.DB, lockTimeout time.Duration, command []string) error {
+	if len(command) < 2 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migrations.New(ctx, driver, conn, lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	action, args := command[1], command[2:]
+	switch {
+	case action == "up" && len(args) == 0:
+		err = migrator.Up()
+	case action == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[0])
+			}
+		}
+		err = migrator.Down(steps)
+	case action == "force" && len(args) == 1:
+		version, convErr := strconv.Atoi(args[0])
+		if convErr != nil {
+			return fmt.Errorf("version must be a number, got %q", args[0])
+		}
+		err = migrator.Force(version)
+	case action == "version" && len(args) == 0:
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	slog.Info("schema version", "version", version, "dirty", dirty, "latest", migrations.Latest())
+	return nil
+}
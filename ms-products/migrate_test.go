@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		command []string
+		flags   []string
+	}{
+		{args: []string{"--log-level=debug"}, flags: []string{"--log-level=debug"}},
+		{args: []string{"migrate", "up"}, command: []string{"migrate", "up"}, flags: []string{}},
+		{args: []string{"migrate", "down", "2", "--db-host", "db"}, command: []string{"migrate", "down", "2"}, flags: []string{"--db-host", "db"}},
+		{args: []string{"migrate", "--db-host", "db"}, command: []string{"migrate"}, flags: []string{"--db-host", "db"}},
+	}
+
+	for _, tC := range testCases {
+		command, flags := splitCommand(tC.args)
+		require.Equal(t, tC.command, command)
+		require.Equal(t, tC.flags, flags)
+	}
+}
@@ -1,12 +1,135 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"reflect"
 	"regexp"
+	"strings"
 
+	"github.com/djudju12/ms-products/policy"
 	"github.com/go-playground/validator/v10"
 )
 
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	RegisterValidations(v)
+
+	return v
+}
+
+// RegisterValidations adds the custom rules to v and makes it report fields by
+// their json, form or uri name, the way clients see them.
+func RegisterValidations(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+
+			if name != "" {
+				return name
+			}
+		}
+
+		return field.Name
+	})
+
+	v.RegisterValidation("price", ValidPrice)
+	v.RegisterValidation("status", ValidStatus)
+	v.RegisterValidation("scope", ValidScope)
+}
+
+// Validate checks a request against its binding rules outside of gin, returning
+// the failed rule for each field keyed by its json name.
+func Validate(req any) map[string]string {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return map[string]string{"": err.Error()}
+	}
+
+	fieldErrors := make(map[string]string, len(validationErrors))
+	for _, fe := range validationErrors {
+		fieldErrors[fe.Field()] = fe.Tag()
+	}
+
+	return fieldErrors
+}
+
+// ValidateProblems checks a request against its binding rules outside of gin,
+// describing each failed rule as FieldProblems does.
+func ValidateProblems(req any) []FieldProblem {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []FieldProblem{{Message: err.Error()}}
+	}
+
+	return FieldProblems(validationErrors)
+}
+
+// FieldProblems describes each failed rule in a message a client can show.
+func FieldProblems(validationErrors validator.ValidationErrors) []FieldProblem {
+	problems := make([]FieldProblem, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		// the namespace starts with the go name of the request struct
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		problems = append(problems, FieldProblem{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+
+	return problems
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return boundMessage(fe, "at least")
+	case "max":
+		return boundMessage(fe, "at most")
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "price":
+		return "must be a decimal with up to 10 digits and 2 decimal places"
+	case "status":
+		return fmt.Sprintf("must be one of %s, %s", ProductStatusAvailable, ProductStatusOutOfStock)
+	case "scope":
+		return "must be a known permission"
+	}
+
+	return fmt.Sprintf("failed on the %s rule", fe.Tag())
+}
+
+func boundMessage(fe validator.FieldError, bound string) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must have %s %s items", bound, fe.Param())
+	}
+
+	return fmt.Sprintf("must be %s %s", bound, fe.Param())
+}
+
 var ValidPrice validator.Func = func(fl validator.FieldLevel) bool {
 	if price, ok := fl.Field().Interface().(string); ok {
 		return isValidPrice(price)
@@ -28,13 +151,15 @@ func isValidPrice(price string) bool {
 
 var ValidStatus validator.Func = func(fl validator.FieldLevel) bool {
 	if status, ok := fl.Field().Interface().(string); ok {
-		return isValidStatus(status)
+		return IsValidStatus(status)
 	}
 
 	return false
 }
 
-func isValidStatus(status string) bool {
+// IsValidStatus tells whether status can be set by a status update. Products
+// become inactive only by being deactivated, which takes its own permission.
+func IsValidStatus(status string) bool {
 	switch status {
 	case ProductStatusAvailable, ProductStatusOutOfStock:
 		return true
@@ -42,3 +167,11 @@ func isValidStatus(status string) bool {
 
 	return false
 }
+
+var ValidScope validator.Func = func(fl validator.FieldLevel) bool {
+	if scope, ok := fl.Field().Interface().(string); ok {
+		return policy.Known(scope)
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+type APIKey struct {
+	ID          int32      `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	RateLimit   int32      `json:"rate_limit"`
+	RotatedFrom *int32     `json:"rotated_from,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key can still authenticate at now.
+func (key *APIKey) Active(now time.Time) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+
+	return key.ExpiresAt == nil || now.Before(*key.ExpiresAt)
+}
+
+func APIKeyDbToModel(key db.ApiKey) *APIKey {
+	result := &APIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		RateLimit: key.RateLimit,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if key.RotatedFrom.Valid {
+		result.RotatedFrom = &key.RotatedFrom.Int32
+	}
+	if key.ExpiresAt.Valid {
+		result.ExpiresAt = &key.ExpiresAt.Time
+	}
+	if key.RevokedAt.Valid {
+		result.RevokedAt = &key.RevokedAt.Time
+	}
+	if key.LastUsedAt.Valid {
+		result.LastUsedAt = &key.LastUsedAt.Time
+	}
+
+	return result
+}
+
+func ListAPIKeysDbToModel(keys []db.ApiKey) []*APIKey {
+	result := make([]*APIKey, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, APIKeyDbToModel(key))
+	}
+
+	return result
+}
+
+// CreatedAPIKey carries the plaintext key, which is only shown once.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,scope"`
+	RateLimit int32      `json:"rate_limit" binding:"min=0"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type GetAPIKeyRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type RotateAPIKeyRequest struct {
+	Overlap time.Duration `form:"overlap" binding:"min=0"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+const TokenTypeBearer = "Bearer"
+
+type IssueTokenRequest struct {
+	ClientID     string `json:"client_id" binding:"required"`
+	ClientSecret string `json:"client_secret" binding:"required"`
+}
+
+type IssueTokenResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
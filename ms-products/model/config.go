@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+type ConfigSnapshot struct {
+	Version  int64          `json:"version"`
+	LoadedAt time.Time      `json:"loaded_at"`
+	Config   map[string]any `json:"config"`
+}
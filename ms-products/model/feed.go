@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+)
+
+const (
+	FeedAvailabilityInStock    = "in_stock"
+	FeedAvailabilityOutOfStock = "out_of_stock"
+)
+
+const GoogleMerchantNamespace = "http://base.google.com/ns/1.0"
+
+type Feed struct {
+	ContentType string
+	Body        []byte
+	ETag        string
+	UpdatedAt   time.Time
+}
+
+// FeedItem holds the Google Merchant attributes shared by the RSS and Atom
+// renderings of a product.
+type FeedItem struct {
+	ID           string `xml:"g:id"`
+	Title        string `xml:"g:title"`
+	Description  string `xml:"g:description"`
+	Link         string `xml:"g:link"`
+	ImageLink    string `xml:"g:image_link"`
+	Price        string `xml:"g:price"`
+	Availability string `xml:"g:availability"`
+	Condition    string `xml:"g:condition"`
+}
+
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	NSG     string     `xml:"xmlns:g,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	FeedItem
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	NS      string      `xml:"xmlns,attr"`
+	NSG     string      `xml:"xmlns:g,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Link    AtomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type AtomEntry struct {
+	FeedItem
+	Updated string `xml:"updated"`
+}
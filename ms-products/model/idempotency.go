@@ -0,0 +1,14 @@
+package model
+
+const (
+	IdempotencyStatusInProgress = "in_progress"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotentResponse is the response stored for an Idempotency-Key and
+// replayed when the request is retried.
+type IdempotentResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+type ImportJob struct {
+	ID            int32     `json:"id"`
+	Format        string    `json:"format"`
+	Status        string    `json:"status"`
+	TotalRows     int32     `json:"total_rows"`
+	ProcessedRows int32     `json:"processed_rows"`
+	CreatedRows   int32     `json:"created_rows"`
+	UpdatedRows   int32     `json:"updated_rows"`
+	RejectedRows  int32     `json:"rejected_rows"`
+	Progress      float64   `json:"progress"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func ImportJobDbToModel(job db.ImportJob) *ImportJob {
+	progress := 1.0
+	if job.TotalRows > 0 {
+		progress = float64(job.ProcessedRows) / float64(job.TotalRows)
+	}
+
+	return &ImportJob{
+		ID:            job.ID,
+		Format:        job.Format,
+		Status:        job.Status,
+		TotalRows:     job.TotalRows,
+		ProcessedRows: job.ProcessedRows,
+		CreatedRows:   job.CreatedRows,
+		UpdatedRows:   job.UpdatedRows,
+		RejectedRows:  job.RejectedRows,
+		Progress:      progress,
+		Error:         job.Error,
+		CreatedAt:     job.CreatedAt,
+		UpdatedAt:     job.UpdatedAt,
+	}
+}
+
+type ImportError struct {
+	Line    int32  `json:"line"`
+	Raw     string `json:"raw"`
+	Message string `json:"message"`
+}
+
+func ListImportErrorsDbToModel(errors []db.ImportJobError) []*ImportError {
+	result := make([]*ImportError, 0, len(errors))
+	for _, e := range errors {
+		result = append(result, &ImportError{
+			Line:    e.Line,
+			Raw:     e.Raw,
+			Message: e.Message,
+		})
+	}
+
+	return result
+}
+
+type CreateImportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+type GetImportRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
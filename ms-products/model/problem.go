@@ -0,0 +1,23 @@
+package model
+
+// Problem is an RFC 7807 problem details document, served as
+// application/problem+json for every error response.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+
+	Errors             []FieldProblem `json:"errors,omitempty"`
+	MissingPermissions []string       `json:"missing_permissions,omitempty"`
+}
+
+// FieldProblem describes one invalid request field. Rule is the binding rule
+// that failed, e.g. required or min.
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"database/sql"
 	"time"
 
 	db "github.com/djudju12/ms-products/db/sqlc"
@@ -81,6 +82,22 @@ func (req *CreateProductRequest) ToDB() db.CreateProductParams {
 	}
 }
 
+type UpdateProductRequest struct {
+	ID          int32  `json:"id" binding:"required,min=1"`
+	Name        string `json:"name" binding:"required"`
+	Price       string `json:"price" binding:"required,price"`
+	Description string `json:"description" binding:"required"`
+}
+
+func (req *UpdateProductRequest) ToDB() db.UpdateProductParams {
+	return db.UpdateProductParams{
+		ID:          req.ID,
+		Name:        req.Name,
+		Price:       req.Price,
+		Description: req.Description,
+	}
+}
+
 type UpdateProductStatusRequest struct {
 	ID     int32  `json:"id" binding:"required,min=1"`
 	Status string `json:"status" binding:"required,status"`
@@ -100,3 +117,98 @@ type GetProductRequest struct {
 type DeleteProductRequest struct {
 	ID int32 `uri:"id" binding:"required,min=1"`
 }
+
+type BatchGetProductsRequest struct {
+	IDs []int32 `json:"ids" binding:"required,min=1,dive,min=1"`
+}
+
+type BatchGetProductsResponse struct {
+	Products []*Product `json:"products"`
+	Missing  []int32    `json:"missing"`
+}
+
+const (
+	BulkOperationCreate = "create"
+	BulkOperationUpdate = "update"
+)
+
+const (
+	BulkStatusCreated  = "created"
+	BulkStatusUpdated  = "updated"
+	BulkStatusConflict = "conflict"
+	BulkStatusNotFound = "not_found"
+	BulkStatusInvalid  = "invalid"
+	BulkStatusAborted  = "aborted"
+)
+
+type BulkProductOperation struct {
+	Operation   string `json:"operation"`
+	ID          int32  `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Price       string `json:"price"`
+	Description string `json:"description"`
+}
+
+func (op *BulkProductOperation) ToCreateRequest() CreateProductRequest {
+	return CreateProductRequest{
+		Name:        op.Name,
+		Price:       op.Price,
+		Description: op.Description,
+	}
+}
+
+func (op *BulkProductOperation) ToUpdateRequest() UpdateProductRequest {
+	return UpdateProductRequest{
+		ID:          op.ID,
+		Name:        op.Name,
+		Price:       op.Price,
+		Description: op.Description,
+	}
+}
+
+type BulkProductsRequest struct {
+	Atomic     bool                   `json:"-" form:"atomic"`
+	Operations []BulkProductOperation `json:"operations" binding:"required,min=1"`
+}
+
+type BulkProductResult struct {
+	Index   int            `json:"index"`
+	Status  string         `json:"status"`
+	Product *Product       `json:"product,omitempty"`
+	Errors  []FieldProblem `json:"errors,omitempty"`
+}
+
+func (r *BulkProductResult) Failed() bool {
+	switch r.Status {
+	case BulkStatusCreated, BulkStatusUpdated:
+		return false
+	}
+
+	return true
+}
+
+type BulkProductsResponse struct {
+	Results []BulkProductResult `json:"results"`
+}
+
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+)
+
+type ExportProductsRequest struct {
+	Format       string    `form:"format" binding:"required,oneof=csv jsonl xlsx"`
+	Status       string    `form:"status" binding:"omitempty,oneof=available out_of_stock inactive"`
+	UpdatedSince time.Time `form:"updated_since" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (req *ExportProductsRequest) ToDB() db.ExportProductsParams {
+	return db.ExportProductsParams{
+		Status: req.Status,
+		UpdatedSince: sql.NullTime{
+			Time:  req.UpdatedSince,
+			Valid: !req.UpdatedSince.IsZero(),
+		},
+	}
+}
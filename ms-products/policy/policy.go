@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+const (
+	ProductsCreate     = "products:create"
+	ProductsUpdate     = "products:update"
+	ProductsDeactivate = "products:deactivate"
+	ImportsCreate      = "imports:create"
+	ImportsRead        = "imports:read"
+	APIKeysManage      = "api_keys:manage"
+	ConfigRead         = "config:read"
+)
+
+var permissions = map[string]bool{
+	ProductsCreate:     true,
+	ProductsUpdate:     true,
+	ProductsDeactivate: true,
+	ImportsCreate:      true,
+	ImportsRead:        true,
+	APIKeysManage:      true,
+	ConfigRead:         true,
+}
+
+// Known reports whether permission is one the service checks. API key scopes
+// are drawn from the same set.
+func Known(permission string) bool {
+	return permissions[permission]
+}
+
+// Policy maps each role to the permissions it grants.
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+type policyFile struct {
+	Roles map[string][]string `json:"roles"`
+}
+
+// Load reads a policy file like {"roles": {"admin": ["products:create", ...]}}.
+// Unknown permissions are rejected so a typo fails at startup instead of
+// silently denying requests.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse policy file %s: %w", path, err)
+	}
+
+	return New(file.Roles)
+}
+
+func New(roles map[string][]string) (*Policy, error) {
+	policy := &Policy{roles: make(map[string]map[string]bool, len(roles))}
+	for role, granted := range roles {
+		policy.roles[role] = make(map[string]bool, len(granted))
+		for _, permission := range granted {
+			if !permissions[permission] {
+				return nil, fmt.Errorf("role %q: unknown permission %q", role, permission)
+			}
+
+			policy.roles[role][permission] = true
+		}
+	}
+
+	return policy, nil
+}
+
+// Missing returns the permissions from required that role does not grant.
+func (p *Policy) Missing(role string, required ...string) []string {
+	var missing []string
+	for _, permission := range required {
+		if !p.roles[role][permission] {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing
+}
+
+// MissingScopes returns the permissions from required that are not in scopes.
+func MissingScopes(scopes []string, required ...string) []string {
+	var missing []string
+	for _, permission := range required {
+		if !slices.Contains(scopes, permission) {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	policy, err := Load("../policy.json")
+	require.NoError(t, err)
+
+	require.Empty(t, policy.Missing("admin", ProductsCreate, ProductsUpdate, ProductsDeactivate, ImportsCreate, ImportsRead, APIKeysManage, ConfigRead))
+	require.Equal(t, []string{ProductsDeactivate}, policy.Missing("merchandiser", ProductsUpdate, ProductsDeactivate))
+	require.Equal(t, []string{ProductsCreate, ProductsUpdate}, policy.Missing("support", ImportsRead, ProductsCreate, ProductsUpdate))
+	require.Equal(t, []string{ImportsRead}, policy.Missing("unknown", ImportsRead))
+}
+
+func TestLoadUnknownPermission(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(path, []byte(`{"roles": {"admin": ["products:purge"]}}`), 0o600)
+	require.NoError(t, err)
+
+	policy, err := Load(path)
+	require.ErrorContains(t, err, "products:purge")
+	require.Nil(t, policy)
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(path, []byte(`{"roles": [`), 0o600)
+	require.NoError(t, err)
+
+	_, err = Load(path)
+	require.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestMissingScopes(t *testing.T) {
+	scopes := []string{ProductsCreate, ImportsRead}
+
+	require.Empty(t, MissingScopes(scopes, ProductsCreate))
+	require.Equal(t, []string{ProductsUpdate}, MissingScopes(scopes, ProductsCreate, ProductsUpdate))
+	require.Equal(t, []string{ImportsRead}, MissingScopes(nil, ImportsRead))
+	require.True(t, Known(APIKeysManage))
+	require.False(t, Known("products:purge"))
+}
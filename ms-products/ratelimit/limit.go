@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// DefaultRoute is the Limits entry used by routes without their own limit.
+const DefaultRoute = "*"
+
+// Limit allows Requests per Period, refilled continuously, with bursts of up
+// to Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// ParseLimit reads a limit written as "requests/period", like "100/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected requests/period", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Limits maps a route, written as "METHOD /path", to its limit.
+type Limits map[string]Limit
+
+// ParseLimits reads entries like "GET /products=100/1m". The "*" route sets the
+// limit for every route not listed.
+func ParseLimits(entries []string) (Limits, error) {
+	limits := make(Limits, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit entry %q: expected route=requests/period", entry)
+		}
+
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+
+	return limits, nil
+}
+
+// For returns the limit of route, falling back to the default route. It
+// reports false when neither is set.
+func (l Limits) For(route string) (Limit, bool) {
+	if limit, ok := l[route]; ok {
+		return limit, true
+	}
+
+	limit, ok := l[DefaultRoute]
+	return limit, ok
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets. Take removes a token from the bucket at key,
+// creating it full when missing.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens after elapsed and tries to remove one.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	capacity := float64(limit.Requests)
+	tokens = math.Min(capacity, tokens+elapsed.Seconds()*limit.rate())
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = seconds((capacity - tokens) / limit.rate())
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// NewStore returns the store for backend.
+func NewStore(backend string, repository db.Store) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendPostgres:
+		return NewPostgresStore(repository), nil
+	}
+
+	return nil, fmt.Errorf("unknown rate limit backend %q", backend)
+}
+
+type Limiter struct {
+	store  Store
+	limits atomic.Pointer[Limits]
+}
+
+func NewLimiter(store Store, limits Limits) *Limiter {
+	l := &Limiter{store: store}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits replaces the route limits for the requests that follow. Buckets
+// already taken from keep their tokens.
+func (l *Limiter) SetLimits(limits Limits) {
+	l.limits.Store(&limits)
+}
+
+// Allow takes a request from the bucket identity has for route. Routes without
+// a limit are always allowed and return a zero Result.
+func (l *Limiter) Allow(ctx context.Context, route string, identity string) (Result, bool, error) {
+	limit, ok := l.limits.Load().For(route)
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	result, err := l.store.Take(ctx, route+"|"+identity, limit)
+	return result, true, err
+}
+
+// Take takes a request from the bucket at key, outside of the route limits.
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.store.Take(ctx, key, limit)
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits([]string{"*=600/1m", " GET  /products = 120/1m", "POST /products:bulk=30/1h", ""})
+	require.NoError(t, err)
+	require.Equal(t, Limits{
+		"*":                   {Requests: 600, Period: time.Minute},
+		"GET /products":       {Requests: 120, Period: time.Minute},
+		"POST /products:bulk": {Requests: 30, Period: time.Hour},
+	}, limits)
+
+	limit, ok := limits.For("GET /products")
+	require.True(t, ok)
+	require.Equal(t, 120, limit.Requests)
+
+	limit, ok = limits.For("GET /products/:id")
+	require.True(t, ok)
+	require.Equal(t, 600, limit.Requests)
+
+	_, ok = Limits{}.For("GET /products")
+	require.False(t, ok)
+}
+
+func TestParseLimitsInvalid(t *testing.T) {
+	for _, entry := range []string{"GET /products", "GET /products=120", "GET /products=0/1m", "GET /products=x/1m", "GET /products=10/soon", "GET /products=10/-1m"} {
+		_, err := ParseLimits([]string{entry})
+		require.Error(t, err, entry)
+	}
+}
+
+func TestTake(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	tokens, result := take(2, 0, limit)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1.0, tokens)
+	require.Equal(t, 1, result.Remaining)
+	require.Equal(t, 30*time.Second, result.Reset)
+
+	tokens, result = take(tokens, 0, limit)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	tokens, result = take(tokens, 15*time.Second, limit)
+	require.False(t, result.Allowed)
+	require.Equal(t, 0.5, tokens)
+	require.Equal(t, 15*time.Second, result.RetryAfter)
+
+	tokens, result = take(tokens, time.Hour, limit)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1.0, tokens)
+}
+
+func TestLimiterSetLimits(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), Limits{"GET /products": {Requests: 1, Period: time.Minute}})
+
+	_, limited, err := limiter.Allow(context.Background(), "GET /products/:id", "client")
+	require.NoError(t, err)
+	require.False(t, limited)
+
+	limiter.SetLimits(Limits{DefaultRoute: {Requests: 1, Period: time.Minute}})
+
+	result, limited, err := limiter.Allow(context.Background(), "GET /products/:id", "client")
+	require.NoError(t, err)
+	require.True(t, limited)
+	require.True(t, result.Allowed)
+
+	result, _, err = limiter.Allow(context.Background(), "GET /products/:id", "client")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the stores drop buckets that have refilled.
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore keeps buckets in process. Each replica enforces its own quota.
+type MemoryStore struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, result := take(bucket.tokens, now.Sub(bucket.updated), limit)
+	bucket.tokens, bucket.updated, bucket.limit = tokens, now, limit
+	return result, nil
+}
+
+// sweep drops buckets that have refilled, since a missing bucket starts full.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= bucket.limit.Period {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "ip:1", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "ip:1", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.RetryAfter)
+
+	result, err = store.Take(context.Background(), "ip:2", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	now = now.Add(20 * time.Second)
+	result, err = store.Take(context.Background(), "ip:1", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	_, err := store.Take(context.Background(), "ip:1", Limit{Requests: 1, Period: time.Minute})
+	require.NoError(t, err)
+	_, err = store.Take(context.Background(), "ip:2", Limit{Requests: 1, Period: time.Hour})
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Take(context.Background(), "ip:3", Limit{Requests: 1, Period: time.Minute})
+	require.NoError(t, err)
+
+	require.NotContains(t, store.buckets, "ip:1")
+	require.Contains(t, store.buckets, "ip:2")
+	require.Contains(t, store.buckets, "ip:3")
+}
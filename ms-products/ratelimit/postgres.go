@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every replica
+// shares the same quota. Time is read from the database to keep replicas with
+// skewed clocks consistent.
+type PostgresStore struct {
+	repository db.Store
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(repository db.Store) *PostgresStore {
+	return &PostgresStore{
+		repository: repository,
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var result Result
+	var now time.Time
+	err := s.repository.ExecTx(ctx, func(q db.Querier) error {
+		bucket, err := q.LockRateLimitBucket(ctx, db.LockRateLimitBucketParams{
+			Key:    key,
+			Tokens: float64(limit.Requests),
+		})
+		if err != nil {
+			return err
+		}
+
+		var tokens float64
+		now = bucket.Now
+		tokens, result = take(bucket.Tokens, now.Sub(bucket.UpdatedAt), limit)
+		return q.UpdateRateLimitBucket(ctx, db.UpdateRateLimitBucketParams{
+			Key:       key,
+			Tokens:    tokens,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(limit.Period),
+		})
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	s.sweep(ctx, now)
+	return result, nil
+}
+
+// sweep drops buckets that have refilled. Each expires one period of its own
+// limit after its last use, so limits changed since then are still honoured.
+func (s *PostgresStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	if err := s.repository.DeleteExpiredRateLimitBuckets(ctx, now); err != nil {
+		slog.ErrorContext(ctx, "cannot delete expired rate limit buckets", "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostgresStore(t *testing.T) {
+	now := time.Now()
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	execTx := func(repository *mockdb.MockStore) func(ctx context.Context, fn func(db.Querier) error) error {
+		return func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(repository)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, result Result, err error)
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().
+					LockRateLimitBucket(gomock.Any(), gomock.Eq(db.LockRateLimitBucketParams{Key: "ip:1", Tokens: 2})).
+					Times(1).
+					Return(db.LockRateLimitBucketRow{Tokens: 0.5, UpdatedAt: now.Add(-15 * time.Second), Now: now}, nil)
+				repository.EXPECT().
+					UpdateRateLimitBucket(gomock.Any(), gomock.Eq(db.UpdateRateLimitBucketParams{Key: "ip:1", Tokens: 0, UpdatedAt: now, ExpiresAt: now.Add(time.Minute)})).
+					Times(1).
+					Return(nil)
+				repository.EXPECT().
+					DeleteExpiredRateLimitBuckets(gomock.Any(), gomock.Eq(now)).
+					Times(1).
+					Return(nil)
+			},
+			check: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.True(t, result.Allowed)
+				require.Equal(t, 0, result.Remaining)
+			},
+		},
+		{
+			name: "Denied",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().
+					LockRateLimitBucket(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LockRateLimitBucketRow{Tokens: 0, UpdatedAt: now, Now: now}, nil)
+				repository.EXPECT().
+					UpdateRateLimitBucket(gomock.Any(), gomock.Eq(db.UpdateRateLimitBucketParams{Key: "ip:1", Tokens: 0, UpdatedAt: now, ExpiresAt: now.Add(time.Minute)})).
+					Times(1).
+					Return(nil)
+				repository.EXPECT().
+					DeleteExpiredRateLimitBuckets(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			check: func(t *testing.T, result Result, err error) {
+				require.NoError(t, err)
+				require.False(t, result.Allowed)
+				require.Equal(t, 30*time.Second, result.RetryAfter)
+			},
+		},
+		{
+			name: "Database Error",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().
+					LockRateLimitBucket(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LockRateLimitBucketRow{}, sql.ErrConnDone)
+				repository.EXPECT().
+					DeleteExpiredRateLimitBuckets(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, result Result, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockdb.NewMockStore(ctrl)
+			tC.buildStubs(repository)
+
+			store := NewPostgresStore(repository)
+			result, err := store.Take(context.Background(), "ip:1", limit)
+			tC.check(t, result, err)
+		})
+	}
+}
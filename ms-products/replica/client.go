@@ -0,0 +1,16 @@
+package replica
+
+import "context"
+
+type clientKey struct{}
+
+// WithClient tells the router which client ctx belongs to, so that the
+// client's reads see its own writes.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+func clientFrom(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
@@ -0,0 +1,311 @@
+// Package replica spreads the catalog reads over Postgres read replicas, which
+// take the load that dwarfs the writes off the primary.
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+)
+
+// Querier is what the router asks of a read replica.
+type Querier interface {
+	GetProduct(ctx context.Context, id int32) (db.Product, error)
+	ListProducts(ctx context.Context, arg db.ListProductsParams) ([]db.Product, error)
+	ReplicationLag(ctx context.Context) (time.Duration, error)
+}
+
+// Replica is a read replica and the name it is logged by, never its data
+// source, which holds a password.
+type Replica struct {
+	Name    string
+	Querier Querier
+}
+
+type Options struct {
+	// MaxLag is the replication lag past which a replica stops serving reads.
+	MaxLag time.Duration
+	// CheckInterval is how often every replica is checked.
+	CheckInterval time.Duration
+	// CheckTimeout bounds a single check.
+	CheckTimeout time.Duration
+	// StickyWindow is how long a client reads from the primary after its own
+	// write, so it does not miss the write on a replica that lags.
+	StickyWindow time.Duration
+}
+
+type backend struct {
+	Replica
+	healthy atomic.Bool
+	// checked is only touched by the checks
+	checked bool
+}
+
+// Router is a db.Store that answers GetProduct and ListProducts from its
+// healthy replicas in turn and everything else from the primary. Replicas
+// serve reads only once a check has found them within MaxLag, and a read that
+// fails on a replica is retried on the primary.
+type Router struct {
+	db.Store
+	options  Options
+	replicas []*backend
+	next     atomic.Uint64
+	now      func() time.Time
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+}
+
+var _ db.Store = (*Router)(nil)
+
+func NewRouter(primary db.Store, options Options, replicas ...Replica) *Router {
+	r := &Router{
+		Store:   primary,
+		options: options,
+		now:     time.Now,
+		writes:  make(map[string]time.Time),
+	}
+
+	for _, replica := range replicas {
+		r.replicas = append(r.replicas, &backend{Replica: replica})
+	}
+
+	return r
+}
+
+// Run checks the replicas every CheckInterval until ctx is done.
+func (r *Router) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Router) check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range r.replicas {
+		wg.Add(1)
+		go func(b *backend) {
+			defer wg.Done()
+			r.checkReplica(ctx, b)
+		}(b)
+	}
+	wg.Wait()
+
+	r.sweep()
+}
+
+func (r *Router) checkReplica(ctx context.Context, b *backend) {
+	checkCtx, cancel := context.WithTimeout(ctx, r.options.CheckTimeout)
+	defer cancel()
+
+	lag, err := b.Querier.ReplicationLag(checkCtx)
+	if ctx.Err() != nil {
+		// shutting down, the replica is not to blame
+		return
+	}
+
+	healthy := err == nil && lag <= r.options.MaxLag
+	if b.healthy.Swap(healthy) == healthy && b.checked {
+		return
+	}
+	b.checked = true
+
+	switch {
+	case healthy:
+		slog.Info("replica serving reads", "replica", b.Name, "lag", lag)
+	case err != nil:
+		slog.Warn("replica unavailable, reading from the primary", "replica", b.Name, "error", err)
+	default:
+		slog.Warn("replica lagging, reading from the primary", "replica", b.Name, "lag", lag, "max_lag", r.options.MaxLag)
+	}
+}
+
+// replica picks the next healthy replica, or nil when the read must go to the
+// primary.
+func (r *Router) replica(ctx context.Context) *backend {
+	if len(r.replicas) == 0 || r.wroteRecently(ctx) {
+		return nil
+	}
+
+	healthy := make([]*backend, 0, len(r.replicas))
+	for _, b := range r.replicas {
+		if b.healthy.Load() {
+			healthy = append(healthy, b)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	return healthy[r.next.Add(1)%uint64(len(healthy))]
+}
+
+// retry tells whether a read that returned err on b is worth repeating on the
+// primary. A missing row is an answer, not a failure.
+func retry(ctx context.Context, b *backend, err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) || ctx.Err() != nil {
+		return false
+	}
+
+	slog.WarnContext(ctx, "replica read failed, retrying on the primary", "replica", b.Name, "error", err)
+	return true
+}
+
+func (r *Router) GetProduct(ctx context.Context, id int32) (db.Product, error) {
+	if b := r.replica(ctx); b != nil {
+		product, err := b.Querier.GetProduct(ctx, id)
+		if !retry(ctx, b, err) {
+			return product, err
+		}
+	}
+
+	return r.Store.GetProduct(ctx, id)
+}
+
+func (r *Router) ListProducts(ctx context.Context, arg db.ListProductsParams) ([]db.Product, error) {
+	if b := r.replica(ctx); b != nil {
+		products, err := b.Querier.ListProducts(ctx, arg)
+		if !retry(ctx, b, err) {
+			return products, err
+		}
+	}
+
+	return r.Store.ListProducts(ctx, arg)
+}
+
+func (r *Router) CreateProduct(ctx context.Context, arg db.CreateProductParams) (db.Product, error) {
+	product, err := r.Store.CreateProduct(ctx, arg)
+	r.wrote(ctx, err)
+	return product, err
+}
+
+func (r *Router) UpdateProduct(ctx context.Context, arg db.UpdateProductParams) (db.Product, error) {
+	product, err := r.Store.UpdateProduct(ctx, arg)
+	r.wrote(ctx, err)
+	return product, err
+}
+
+func (r *Router) UpdateProductStatus(ctx context.Context, arg db.UpdateProductStatusParams) (db.Product, error) {
+	product, err := r.Store.UpdateProductStatus(ctx, arg)
+	r.wrote(ctx, err)
+	return product, err
+}
+
+func (r *Router) UpsertProduct(ctx context.Context, arg db.UpsertProductParams) (db.UpsertProductRow, error) {
+	row, err := r.Store.UpsertProduct(ctx, arg)
+	r.wrote(ctx, err)
+	return row, err
+}
+
+func (r *Router) UpsertStagedProducts(ctx context.Context, jobID int32) (db.UpsertStagedProductsRow, error) {
+	row, err := r.Store.UpsertStagedProducts(ctx, jobID)
+	r.wrote(ctx, err)
+	return row, err
+}
+
+// ExecTx makes the client sticky only when the transaction wrote products, so
+// the rate limit buckets other transactions lock do not count as writes.
+func (r *Router) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	var wrote bool
+	err := r.Store.ExecTx(ctx, func(q db.Querier) error {
+		return fn(&writeTracker{Querier: q, wrote: &wrote})
+	})
+	if wrote {
+		r.wrote(ctx, err)
+	}
+
+	return err
+}
+
+func (r *Router) wrote(ctx context.Context, err error) {
+	client := clientFrom(ctx)
+	if err != nil || client == "" || r.options.StickyWindow <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[client] = r.now()
+}
+
+func (r *Router) wroteRecently(ctx context.Context) bool {
+	client := clientFrom(ctx)
+	if client == "" || r.options.StickyWindow <= 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[client]
+	return ok && r.now().Sub(at) < r.options.StickyWindow
+}
+
+// sweep forgets the writes older than the window.
+func (r *Router) sweep() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for client, at := range r.writes {
+		if now.Sub(at) >= r.options.StickyWindow {
+			delete(r.writes, client)
+		}
+	}
+}
+
+// writeTracker notes the product writes of a transaction.
+type writeTracker struct {
+	db.Querier
+	wrote *bool
+}
+
+func (w *writeTracker) track(err error) {
+	if err == nil {
+		*w.wrote = true
+	}
+}
+
+func (w *writeTracker) CreateProduct(ctx context.Context, arg db.CreateProductParams) (db.Product, error) {
+	product, err := w.Querier.CreateProduct(ctx, arg)
+	w.track(err)
+	return product, err
+}
+
+func (w *writeTracker) UpdateProduct(ctx context.Context, arg db.UpdateProductParams) (db.Product, error) {
+	product, err := w.Querier.UpdateProduct(ctx, arg)
+	w.track(err)
+	return product, err
+}
+
+func (w *writeTracker) UpdateProductStatus(ctx context.Context, arg db.UpdateProductStatusParams) (db.Product, error) {
+	product, err := w.Querier.UpdateProductStatus(ctx, arg)
+	w.track(err)
+	return product, err
+}
+
+func (w *writeTracker) UpsertProduct(ctx context.Context, arg db.UpsertProductParams) (db.UpsertProductRow, error) {
+	row, err := w.Querier.UpsertProduct(ctx, arg)
+	w.track(err)
+	return row, err
+}
+
+func (w *writeTracker) UpsertStagedProducts(ctx context.Context, jobID int32) (db.UpsertStagedProductsRow, error) {
+	row, err := w.Querier.UpsertStagedProducts(ctx, jobID)
+	w.track(err)
+	return row, err
+}
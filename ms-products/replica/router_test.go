@@ -0,0 +1,158 @@
+package replica
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeReplica struct {
+	name string
+	lag  time.Duration
+	err  error
+}
+
+func (f *fakeReplica) GetProduct(ctx context.Context, id int32) (db.Product, error) {
+	return db.Product{ID: id, Name: f.name}, f.err
+}
+
+func (f *fakeReplica) ListProducts(ctx context.Context, arg db.ListProductsParams) ([]db.Product, error) {
+	return []db.Product{{Name: f.name}}, f.err
+}
+
+func (f *fakeReplica) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	return f.lag, f.err
+}
+
+var testOptions = Options{
+	MaxLag:        time.Second,
+	CheckInterval: time.Second,
+	CheckTimeout:  time.Second,
+	StickyWindow:  10 * time.Second,
+}
+
+func newTestRouter(t *testing.T, replicas ...*fakeReplica) (*Router, *mockdb.MockStore) {
+	primary := mockdb.NewMockStore(gomock.NewController(t))
+
+	backends := make([]Replica, 0, len(replicas))
+	for _, r := range replicas {
+		backends = append(backends, Replica{Name: r.name, Querier: r})
+	}
+
+	return NewRouter(primary, testOptions, backends...), primary
+}
+
+func readFrom(t *testing.T, ctx context.Context, router *Router) string {
+	product, err := router.GetProduct(ctx, 1)
+	require.NoError(t, err)
+	return product.Name
+}
+
+func TestRoundRobin(t *testing.T) {
+	router, _ := newTestRouter(t, &fakeReplica{name: "a"}, &fakeReplica{name: "b"}, &fakeReplica{name: "lagging", lag: time.Minute})
+	router.check(context.Background())
+
+	served := map[string]int{}
+	for i := 0; i < 6; i++ {
+		served[readFrom(t, context.Background(), router)]++
+	}
+	require.Equal(t, map[string]int{"a": 3, "b": 3}, served)
+
+	products, err := router.ListProducts(context.Background(), db.ListProductsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Contains(t, []string{"a", "b"}, products[0].Name)
+}
+
+func TestPrimaryWithoutHealthyReplicas(t *testing.T) {
+	down := &fakeReplica{name: "down", err: errors.New("connection refused")}
+	router, primary := newTestRouter(t, down)
+	primary.EXPECT().GetProduct(gomock.Any(), int32(1)).Times(2).Return(db.Product{Name: "primary"}, nil)
+
+	// not checked yet
+	require.Equal(t, "primary", readFrom(t, context.Background(), router))
+
+	router.check(context.Background())
+	require.Equal(t, "primary", readFrom(t, context.Background(), router))
+
+	down.err = nil
+	router.check(context.Background())
+	require.Equal(t, "down", readFrom(t, context.Background(), router))
+}
+
+func TestRetryOnPrimary(t *testing.T) {
+	flaky := &fakeReplica{name: "flaky"}
+	router, primary := newTestRouter(t, flaky)
+	router.check(context.Background())
+
+	flaky.err = errors.New("connection reset")
+	primary.EXPECT().GetProduct(gomock.Any(), int32(1)).Times(1).Return(db.Product{Name: "primary"}, nil)
+	require.Equal(t, "primary", readFrom(t, context.Background(), router))
+
+	// a product missing from the replica is not looked up again
+	flaky.err = sql.ErrNoRows
+	_, err := router.GetProduct(context.Background(), 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestReadYourWrites(t *testing.T) {
+	router, primary := newTestRouter(t, &fakeReplica{name: "replica"})
+	router.check(context.Background())
+
+	now := time.Now()
+	router.now = func() time.Time { return now }
+
+	writer := WithClient(context.Background(), "ip:10.0.0.1")
+	other := WithClient(context.Background(), "ip:10.0.0.2")
+
+	primary.EXPECT().UpdateProductStatus(gomock.Any(), gomock.Any()).Times(1).Return(db.Product{}, nil)
+	_, err := router.UpdateProductStatus(writer, db.UpdateProductStatusParams{ID: 1})
+	require.NoError(t, err)
+
+	primary.EXPECT().GetProduct(gomock.Any(), int32(1)).Times(1).Return(db.Product{Name: "primary"}, nil)
+	require.Equal(t, "primary", readFrom(t, writer, router))
+	require.Equal(t, "replica", readFrom(t, other, router))
+
+	now = now.Add(testOptions.StickyWindow)
+	require.Equal(t, "replica", readFrom(t, writer, router))
+
+	router.sweep()
+	require.Empty(t, router.writes)
+}
+
+func TestReadYourWritesInTx(t *testing.T) {
+	router, primary := newTestRouter(t, &fakeReplica{name: "replica"})
+	router.check(context.Background())
+
+	tx := mockdb.NewMockQuerier(gomock.NewController(t))
+	primary.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+		return fn(tx)
+	})
+
+	// locking a rate limit bucket is not a catalog write
+	limiter := WithClient(context.Background(), "ip:10.0.0.1")
+	tx.EXPECT().LockRateLimitBucket(gomock.Any(), gomock.Any()).Times(1).Return(db.LockRateLimitBucketRow{}, nil)
+	err := router.ExecTx(limiter, func(q db.Querier) error {
+		_, err := q.LockRateLimitBucket(limiter, db.LockRateLimitBucketParams{})
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "replica", readFrom(t, limiter, router))
+
+	writer := WithClient(context.Background(), "ip:10.0.0.2")
+	tx.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Times(1).Return(db.Product{}, nil)
+	err = router.ExecTx(writer, func(q db.Querier) error {
+		_, err := q.CreateProduct(writer, db.CreateProductParams{})
+		return err
+	})
+	require.NoError(t, err)
+
+	primary.EXPECT().GetProduct(gomock.Any(), int32(1)).Times(1).Return(db.Product{Name: "primary"}, nil)
+	require.Equal(t, "primary", readFrom(t, writer, router))
+}
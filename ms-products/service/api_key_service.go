@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+)
+
+const (
+	apiKeyPrefix       = "msp_"
+	apiKeyDisplayChars = 8
+	apiKeySecretBytes  = 32
+)
+
+var (
+	ErrInvalidAPIKey  = NewError(KindUnauthenticated, CodeInvalidAPIKey, "invalid api key")
+	ErrInactiveAPIKey = NewError(KindConflict, CodeAPIKeyInactive, "api key is revoked or expired")
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int32) (*model.APIKey, error)
+	RotateAPIKey(ctx context.Context, keyID int32, overlap time.Duration) (*model.CreatedAPIKey, error)
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+type apiKeyService struct {
+	config     configs.Config
+	repository db.Store
+	now        func() time.Time
+}
+
+var _ APIKeyService = (*apiKeyService)(nil)
+
+func NewAPIKeyService(config configs.Config, repository db.Store) APIKeyService {
+	return &apiKeyService{
+		config:     config,
+		repository: repository,
+		now:        time.Now,
+	}
+}
+
+func (as *apiKeyService) CreateAPIKey(ctx context.Context, req model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error) {
+	key, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.CreateAPIKeyParams{
+		Name:      req.Name,
+		Prefix:    key[:len(apiKeyPrefix)+apiKeyDisplayChars],
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		RateLimit: req.RateLimit,
+	}
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	created, err := as.repository.CreateAPIKey(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CreatedAPIKey{APIKey: *model.APIKeyDbToModel(created), Key: key}, nil
+}
+
+func (as *apiKeyService) ListAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	keys, err := as.repository.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ListAPIKeysDbToModel(keys), nil
+}
+
+func (as *apiKeyService) RevokeAPIKey(ctx context.Context, keyID int32) (*model.APIKey, error) {
+	key, err := as.repository.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		return nil, repositoryError(err, "api key", CodeAPIKeyNotFound)
+	}
+
+	return model.APIKeyDbToModel(key), nil
+}
+
+// RotateAPIKey issues a replacement with the same name, scopes and limits. The
+// old key keeps working until overlap has passed so clients can switch over.
+func (as *apiKeyService) RotateAPIKey(ctx context.Context, keyID int32, overlap time.Duration) (*model.CreatedAPIKey, error) {
+	if overlap == 0 {
+		overlap = as.config.APIKeyRotationOverlap
+	}
+
+	key, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := as.now()
+	var created db.ApiKey
+	err = as.repository.ExecTx(ctx, func(q db.Querier) error {
+		old, err := q.GetAPIKey(ctx, keyID)
+		if err != nil {
+			return err
+		}
+
+		if !model.APIKeyDbToModel(old).Active(now) {
+			return ErrInactiveAPIKey
+		}
+
+		created, err = q.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+			Name:        old.Name,
+			Prefix:      key[:len(apiKeyPrefix)+apiKeyDisplayChars],
+			KeyHash:     hash,
+			Scopes:      old.Scopes,
+			RateLimit:   old.RateLimit,
+			RotatedFrom: sql.NullInt32{Int32: old.ID, Valid: true},
+			ExpiresAt:   old.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExpireAPIKey(ctx, db.ExpireAPIKeyParams{
+			ID:        old.ID,
+			ExpiresAt: now.Add(overlap),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, repositoryError(err, "api key", CodeAPIKeyNotFound)
+	}
+
+	return &model.CreatedAPIKey{APIKey: *model.APIKeyDbToModel(created), Key: key}, nil
+}
+
+func (as *apiKeyService) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	found, err := as.repository.GetAPIKeyByHash(ctx, hash[:])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, err
+	}
+
+	result := model.APIKeyDbToModel(found)
+	if !result.Active(as.now()) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := as.repository.TouchAPIKey(ctx, found.ID); err != nil {
+		slog.WarnContext(ctx, "cannot update last use of api key", "api_key_id", found.ID, "error", err)
+	}
+
+	return result, nil
+}
+
+// generateAPIKey returns a new plaintext key and the sha256 stored in its
+// place. Keys carry 256 bits of entropy, so a fast hash is enough.
+func generateAPIKey() (string, []byte, error) {
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(key))
+	return key, hash[:], nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestAPIKeyService(t *testing.T, now time.Time) (*apiKeyService, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+
+	config := configs.Config{APIKeyRotationOverlap: time.Hour}
+	service := NewAPIKeyService(config, repository).(*apiKeyService)
+	service.now = func() time.Time { return now }
+
+	return service, repository
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	service, repository := newTestAPIKeyService(t, time.Now())
+	req := model.CreateAPIKeyRequest{
+		Name:      "nightly-sync",
+		Scopes:    []string{"products:update"},
+		RateLimit: 60,
+	}
+
+	var arg db.CreateAPIKeyParams
+	repository.EXPECT().
+		CreateAPIKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, params db.CreateAPIKeyParams) (db.ApiKey, error) {
+			arg = params
+			return db.ApiKey{ID: 1, Name: params.Name, Prefix: params.Prefix, Scopes: params.Scopes, RateLimit: params.RateLimit}, nil
+		})
+
+	key, err := service.CreateAPIKey(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key.Key, apiKeyPrefix))
+	require.True(t, strings.HasPrefix(key.Key, key.Prefix))
+	require.Equal(t, req.Scopes, key.Scopes)
+
+	hash := sha256.Sum256([]byte(key.Key))
+	require.Equal(t, hash[:], arg.KeyHash)
+	require.NotContains(t, string(arg.KeyHash), key.Key)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	key := "msp_secret"
+	hash := sha256.Sum256([]byte(key))
+
+	testCases := []struct {
+		name       string
+		key        string
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, result *model.APIKey, err error)
+	}{
+		{
+			name: "OK",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash[:])).
+					Times(1).
+					Return(db.ApiKey{ID: 1, Scopes: []string{"imports:read"}, ExpiresAt: sql.NullTime{Time: future, Valid: true}}, nil)
+
+				repository.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Eq(int32(1))).
+					Times(1).
+					Return(nil)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"imports:read"}, result.Scopes)
+			},
+		},
+		{
+			name: "Touch Fails",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Eq(hash[:])).
+					Times(1).
+					Return(db.ApiKey{ID: 1}, nil)
+
+				repository.EXPECT().
+					TouchAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, result)
+			},
+		},
+		{
+			name: "Wrong Prefix",
+			key:  "secret",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.ErrorIs(t, err, ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "Unknown",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.ErrorIs(t, err, ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "Revoked",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{ID: 1, RevokedAt: sql.NullTime{Time: past, Valid: true}}, nil)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.ErrorIs(t, err, ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "Expired",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{ID: 1, ExpiresAt: sql.NullTime{Time: past, Valid: true}}, nil)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.ErrorIs(t, err, ErrInvalidAPIKey)
+			},
+		},
+		{
+			name: "Internal Error",
+			key:  key,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, result *model.APIKey, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			service, repository := newTestAPIKeyService(t, now)
+			tC.buildStubs(repository)
+
+			result, err := service.Authenticate(context.Background(), tC.key)
+			tC.check(t, result, err)
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	now := time.Now()
+	old := db.ApiKey{ID: 1, Name: "nightly-sync", Scopes: []string{"products:update"}, RateLimit: 60}
+
+	execTx := func(repository *mockdb.MockStore) func(ctx context.Context, fn func(db.Querier) error) error {
+		return func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(repository)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		overlap    time.Duration
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, key *model.CreatedAPIKey, err error)
+	}{
+		{
+			name:    "OK",
+			overlap: 10 * time.Minute,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(old.ID)).Times(1).Return(old, nil)
+				repository.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+						require.Equal(t, old.Name, arg.Name)
+						require.Equal(t, old.Scopes, arg.Scopes)
+						require.Equal(t, old.RateLimit, arg.RateLimit)
+						require.Equal(t, sql.NullInt32{Int32: old.ID, Valid: true}, arg.RotatedFrom)
+						return db.ApiKey{ID: 2, Name: arg.Name, RotatedFrom: arg.RotatedFrom}, nil
+					})
+				repository.EXPECT().
+					ExpireAPIKey(gomock.Any(), gomock.Eq(db.ExpireAPIKeyParams{ID: old.ID, ExpiresAt: now.Add(10 * time.Minute)})).
+					Times(1).
+					Return(old, nil)
+			},
+			check: func(t *testing.T, key *model.CreatedAPIKey, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int32(2), key.ID)
+				require.Equal(t, old.ID, *key.RotatedFrom)
+				require.NotEmpty(t, key.Key)
+			},
+		},
+		{
+			name: "Default Overlap",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().GetAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(old, nil)
+				repository.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{ID: 2}, nil)
+				repository.EXPECT().
+					ExpireAPIKey(gomock.Any(), gomock.Eq(db.ExpireAPIKeyParams{ID: old.ID, ExpiresAt: now.Add(time.Hour)})).
+					Times(1).
+					Return(old, nil)
+			},
+			check: func(t *testing.T, key *model.CreatedAPIKey, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Revoked",
+			buildStubs: func(repository *mockdb.MockStore) {
+				revoked := old
+				revoked.RevokedAt = sql.NullTime{Time: now, Valid: true}
+
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().GetAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+				repository.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, key *model.CreatedAPIKey, err error) {
+				require.ErrorIs(t, err, ErrInactiveAPIKey)
+				require.Nil(t, key)
+			},
+		},
+		{
+			name: "Not Found",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().GetAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			check: func(t *testing.T, key *model.CreatedAPIKey, err error) {
+				require.ErrorIs(t, err, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "Expire Fails",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().ExecTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(execTx(repository))
+				repository.EXPECT().GetAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(old, nil)
+				repository.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{ID: 2}, nil)
+				repository.EXPECT().ExpireAPIKey(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, errors.New("boom"))
+			},
+			check: func(t *testing.T, key *model.CreatedAPIKey, err error) {
+				require.Error(t, err)
+				require.Nil(t, key)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			service, repository := newTestAPIKeyService(t, now)
+			tC.buildStubs(repository)
+
+			key, err := service.RotateAPIKey(context.Background(), old.ID, tC.overlap)
+			tC.check(t, key, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/token"
+)
+
+var ErrInvalidCredentials = NewError(KindUnauthenticated, CodeInvalidCredentials, "invalid client credentials")
+
+type AuthService interface {
+	IssueToken(ctx context.Context, req model.IssueTokenRequest) (*model.IssueTokenResponse, error)
+}
+
+type authService struct {
+	config     configs.Config
+	tokenMaker token.Maker
+	accounts   map[string]serviceAccount
+}
+
+type serviceAccount struct {
+	role       string
+	secretHash []byte
+}
+
+var _ AuthService = (*authService)(nil)
+
+// NewAuthService reads the service accounts from config, each one given as
+// "client_id:role:hex(sha256(client_secret))".
+func NewAuthService(config configs.Config, tokenMaker token.Maker) (AuthService, error) {
+	accounts := make(map[string]serviceAccount, len(config.ServiceAccounts))
+	for _, account := range config.ServiceAccounts {
+		fields := strings.Split(account, ":")
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("invalid service account %q: expected client_id:role:sha256", fields[0])
+		}
+
+		clientID, role, digest := fields[0], fields[1], fields[2]
+		secretHash, err := hex.DecodeString(digest)
+		if err != nil || len(secretHash) != sha256.Size {
+			return nil, fmt.Errorf("invalid service account %q: secret must be a hex encoded sha256", clientID)
+		}
+
+		accounts[clientID] = serviceAccount{role: role, secretHash: secretHash}
+	}
+
+	return &authService{
+		config:     config,
+		tokenMaker: tokenMaker,
+		accounts:   accounts,
+	}, nil
+}
+
+func (as *authService) IssueToken(ctx context.Context, req model.IssueTokenRequest) (*model.IssueTokenResponse, error) {
+	account, ok := as.accounts[req.ClientID]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	given := sha256.Sum256([]byte(req.ClientSecret))
+	if subtle.ConstantTimeCompare(given[:], account.secretHash) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	accessToken, payload, err := as.tokenMaker.CreateToken(req.ClientID, account.role, as.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.IssueTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   model.TokenTypeBearer,
+		ExpiresAt:   payload.ExpiredAt,
+	}, nil
+}
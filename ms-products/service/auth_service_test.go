@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	"github.com/djudju12/ms-products/model"
+	"github.com/djudju12/ms-products/token"
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// sha256("order-service-secret")
+const orderServiceAccount = "order-service:merchandiser:db25c15f3611c175be093b5a12c7d7c2e667f969a49babda4db34b5b0a21635e"
+
+func newTestAuthService(t *testing.T, accounts ...string) (AuthService, token.Maker) {
+	tokenMaker, err := token.NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	config := configs.Config{
+		AccessTokenDuration: time.Minute,
+		ServiceAccounts:     accounts,
+	}
+
+	service, err := NewAuthService(config, tokenMaker)
+	require.NoError(t, err)
+
+	return service, tokenMaker
+}
+
+func TestIssueToken(t *testing.T) {
+	testCases := []struct {
+		name  string
+		req   model.IssueTokenRequest
+		check func(t *testing.T, tokenMaker token.Maker, res *model.IssueTokenResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  model.IssueTokenRequest{ClientID: "order-service", ClientSecret: "order-service-secret"},
+			check: func(t *testing.T, tokenMaker token.Maker, res *model.IssueTokenResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, model.TokenTypeBearer, res.TokenType)
+				require.WithinDuration(t, time.Now().Add(time.Minute), res.ExpiresAt, time.Second)
+
+				payload, err := tokenMaker.VerifyToken(res.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, "order-service", payload.Subject)
+				require.Equal(t, "merchandiser", payload.Role)
+			},
+		},
+		{
+			name: "Wrong Secret",
+			req:  model.IssueTokenRequest{ClientID: "order-service", ClientSecret: "wrong"},
+			check: func(t *testing.T, tokenMaker token.Maker, res *model.IssueTokenResponse, err error) {
+				require.ErrorIs(t, err, ErrInvalidCredentials)
+				require.Nil(t, res)
+			},
+		},
+		{
+			name: "Unknown Client",
+			req:  model.IssueTokenRequest{ClientID: "unknown", ClientSecret: "order-service-secret"},
+			check: func(t *testing.T, tokenMaker token.Maker, res *model.IssueTokenResponse, err error) {
+				require.ErrorIs(t, err, ErrInvalidCredentials)
+				require.Nil(t, res)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			service, tokenMaker := newTestAuthService(t, orderServiceAccount)
+
+			res, err := service.IssueToken(context.Background(), tC.req)
+			tC.check(t, tokenMaker, res, err)
+		})
+	}
+}
+
+func TestNewAuthServiceInvalidAccount(t *testing.T) {
+	tokenMaker, err := token.NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	for _, account := range []string{
+		"order-service",
+		"order-service:db25c15f3611c175be093b5a12c7d7c2e667f969a49babda4db34b5b0a21635e",
+		":admin:abcd",
+		"order-service::abcd",
+		"order-service:admin:not-hex",
+		"order-service:admin:abcd",
+	} {
+		_, err := NewAuthService(configs.Config{ServiceAccounts: []string{account}}, tokenMaker)
+		require.Error(t, err, account)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+)
+
+// ErrorKind classifies an Error by what the caller can do about it. The
+// controllers map each kind to an HTTP status.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindUnauthenticated
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooLarge
+	KindUnprocessable
+	KindRateLimited
+)
+
+// Error codes are part of the API: clients may switch on them, so a code is
+// never renamed or reused for a different problem.
+const (
+	CodeMalformedRequest         = "malformed_request"
+	CodeValidationFailed         = "validation_failed"
+	CodeInvalidImport            = "invalid_import"
+	CodeUnauthenticated          = "unauthenticated"
+	CodeInvalidToken             = "invalid_token"
+	CodeInvalidCredentials       = "invalid_credentials"
+	CodeInvalidAPIKey            = "invalid_api_key"
+	CodePermissionDenied         = "permission_denied"
+	CodeNotFound                 = "not_found"
+	CodeProductNotFound          = "product_not_found"
+	CodeImportNotFound           = "import_not_found"
+	CodeAPIKeyNotFound           = "api_key_not_found"
+	CodeAlreadyExists            = "already_exists"
+	CodeAPIKeyInactive           = "api_key_inactive"
+	CodeIdempotencyKeyInProgress = "idempotency_key_in_progress"
+	CodePayloadTooLarge          = "payload_too_large"
+	CodeIdempotencyKeyReused     = "idempotency_key_reused"
+	CodeRateLimited              = "rate_limited"
+	CodeInternal                 = "internal"
+)
+
+// Error is an error meant for API clients. Its message is safe to show them;
+// Err keeps the underlying cause for errors.Is and errors.As.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []model.FieldProblem
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewError(kind ErrorKind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(message string, fields ...model.FieldProblem) *Error {
+	return &Error{Kind: KindValidation, Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+func NotFound(code string, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// uniqueFields names the request field behind each unique constraint.
+var uniqueFields = map[string]string{
+	"products_name_key": "name",
+}
+
+// repositoryError translates the database errors a client can act on, with
+// the code of their kind: a missing row becomes a not found error and a unique
+// violation a conflict. notFoundCode overrides CodeNotFound with the code of
+// the resource. Anything else is returned as is.
+func repositoryError(err error, resource string, notFoundCode ...string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		code := CodeNotFound
+		if len(notFoundCode) > 0 {
+			code = notFoundCode[0]
+		}
+
+		return &Error{Kind: KindNotFound, Code: code, Message: resource + " not found", Err: err}
+	}
+
+	var dbErr *db.Error
+	if errors.As(err, &dbErr) && dbErr.Code == db.CodeUniqueViolation {
+		conflict := &Error{Kind: KindConflict, Code: CodeAlreadyExists, Message: resource + " already exists", Err: err}
+		if field, ok := uniqueFields[dbErr.Constraint]; ok {
+			conflict.Fields = []model.FieldProblem{{Field: field, Rule: "unique", Message: "is already taken"}}
+		}
+
+		return conflict
+	}
+
+	return err
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryError(t *testing.T) {
+	t.Run("Not Found", func(t *testing.T) {
+		err := repositoryError(sql.ErrNoRows, "product", CodeProductNotFound)
+
+		var serviceErr *Error
+		require.True(t, errors.As(err, &serviceErr))
+		require.Equal(t, KindNotFound, serviceErr.Kind)
+		require.Equal(t, CodeProductNotFound, serviceErr.Code)
+		require.Equal(t, "product not found", err.Error())
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Unique Violation", func(t *testing.T) {
+		dbErr := &db.Error{Code: db.CodeUniqueViolation, Constraint: "products_name_key"}
+		err := repositoryError(dbErr, "product")
+
+		var serviceErr *Error
+		require.True(t, errors.As(err, &serviceErr))
+		require.Equal(t, KindConflict, serviceErr.Kind)
+		require.Equal(t, CodeAlreadyExists, serviceErr.Code)
+		require.Equal(t, []model.FieldProblem{{Field: "name", Rule: "unique", Message: "is already taken"}}, serviceErr.Fields)
+		require.NotContains(t, err.Error(), "products_name_key")
+	})
+
+	t.Run("Not Found Without Override", func(t *testing.T) {
+		err := repositoryError(sql.ErrNoRows, "product")
+
+		var serviceErr *Error
+		require.True(t, errors.As(err, &serviceErr))
+		require.Equal(t, KindNotFound, serviceErr.Kind)
+		require.Equal(t, CodeNotFound, serviceErr.Code)
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		err := repositoryError(sql.ErrConnDone, "product")
+		require.Equal(t, sql.ErrConnDone, err)
+	})
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/djudju12/ms-products/model"
+	"github.com/xuri/excelize/v2"
+)
+
+var exportHeader = []string{"id", "name", "price", "description", "status", "created_at", "updated_at"}
+
+// productExporter writes products as they are read. Close finishes the output,
+// Discard only releases what the exporter holds once an export has failed.
+type productExporter interface {
+	Write(product *model.Product) error
+	Close() error
+	Discard()
+}
+
+func newProductExporter(format string, w io.Writer) (productExporter, error) {
+	switch format {
+	case model.ExportFormatCSV:
+		return newCSVExporter(w)
+	case model.ExportFormatJSONL:
+		return &jsonlExporter{encoder: json.NewEncoder(w)}, nil
+	case model.ExportFormatXLSX:
+		return newXLSXExporter(w)
+	}
+
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) (*csvExporter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return nil, err
+	}
+
+	return &csvExporter{writer: writer}, nil
+}
+
+func (e *csvExporter) Write(product *model.Product) error {
+	return e.writer.Write([]string{
+		strconv.Itoa(int(product.ID)),
+		product.Name,
+		product.Price,
+		product.Description,
+		product.Status,
+		product.CreatedAt.Format(time.RFC3339),
+		product.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvExporter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Discard() {}
+
+type jsonlExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *jsonlExporter) Write(product *model.Product) error {
+	return e.encoder.Encode(product)
+}
+
+func (e *jsonlExporter) Close() error {
+	return nil
+}
+
+func (e *jsonlExporter) Discard() {}
+
+// xlsxExporter uses excelize's stream writer, which spills rows to a temporary
+// file instead of holding the sheet in memory. The zip container can only be
+// written once the last row is known, so output starts on Close.
+type xlsxExporter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExporter(w io.Writer) (*xlsxExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	e := &xlsxExporter{w: w, file: file, stream: stream}
+	header := make([]interface{}, len(exportHeader))
+	for i, column := range exportHeader {
+		header[i] = column
+	}
+
+	if err := e.writeRow(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *xlsxExporter) Write(product *model.Product) error {
+	return e.writeRow([]interface{}{
+		product.ID,
+		product.Name,
+		product.Price,
+		product.Description,
+		product.Status,
+		product.CreatedAt.Format(time.RFC3339),
+		product.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *xlsxExporter) writeRow(values []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+
+	return e.stream.SetRow(cell, values)
+}
+
+func (e *xlsxExporter) Close() error {
+	defer e.file.Close()
+
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+
+	return e.file.Write(e.w)
+}
+
+// Discard removes the temporary file the stream writer spilled rows to.
+func (e *xlsxExporter) Discard() {
+	e.file.Close()
+}
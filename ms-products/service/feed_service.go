@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+)
+
+// feedWatermarkOverlap re-reads recent changes on every refresh, so rows from
+// transactions that committed after a later one are not skipped.
+const feedWatermarkOverlap = time.Minute
+
+type FeedService interface {
+	GoogleFeed(ctx context.Context, format string) (*model.Feed, error)
+}
+
+type feedService struct {
+	config     configs.Config
+	repository db.Store
+	now        func() time.Time
+
+	mu          sync.Mutex
+	products    map[int32]db.Product
+	watermark   time.Time
+	refreshedAt time.Time
+	rendered    map[string]*model.Feed
+}
+
+var _ FeedService = (*feedService)(nil)
+
+func NewFeedService(config configs.Config, repository db.Store) FeedService {
+	return &feedService{
+		config:     config,
+		repository: repository,
+		now:        time.Now,
+		products:   make(map[int32]db.Product),
+		rendered:   make(map[string]*model.Feed),
+	}
+}
+
+func (fs *feedService) GoogleFeed(ctx context.Context, format string) (*model.Feed, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.refreshedAt.IsZero() || fs.now().Sub(fs.refreshedAt) >= fs.config.FeedRefreshInterval {
+		if err := fs.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if feed, ok := fs.rendered[format]; ok {
+		return feed, nil
+	}
+
+	feed, err := fs.render(format)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.rendered[format] = feed
+	return feed, nil
+}
+
+// refresh applies the products changed since the last refresh. The first call
+// loads the whole catalog.
+func (fs *feedService) refresh(ctx context.Context) error {
+	arg := db.ExportProductsParams{FetchSize: fs.config.ExportFetchSize}
+	if !fs.watermark.IsZero() {
+		arg.UpdatedSince = sql.NullTime{Time: fs.watermark.Add(-feedWatermarkOverlap), Valid: true}
+	}
+
+	changed := false
+	watermark := fs.watermark
+	err := fs.repository.ExportProducts(ctx, arg, func(product db.Product) error {
+		if product.UpdatedAt.After(watermark) {
+			watermark = product.UpdatedAt
+		}
+
+		current, ok := fs.products[product.ID]
+		if product.Status == model.ProductStatusInactive {
+			if ok {
+				delete(fs.products, product.ID)
+				changed = true
+			}
+
+			return nil
+		}
+
+		if ok && current.UpdatedAt.Equal(product.UpdatedAt) {
+			return nil
+		}
+
+		fs.products[product.ID] = product
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fs.watermark = watermark
+	fs.refreshedAt = fs.now()
+	if changed {
+		fs.rendered = make(map[string]*model.Feed)
+	}
+
+	return nil
+}
+
+func (fs *feedService) render(format string) (*model.Feed, error) {
+	ids := make([]int32, 0, len(fs.products))
+	for id := range fs.products {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var document any
+	var contentType string
+	switch format {
+	case model.FeedFormatRSS:
+		rss := model.RSSFeed{
+			Version: "2.0",
+			NSG:     model.GoogleMerchantNamespace,
+			Channel: model.RSSChannel{
+				Title:       fs.config.FeedTitle,
+				Link:        fs.config.FeedLink,
+				Description: fs.config.FeedTitle,
+				Items:       make([]model.RSSItem, 0, len(ids)),
+			},
+		}
+		for _, id := range ids {
+			rss.Channel.Items = append(rss.Channel.Items, model.RSSItem{FeedItem: fs.feedItem(fs.products[id])})
+		}
+
+		document, contentType = rss, "application/rss+xml; charset=utf-8"
+	case model.FeedFormatAtom:
+		atom := model.AtomFeed{
+			NS:      "http://www.w3.org/2005/Atom",
+			NSG:     model.GoogleMerchantNamespace,
+			ID:      fs.config.FeedLink,
+			Title:   fs.config.FeedTitle,
+			Link:    model.AtomLink{Href: fs.config.FeedLink},
+			Updated: fs.watermark.UTC().Format(time.RFC3339),
+			Entries: make([]model.AtomEntry, 0, len(ids)),
+		}
+		for _, id := range ids {
+			product := fs.products[id]
+			atom.Entries = append(atom.Entries, model.AtomEntry{
+				FeedItem: fs.feedItem(product),
+				Updated:  product.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		document, contentType = atom, "application/atom+xml; charset=utf-8"
+	default:
+		return nil, fmt.Errorf("unknown feed format %q", format)
+	}
+
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	body = append([]byte(xml.Header), body...)
+	sum := sha256.Sum256(body)
+
+	return &model.Feed{
+		ContentType: contentType,
+		Body:        body,
+		ETag:        fmt.Sprintf("%q", hex.EncodeToString(sum[:16])),
+		UpdatedAt:   fs.watermark,
+	}, nil
+}
+
+func (fs *feedService) feedItem(product db.Product) model.FeedItem {
+	availability := model.FeedAvailabilityInStock
+	if product.Status == model.ProductStatusOutOfStock {
+		availability = model.FeedAvailabilityOutOfStock
+	}
+
+	return model.FeedItem{
+		ID:           fmt.Sprintf("product-%d", product.ID),
+		Title:        product.Name,
+		Description:  product.Description,
+		Link:         fmt.Sprintf(fs.config.FeedProductURL, product.ID),
+		ImageLink:    fmt.Sprintf(fs.config.FeedImageURL, product.ID),
+		Price:        fmt.Sprintf("%s %s", product.Price, fs.config.FeedCurrency),
+		Availability: availability,
+		Condition:    "new",
+	}
+}
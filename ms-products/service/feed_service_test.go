@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestFeedService(t *testing.T, now *time.Time) (*feedService, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+
+	config := configs.Config{
+		FeedTitle:           "catalog",
+		FeedLink:            "https://shop.example.com",
+		FeedProductURL:      "https://shop.example.com/products/%d",
+		FeedImageURL:        "https://cdn.example.com/%d.jpg",
+		FeedCurrency:        "BRL",
+		FeedRefreshInterval: time.Minute,
+	}
+
+	service := NewFeedService(config, repository).(*feedService)
+	service.now = func() time.Time { return *now }
+
+	return service, repository
+}
+
+func streamFeedProducts(products ...db.Product) func(context.Context, db.ExportProductsParams, func(db.Product) error) error {
+	return func(ctx context.Context, arg db.ExportProductsParams, fn func(db.Product) error) error {
+		for _, product := range products {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestGoogleFeed(t *testing.T) {
+	now := time.Now()
+	service, repository := newTestFeedService(t, &now)
+
+	available := RandomProduct()
+	available.Status = model.ProductStatusAvailable
+	outOfStock := RandomProduct()
+	outOfStock.ID = available.ID + 1
+	outOfStock.Status = model.ProductStatusOutOfStock
+
+	repository.EXPECT().
+		ExportProducts(gomock.Any(), gomock.Eq(db.ExportProductsParams{}), gomock.Any()).
+		Times(1).
+		DoAndReturn(streamFeedProducts(available, outOfStock))
+
+	feed, err := service.GoogleFeed(context.Background(), model.FeedFormatRSS)
+	require.NoError(t, err)
+	require.Equal(t, "application/rss+xml; charset=utf-8", feed.ContentType)
+	require.NotEmpty(t, feed.ETag)
+
+	var rss struct {
+		Items []struct {
+			ID           string `xml:"id"`
+			Price        string `xml:"price"`
+			Availability string `xml:"availability"`
+			Link         string `xml:"link"`
+		} `xml:"channel>item"`
+	}
+	require.NoError(t, xml.Unmarshal(feed.Body, &rss))
+	require.Len(t, rss.Items, 2)
+	require.Equal(t, fmt.Sprintf("product-%d", available.ID), rss.Items[0].ID)
+	require.Equal(t, available.Price+" BRL", rss.Items[0].Price)
+	require.Equal(t, model.FeedAvailabilityInStock, rss.Items[0].Availability)
+	require.Equal(t, fmt.Sprintf("https://shop.example.com/products/%d", available.ID), rss.Items[0].Link)
+	require.Equal(t, model.FeedAvailabilityOutOfStock, rss.Items[1].Availability)
+
+	// within the refresh interval the cached feed is served as is
+	cached, err := service.GoogleFeed(context.Background(), model.FeedFormatRSS)
+	require.NoError(t, err)
+	require.Same(t, feed, cached)
+
+	// after the interval only the products changed since the watermark are read
+	now = now.Add(2 * time.Minute)
+	inactive := outOfStock
+	inactive.Status = model.ProductStatusInactive
+	inactive.UpdatedAt = outOfStock.UpdatedAt.Add(time.Second)
+
+	repository.EXPECT().
+		ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.ExportProductsParams, fn func(db.Product) error) error {
+			require.Equal(t, sql.NullTime{Time: outOfStock.UpdatedAt.Add(-feedWatermarkOverlap), Valid: true}, arg.UpdatedSince)
+			return streamFeedProducts(available, inactive)(ctx, arg, fn)
+		})
+
+	refreshed, err := service.GoogleFeed(context.Background(), model.FeedFormatAtom)
+	require.NoError(t, err)
+	require.Equal(t, "application/atom+xml; charset=utf-8", refreshed.ContentType)
+
+	var atom struct {
+		Entries []struct {
+			ID string `xml:"id"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal(refreshed.Body, &atom))
+	require.Len(t, atom.Entries, 1)
+	require.Equal(t, fmt.Sprintf("product-%d", available.ID), atom.Entries[0].ID)
+}
+
+func TestGoogleFeedRepositoryError(t *testing.T) {
+	now := time.Now()
+	service, repository := newTestFeedService(t, &now)
+
+	repository.EXPECT().
+		ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(errors.New("some error"))
+
+	feed, err := service.GoogleFeed(context.Background(), model.FeedFormatRSS)
+	require.Error(t, err)
+	require.Empty(t, feed)
+}
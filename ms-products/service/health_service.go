@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+)
+
+type HealthService interface {
+	Readiness(ctx context.Context) *model.Health
+	WaitUntilReady(ctx context.Context) error
+	Drain()
+}
+
+type healthCheck struct {
+	name string
+	// check returns a detail shown even when it passes
+	check func(ctx context.Context) (string, error)
+}
+
+type healthService struct {
+	config     configs.Config
+	repository db.Store
+	checks     []healthCheck
+	sleep      func(ctx context.Context, d time.Duration) error
+	draining   atomic.Bool
+}
+
+var _ HealthService = (*healthService)(nil)
+
+func NewHealthService(config configs.Config, repository db.Store) HealthService {
+	hs := &healthService{
+		config:     config,
+		repository: repository,
+		sleep:      sleep,
+	}
+
+	hs.checks = []healthCheck{
+		{name: "database", check: hs.checkDatabase},
+		{name: "migrations", check: hs.checkMigrations},
+	}
+
+	return hs
+}
+
+// Readiness runs every check with its own timeout. The service is ready only
+// when all of them pass. The probe is public, so a failing check only reads
+// unavailable and its cause is logged instead.
+func (hs *healthService) Readiness(ctx context.Context) *model.Health {
+	if hs.draining.Load() {
+		return &model.Health{
+			Status: model.HealthStatusDown,
+			Checks: []model.HealthCheck{{Name: "shutdown", Status: model.HealthStatusDown, Detail: "shutting down"}},
+		}
+	}
+
+	health, err := hs.readiness(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "service not ready", "failing", err)
+	}
+
+	return health
+}
+
+// Drain fails every readiness check from now on, so the load balancer stops
+// routing new requests here while the ones in flight finish.
+func (hs *healthService) Drain() {
+	hs.draining.Store(true)
+}
+
+// WaitUntilReady retries the readiness checks at startup, so the process fails
+// fast with the failing checks instead of serving requests it cannot answer.
+func (hs *healthService) WaitUntilReady(ctx context.Context) error {
+	var err error
+	for attempt := 1; attempt <= hs.config.StartupAttempts; attempt++ {
+		if _, err = hs.readiness(ctx); err == nil {
+			return nil
+		}
+
+		slog.WarnContext(ctx, "service not ready", "attempt", attempt, "failing", err)
+		if attempt == hs.config.StartupAttempts {
+			break
+		}
+
+		if err := hs.sleep(ctx, hs.config.StartupRetryInterval); err != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("not ready after %d attempts: %w", hs.config.StartupAttempts, err)
+}
+
+// readiness runs the checks and returns the causes of the failing ones, which
+// the result leaves out, as a single error.
+func (hs *healthService) readiness(ctx context.Context) (*model.Health, error) {
+	health := &model.Health{Status: model.HealthStatusUp}
+	var failing []string
+	for _, c := range hs.checks {
+		result, err := hs.run(ctx, c)
+		if err != nil {
+			health.Status = model.HealthStatusDown
+			failing = append(failing, fmt.Sprintf("%s: %s", c.name, err))
+		}
+		health.Checks = append(health.Checks, result)
+	}
+
+	if len(failing) > 0 {
+		return health, errors.New(strings.Join(failing, "; "))
+	}
+
+	return health, nil
+}
+
+func (hs *healthService) run(ctx context.Context, c healthCheck) (model.HealthCheck, error) {
+	ctx, cancel := context.WithTimeout(ctx, hs.config.HealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := c.check(ctx)
+	result := model.HealthCheck{
+		Name:       c.name,
+		Status:     model.HealthStatusUp,
+		Detail:     detail,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = model.HealthStatusDown
+		result.Detail = "unavailable"
+	}
+
+	return result, err
+}
+
+func (hs *healthService) checkDatabase(ctx context.Context) (string, error) {
+	return "", hs.repository.Ping(ctx)
+}
+
+func (hs *healthService) checkMigrations(ctx context.Context) (string, error) {
+	version, err := hs.repository.SchemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case version.Dirty:
+		return "", fmt.Errorf("schema version %d is dirty, fix it by hand and force the version with the migrate command", version.Version)
+	case version.Version > db.ExpectedSchemaVersion:
+		return "", fmt.Errorf("schema version %d is newer than the latest known migration %d", version.Version, db.ExpectedSchemaVersion)
+	case version.Version < db.ExpectedSchemaVersion:
+		return "", fmt.Errorf("schema version is %d, expected %d", version.Version, db.ExpectedSchemaVersion)
+	}
+
+	return fmt.Sprintf("schema version %d", version.Version), nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestHealthService(t *testing.T) (*healthService, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+
+	config := configs.Config{
+		HealthCheckTimeout:   time.Second,
+		StartupAttempts:      3,
+		StartupRetryInterval: time.Second,
+	}
+
+	service := NewHealthService(config, repository).(*healthService)
+	service.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+
+	return service, repository
+}
+
+func TestReadiness(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(repository *mockdb.MockStore)
+		status     string
+		details    []string
+		failing    string
+	}{
+		{
+			name: "Ready",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().Ping(gomock.Any()).Return(nil)
+				repository.EXPECT().SchemaVersion(gomock.Any()).
+					Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion}, nil)
+			},
+			status:  model.HealthStatusUp,
+			details: []string{"", "schema version 9"},
+		},
+		{
+			name: "Database Down",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().Ping(gomock.Any()).Return(sql.ErrConnDone)
+				repository.EXPECT().SchemaVersion(gomock.Any()).Return(db.SchemaVersion{}, sql.ErrConnDone)
+			},
+			status:  model.HealthStatusDown,
+			details: []string{"unavailable", "unavailable"},
+			failing: "database: " + sql.ErrConnDone.Error() + "; migrations: " + sql.ErrConnDone.Error(),
+		},
+		{
+			name: "Outdated Schema",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().Ping(gomock.Any()).Return(nil)
+				repository.EXPECT().SchemaVersion(gomock.Any()).
+					Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion - 1}, nil)
+			},
+			status:  model.HealthStatusDown,
+			details: []string{"", "unavailable"},
+			failing: "migrations: schema version is 8, expected 9",
+		},
+		{
+			name: "Dirty Schema",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().Ping(gomock.Any()).Return(nil)
+				repository.EXPECT().SchemaVersion(gomock.Any()).
+					Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion, Dirty: true}, nil)
+			},
+			status:  model.HealthStatusDown,
+			details: []string{"", "unavailable"},
+			failing: "migrations: schema version 9 is dirty, fix it by hand and force the version with the migrate command",
+		},
+		{
+			name: "Newer Schema",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().Ping(gomock.Any()).Return(nil)
+				repository.EXPECT().SchemaVersion(gomock.Any()).
+					Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion + 1}, nil)
+			},
+			status:  model.HealthStatusDown,
+			details: []string{"", "unavailable"},
+			failing: "migrations: schema version 10 is newer than the latest known migration 9",
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			service, repository := newTestHealthService(t)
+			tC.buildStubs(repository)
+
+			// when
+			health, err := service.readiness(context.Background())
+
+			// then
+			require.Equal(t, tC.status, health.Status)
+			require.Len(t, health.Checks, len(tC.details))
+			for i, check := range health.Checks {
+				require.Equal(t, tC.details[i], check.Detail)
+			}
+			if tC.failing == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tC.failing)
+			}
+		})
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	// given
+	service, repository := newTestHealthService(t)
+	service.config.HealthCheckTimeout = 10 * time.Millisecond
+	repository.EXPECT().Ping(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	repository.EXPECT().SchemaVersion(gomock.Any()).
+		Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion}, nil)
+
+	// when
+	health := service.Readiness(context.Background())
+
+	// then
+	require.Equal(t, model.HealthStatusDown, health.Status)
+	// the cause is only logged, the probe is public
+	require.Equal(t, "unavailable", health.Checks[0].Detail)
+}
+
+func TestWaitUntilReady(t *testing.T) {
+	// given
+	service, repository := newTestHealthService(t)
+	gomock.InOrder(
+		repository.EXPECT().Ping(gomock.Any()).Return(sql.ErrConnDone),
+		repository.EXPECT().Ping(gomock.Any()).Return(nil),
+	)
+	repository.EXPECT().SchemaVersion(gomock.Any()).
+		Times(2).
+		Return(db.SchemaVersion{Version: db.ExpectedSchemaVersion}, nil)
+
+	// when
+	err := service.WaitUntilReady(context.Background())
+
+	// then
+	require.NoError(t, err)
+}
+
+func TestWaitUntilReadyGivesUp(t *testing.T) {
+	// given
+	service, repository := newTestHealthService(t)
+	repository.EXPECT().Ping(gomock.Any()).Times(3).Return(sql.ErrConnDone)
+	repository.EXPECT().SchemaVersion(gomock.Any()).Times(3).Return(db.SchemaVersion{}, sql.ErrConnDone)
+
+	// when
+	err := service.WaitUntilReady(context.Background())
+
+	// then
+	require.ErrorContains(t, err, "not ready after 3 attempts")
+	require.ErrorContains(t, err, "database: "+sql.ErrConnDone.Error())
+}
+
+func TestReadinessWhileDraining(t *testing.T) {
+	// given
+	service, repository := newTestHealthService(t)
+	repository.EXPECT().Ping(gomock.Any()).Times(0)
+	repository.EXPECT().SchemaVersion(gomock.Any()).Times(0)
+
+	// when
+	service.Drain()
+	health := service.Readiness(context.Background())
+
+	// then
+	require.Equal(t, model.HealthStatusDown, health.Status)
+	require.Len(t, health.Checks, 1)
+	require.Equal(t, "shutdown", health.Checks[0].Name)
+}
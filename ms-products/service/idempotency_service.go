@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+)
+
+const (
+	// idempotencyLockTimeout frees keys left in progress by a crashed replica.
+	idempotencyLockTimeout   = 5 * time.Minute
+	idempotencySweepInterval = time.Hour
+)
+
+var (
+	ErrIdempotencyKeyReused     = NewError(KindUnprocessable, CodeIdempotencyKeyReused, "idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = NewError(KindConflict, CodeIdempotencyKeyInProgress, "a request with this idempotency key is still in progress")
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, scope string, key string, requestHash []byte) (*model.IdempotentResponse, error)
+	Complete(ctx context.Context, scope string, key string, response model.IdempotentResponse) error
+	Release(ctx context.Context, scope string, key string) error
+}
+
+type idempotencyService struct {
+	config     configs.Config
+	repository db.Store
+	now        func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ IdempotencyService = (*idempotencyService)(nil)
+
+func NewIdempotencyService(config configs.Config, repository db.Store) IdempotencyService {
+	return &idempotencyService{
+		config:     config,
+		repository: repository,
+		now:        time.Now,
+	}
+}
+
+// Begin claims key for the caller identified by scope. It returns nil when the
+// request should run, or the stored response when it already ran.
+func (is *idempotencyService) Begin(ctx context.Context, scope string, key string, requestHash []byte) (*model.IdempotentResponse, error) {
+	is.sweep(ctx)
+
+	now := is.now()
+	_, err := is.repository.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+		Scope:           scope,
+		Key:             key,
+		RequestHash:     requestHash,
+		ExpiresAt:       now.Add(is.config.IdempotencyKeyTTL),
+		AbandonedBefore: now.Add(-idempotencyLockTimeout),
+	})
+	if err == nil {
+		return nil, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	existing, err := is.repository.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{Scope: scope, Key: key})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		return nil, err
+	}
+
+	if !bytes.Equal(existing.RequestHash, requestHash) {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if existing.Status != model.IdempotencyStatusCompleted {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return &model.IdempotentResponse{
+		Status:      int(existing.ResponseStatus),
+		ContentType: existing.ResponseContentType,
+		Body:        existing.ResponseBody,
+	}, nil
+}
+
+func (is *idempotencyService) Complete(ctx context.Context, scope string, key string, response model.IdempotentResponse) error {
+	return is.repository.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		Scope:               scope,
+		Key:                 key,
+		ResponseStatus:      int32(response.Status),
+		ResponseContentType: response.ContentType,
+		ResponseBody:        response.Body,
+	})
+}
+
+// Release forgets key so the request can be retried, used when it failed in a
+// way a retry may fix.
+func (is *idempotencyService) Release(ctx context.Context, scope string, key string) error {
+	return is.repository.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{Scope: scope, Key: key})
+}
+
+func (is *idempotencyService) sweep(ctx context.Context) {
+	is.mu.Lock()
+	now := is.now()
+	if now.Sub(is.lastSweep) < idempotencySweepInterval {
+		is.mu.Unlock()
+		return
+	}
+	is.lastSweep = now
+	is.mu.Unlock()
+
+	if err := is.repository.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+		slog.ErrorContext(ctx, "cannot delete expired idempotency keys", "error", err)
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestIdempotencyService(t *testing.T, now time.Time) (*idempotencyService, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+
+	config := configs.Config{IdempotencyKeyTTL: 24 * time.Hour}
+	service := NewIdempotencyService(config, repository).(*idempotencyService)
+	service.now = func() time.Time { return now }
+	service.lastSweep = now
+
+	return service, repository
+}
+
+func TestBeginIdempotentRequest(t *testing.T) {
+	now := time.Now()
+	hash := []byte("request-hash")
+	key := db.GetIdempotencyKeyParams{Scope: "user:order-service", Key: "retry-1"}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, response *model.IdempotentResponse, err error)
+	}{
+		{
+			name: "New Key",
+			buildStubs: func(repository *mockdb.MockStore) {
+				arg := db.CreateIdempotencyKeyParams{
+					Scope:           key.Scope,
+					Key:             key.Key,
+					RequestHash:     hash,
+					ExpiresAt:       now.Add(24 * time.Hour),
+					AbandonedBefore: now.Add(-idempotencyLockTimeout),
+				}
+
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.IdempotencyKey{Scope: key.Scope, Key: key.Key}, nil)
+
+				repository.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.NoError(t, err)
+				require.Nil(t, response)
+			},
+		},
+		{
+			name: "Replay",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+
+				repository.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(db.IdempotencyKey{
+						RequestHash:         hash,
+						Status:              model.IdempotencyStatusCompleted,
+						ResponseStatus:      201,
+						ResponseContentType: "application/json",
+						ResponseBody:        []byte(`{"id":1}`),
+					}, nil)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &model.IdempotentResponse{Status: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)}, response)
+			},
+		},
+		{
+			name: "Different Request",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+
+				repository.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(db.IdempotencyKey{RequestHash: []byte("other"), Status: model.IdempotencyStatusCompleted}, nil)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+				require.Nil(t, response)
+			},
+		},
+		{
+			name: "In Progress",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+
+				repository.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(db.IdempotencyKey{RequestHash: hash, Status: model.IdempotencyStatusInProgress}, nil)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+			},
+		},
+		{
+			name: "Released Meanwhile",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+
+				repository.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+			},
+		},
+		{
+			name: "Internal Error",
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, response *model.IdempotentResponse, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			// given
+			service, repository := newTestIdempotencyService(t, now)
+			tC.buildStubs(repository)
+
+			// when
+			response, err := service.Begin(context.Background(), key.Scope, key.Key, hash)
+
+			// then
+			tC.check(t, response, err)
+		})
+	}
+}
+
+func TestBeginSweepsExpiredKeys(t *testing.T) {
+	now := time.Now()
+	service, repository := newTestIdempotencyService(t, now)
+	service.lastSweep = now.Add(-idempotencySweepInterval)
+
+	repository.EXPECT().
+		DeleteExpiredIdempotencyKeys(gomock.Any()).
+		Times(1).
+		Return(sql.ErrConnDone)
+
+	repository.EXPECT().
+		CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.IdempotencyKey{}, nil)
+
+	_, err := service.Begin(context.Background(), "user:order-service", "retry-1", nil)
+	require.NoError(t, err)
+
+	_, err = service.Begin(context.Background(), "user:order-service", "retry-2", nil)
+	require.NoError(t, err)
+}
+
+func TestCompleteIdempotentRequest(t *testing.T) {
+	service, repository := newTestIdempotencyService(t, time.Now())
+
+	arg := db.CompleteIdempotencyKeyParams{
+		Scope:               "user:order-service",
+		Key:                 "retry-1",
+		ResponseStatus:      201,
+		ResponseContentType: "application/json",
+		ResponseBody:        []byte(`{"id":1}`),
+	}
+	repository.EXPECT().
+		CompleteIdempotencyKey(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(nil)
+
+	err := service.Complete(context.Background(), arg.Scope, arg.Key, model.IdempotentResponse{
+		Status:      201,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":1}`),
+	})
+	require.NoError(t, err)
+}
+
+func TestReleaseIdempotentRequest(t *testing.T) {
+	service, repository := newTestIdempotencyService(t, time.Now())
+
+	repository.EXPECT().
+		DeleteIdempotencyKey(gomock.Any(), gomock.Eq(db.DeleteIdempotencyKeyParams{Scope: "user:order-service", Key: "retry-1"})).
+		Times(1).
+		Return(nil)
+
+	err := service.Release(context.Background(), "user:order-service", "retry-1")
+	require.NoError(t, err)
+}
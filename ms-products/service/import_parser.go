@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/djudju12/ms-products/model"
+)
+
+var ErrInvalidImport = NewError(KindValidation, CodeInvalidImport, "invalid import file")
+
+type importRow struct {
+	line    int32
+	raw     string
+	request model.CreateProductRequest
+	err     string
+}
+
+func parseImport(format string, payload []byte) ([]importRow, error) {
+	switch format {
+	case model.ImportFormatCSV:
+		return parseCSV(payload)
+	case model.ImportFormatJSONL:
+		return parseJSONL(payload)
+	}
+
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+}
+
+func parseCSV(payload []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(payload))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read csv header: %v", ErrInvalidImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range []string{"name", "price", "description"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("%w: missing csv column %q", ErrInvalidImport, column)
+		}
+	}
+
+	lines := strings.Split(string(payload), "\n")
+	rows := make([]importRow, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var line int
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			line = parseErr.StartLine
+		case err != nil:
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		default:
+			line, _ = reader.FieldPos(0)
+		}
+
+		row := importRow{line: int32(line)}
+		if line > 0 && line <= len(lines) {
+			row.raw = strings.TrimRight(lines[line-1], "\r")
+		}
+
+		switch {
+		case parseErr != nil:
+			row.err = parseErr.Err.Error()
+		case len(record) != len(header):
+			row.err = fmt.Sprintf("expected %d fields, got %d", len(header), len(record))
+		default:
+			row.request = model.CreateProductRequest{
+				Name:        record[columns["name"]],
+				Price:       record[columns["price"]],
+				Description: record[columns["description"]],
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseJSONL(payload []byte) ([]importRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(payload))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(payload)+1)
+
+	rows := make([]importRow, 0)
+	var line int32
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		row := importRow{line: line, raw: raw}
+		if err := json.Unmarshal([]byte(raw), &row.request); err != nil {
+			row.err = err.Error()
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/djudju12/ms-products/configs"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/google/uuid"
+)
+
+// importLease is how long a claimed job stays with its worker without
+// progress. A job whose worker stopped is claimed by another once it expires.
+const importLease = time.Minute
+
+// errLeaseLost means another worker took the job over, so this one must stop
+// without touching the job.
+var errLeaseLost = errors.New("import job lease lost")
+
+type ImportService interface {
+	CreateImport(ctx context.Context, format string, payload []byte) (*model.ImportJob, error)
+	GetImport(ctx context.Context, jobID int32) (*model.ImportJob, error)
+	ListImportErrors(ctx context.Context, jobID int32) ([]*model.ImportError, error)
+	Run(ctx context.Context)
+}
+
+type importService struct {
+	config     configs.Config
+	repository db.Store
+	wake       chan struct{}
+	// worker names this process in the jobs it claims
+	worker string
+}
+
+var _ ImportService = (*importService)(nil)
+
+func NewImportService(config configs.Config, repository db.Store) ImportService {
+	return &importService{
+		config:     config,
+		repository: repository,
+		wake:       make(chan struct{}, 1),
+		worker:     uuid.NewString(),
+	}
+}
+
+func (is *importService) CreateImport(ctx context.Context, format string, payload []byte) (*model.ImportJob, error) {
+	rows, err := parseImport(format, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := is.repository.CreateImportJob(ctx, db.CreateImportJobParams{
+		Format:    format,
+		TotalRows: int32(len(rows)),
+		Payload:   payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	is.notify()
+	return model.ImportJobDbToModel(job), nil
+}
+
+func (is *importService) GetImport(ctx context.Context, jobID int32) (*model.ImportJob, error) {
+	job, err := is.repository.GetImportJob(ctx, jobID)
+	if err != nil {
+		return nil, repositoryError(err, "import", CodeImportNotFound)
+	}
+
+	return model.ImportJobDbToModel(job), nil
+}
+
+func (is *importService) ListImportErrors(ctx context.Context, jobID int32) ([]*model.ImportError, error) {
+	if _, err := is.repository.GetImportJob(ctx, jobID); err != nil {
+		return nil, repositoryError(err, "import", CodeImportNotFound)
+	}
+
+	errors, err := is.repository.ListImportJobErrors(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ListImportErrorsDbToModel(errors), nil
+}
+
+// Run processes import jobs until ctx is done. Every job is claimed by a
+// single replica at a time, and a job left running by a stopped process is
+// claimed again once its lease expires and resumed from its last chunk.
+func (is *importService) Run(ctx context.Context) {
+	ticker := time.NewTicker(importLease)
+	defer ticker.Stop()
+
+	is.notify()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-is.wake:
+			is.processUnfinished(ctx)
+		case <-ticker.C:
+			is.processUnfinished(ctx)
+		}
+	}
+}
+
+func (is *importService) notify() {
+	select {
+	case is.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (is *importService) processUnfinished(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := is.repository.ClaimImportJob(ctx, db.ClaimImportJobParams{
+			ClaimedBy:    is.worker,
+			LeaseSeconds: int32(importLease / time.Second),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "cannot claim import job", "error", err)
+			}
+			return
+		}
+
+		err = is.processJob(ctx, job)
+		switch {
+		case err == nil || ctx.Err() != nil:
+		case errors.Is(err, errLeaseLost):
+			slog.WarnContext(ctx, "import job taken over by another worker", "job_id", job.ID)
+		default:
+			slog.ErrorContext(ctx, "import job failed", "job_id", job.ID, "error", err)
+			is.setStatus(ctx, job.ID, model.ImportStatusFailed, importFailure(err))
+		}
+	}
+}
+
+// importFailure is the error a failed job shows its clients. Only the message
+// of a service Error is meant for them, other causes are just logged.
+func importFailure(err error) string {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Message
+	}
+
+	return "import failed on an internal error"
+}
+
+// processJob imports a job claimed by this worker.
+func (is *importService) processJob(ctx context.Context, job db.ImportJob) error {
+	jobID := job.ID
+	rows, err := parseImport(job.Format, job.Payload)
+	if err != nil {
+		return err
+	}
+
+	// rebuild the names seen before the resume point so duplicates in the file
+	// are rejected the same way they would have been in a single run
+	seen := make(map[string]bool)
+	for _, row := range rows[:min(int(job.ProcessedRows), len(rows))] {
+		if rejectImportRow(row, seen) == "" {
+			seen[row.request.Name] = true
+		}
+	}
+
+	chunkSize := max(is.config.BulkChunkSize, 1)
+	for start := int(job.ProcessedRows); start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+		err := is.repository.ExecTx(ctx, func(q db.Querier) error {
+			var err error
+			job, err = is.importChunk(ctx, q, job, chunk, seen)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return is.setStatus(ctx, jobID, model.ImportStatusCompleted, "")
+}
+
+func (is *importService) importChunk(ctx context.Context, q db.Querier, job db.ImportJob, chunk []importRow, seen map[string]bool) (db.ImportJob, error) {
+	arg := db.UpdateImportJobProgressParams{
+		ID:            job.ID,
+		ClaimedBy:     is.worker,
+		ProcessedRows: job.ProcessedRows + int32(len(chunk)),
+		CreatedRows:   job.CreatedRows,
+		UpdatedRows:   job.UpdatedRows,
+		RejectedRows:  job.RejectedRows,
+		LeaseSeconds:  int32(importLease / time.Second),
+	}
+
+	// the rejected and the valid rows of the chunk are each written together,
+	// with COPY on Postgres, and the valid ones upserted by a single statement
+	var rejected []db.CreateImportJobErrorsParams
+	var staged []db.CreateImportStagingParams
+	for _, row := range chunk {
+		if message := rejectImportRow(row, seen); message != "" {
+			rejected = append(rejected, db.CreateImportJobErrorsParams{
+				JobID:   job.ID,
+				Line:    row.line,
+				Raw:     row.raw,
+				Message: message,
+			})
+
+			arg.RejectedRows++
+			continue
+		}
+
+		seen[row.request.Name] = true
+		staged = append(staged, db.CreateImportStagingParams{
+			JobID:       job.ID,
+			Line:        row.line,
+			Name:        row.request.Name,
+			Price:       row.request.Price,
+			Description: row.request.Description,
+		})
+	}
+
+	if len(rejected) > 0 {
+		if _, err := q.CreateImportJobErrors(ctx, rejected); err != nil {
+			return job, err
+		}
+	}
+
+	if len(staged) > 0 {
+		if _, err := q.CreateImportStaging(ctx, staged); err != nil {
+			return job, err
+		}
+
+		counts, err := q.UpsertStagedProducts(ctx, job.ID)
+		if err != nil {
+			return job, err
+		}
+
+		arg.CreatedRows += int32(counts.CreatedRows)
+		arg.UpdatedRows += int32(counts.UpdatedRows)
+	}
+
+	// the progress also renews the lease, and rolls the chunk back when the
+	// job is no longer ours
+	progress, err := q.UpdateImportJobProgress(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, errLeaseLost
+	}
+
+	return progress, err
+}
+
+// rejectImportRow returns why a row cannot be imported, or an empty string.
+func rejectImportRow(row importRow, seen map[string]bool) string {
+	if row.err != "" {
+		return row.err
+	}
+
+	if fieldErrors := model.Validate(row.request); fieldErrors != nil {
+		messages := make([]string, 0, len(fieldErrors))
+		for field, rule := range fieldErrors {
+			messages = append(messages, fmt.Sprintf("%s: failed on %s", field, rule))
+		}
+
+		sort.Strings(messages)
+		return strings.Join(messages, "; ")
+	}
+
+	if seen[row.request.Name] {
+		return fmt.Sprintf("name: duplicate of an earlier row %q", row.request.Name)
+	}
+
+	return ""
+}
+
+func (is *importService) setStatus(ctx context.Context, jobID int32, status string, message string) error {
+	_, err := is.repository.UpdateImportJobStatus(ctx, db.UpdateImportJobStatusParams{
+		ID:        jobID,
+		ClaimedBy: is.worker,
+		Status:    status,
+		Error:     message,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return errLeaseLost
+	}
+
+	return err
+}
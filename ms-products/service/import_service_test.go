@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const importCSV = `name,price,description
+foo bar,10.00,first product
+baz qux,not a price,second product
+foo bar,11.00,duplicated name
+"broken,12.00,unterminated
+`
+
+func TestParseImport(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  string
+		payload string
+		check   func(t *testing.T, rows []importRow, err error)
+	}{
+		{
+			name:    "CSV",
+			format:  model.ImportFormatCSV,
+			payload: "description,price,name\nfirst product,10.00,foo bar\nonly one field\n",
+			check: func(t *testing.T, rows []importRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, rows, 2)
+				require.Equal(t, int32(2), rows[0].line)
+				require.Equal(t, model.CreateProductRequest{
+					Name:        "foo bar",
+					Price:       "10.00",
+					Description: "first product",
+				}, rows[0].request)
+				require.Empty(t, rows[0].err)
+				require.Equal(t, "only one field", rows[1].raw)
+				require.NotEmpty(t, rows[1].err)
+			},
+		},
+		{
+			name:    "CSV missing column",
+			format:  model.ImportFormatCSV,
+			payload: "name,price\nfoo,10.00\n",
+			check: func(t *testing.T, rows []importRow, err error) {
+				require.ErrorIs(t, err, ErrInvalidImport)
+			},
+		},
+		{
+			name:    "JSONL",
+			format:  model.ImportFormatJSONL,
+			payload: "{\"name\":\"foo bar\",\"price\":\"10.00\",\"description\":\"first\"}\n\n{not json}\n",
+			check: func(t *testing.T, rows []importRow, err error) {
+				require.NoError(t, err)
+				require.Len(t, rows, 2)
+				require.Equal(t, "foo bar", rows[0].request.Name)
+				require.Equal(t, int32(3), rows[1].line)
+				require.NotEmpty(t, rows[1].err)
+			},
+		},
+		{
+			name:    "Unknown format",
+			format:  "xml",
+			payload: "<products/>",
+			check: func(t *testing.T, rows []importRow, err error) {
+				require.ErrorIs(t, err, ErrInvalidImport)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			rows, err := parseImport(tC.format, []byte(tC.payload))
+
+			tC.check(t, rows, err)
+		})
+	}
+}
+
+func TestProcessImportJob(t *testing.T) {
+	job := db.ImportJob{
+		ID:        1,
+		Format:    model.ImportFormatCSV,
+		Status:    model.ImportStatusRunning,
+		Payload:   []byte(importCSV),
+		TotalRows: 4,
+		ClaimedBy: "worker",
+	}
+
+	execTx := func(repository *mockdb.MockStore) func(ctx context.Context, fn func(db.Querier) error) error {
+		return func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(repository)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		job        db.ImportJob
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name: "Happy case",
+			job:  job,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(execTx(repository))
+
+				repository.EXPECT().
+					CreateImportStaging(gomock.Any(), gomock.Eq([]db.CreateImportStagingParams{{
+						JobID:       job.ID,
+						Line:        2,
+						Name:        "foo bar",
+						Price:       "10.00",
+						Description: "first product",
+					}})).
+					Times(1).
+					Return(int64(1), nil)
+
+				repository.EXPECT().
+					UpsertStagedProducts(gomock.Any(), gomock.Eq(job.ID)).
+					Times(1).
+					Return(db.UpsertStagedProductsRow{CreatedRows: 1}, nil)
+
+				repository.EXPECT().
+					CreateImportJobErrors(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(ctx context.Context, arg []db.CreateImportJobErrorsParams) (int64, error) {
+						for _, row := range arg {
+							require.Equal(t, job.ID, row.JobID)
+							require.Contains(t, []int32{3, 4, 5}, row.Line)
+							require.NotEmpty(t, row.Message)
+						}
+						return int64(len(arg)), nil
+					})
+
+				gomock.InOrder(
+					repository.EXPECT().
+						UpdateImportJobProgress(gomock.Any(), gomock.Eq(db.UpdateImportJobProgressParams{
+							ID:            job.ID,
+							ClaimedBy:     "worker",
+							ProcessedRows: 2,
+							CreatedRows:   1,
+							RejectedRows:  1,
+							LeaseSeconds:  60,
+						})).
+						Return(db.ImportJob{ID: job.ID, ProcessedRows: 2, CreatedRows: 1, RejectedRows: 1}, nil),
+					repository.EXPECT().
+						UpdateImportJobProgress(gomock.Any(), gomock.Eq(db.UpdateImportJobProgressParams{
+							ID:            job.ID,
+							ClaimedBy:     "worker",
+							ProcessedRows: 4,
+							CreatedRows:   1,
+							RejectedRows:  3,
+							LeaseSeconds:  60,
+						})).
+						Return(db.ImportJob{ID: job.ID, ProcessedRows: 4, CreatedRows: 1, RejectedRows: 3}, nil),
+				)
+
+				repository.EXPECT().
+					UpdateImportJobStatus(gomock.Any(), gomock.Eq(db.UpdateImportJobStatusParams{ID: job.ID, ClaimedBy: "worker", Status: model.ImportStatusCompleted})).
+					Times(1)
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Resumes after the last processed chunk",
+			job: db.ImportJob{
+				ID:            job.ID,
+				Format:        job.Format,
+				Status:        model.ImportStatusRunning,
+				Payload:       job.Payload,
+				TotalRows:     job.TotalRows,
+				ClaimedBy:     job.ClaimedBy,
+				ProcessedRows: 2,
+				CreatedRows:   1,
+				RejectedRows:  1,
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(execTx(repository))
+
+				// "foo bar" was imported before the restart, so the duplicate is still rejected
+				repository.EXPECT().
+					CreateImportStaging(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				repository.EXPECT().
+					CreateImportJobErrors(gomock.Any(), gomock.Len(2)).
+					Times(1)
+
+				repository.EXPECT().
+					UpdateImportJobProgress(gomock.Any(), gomock.Eq(db.UpdateImportJobProgressParams{
+						ID:            job.ID,
+						ClaimedBy:     "worker",
+						ProcessedRows: 4,
+						CreatedRows:   1,
+						RejectedRows:  3,
+						LeaseSeconds:  60,
+					})).
+					Times(1)
+
+				repository.EXPECT().
+					UpdateImportJobStatus(gomock.Any(), gomock.Eq(db.UpdateImportJobStatusParams{ID: job.ID, ClaimedBy: "worker", Status: model.ImportStatusCompleted})).
+					Times(1)
+			},
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Lease lost",
+			job:  job,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(execTx(repository))
+
+				repository.EXPECT().
+					CreateImportJobErrors(gomock.Any(), gomock.Any()).
+					Times(1)
+
+				repository.EXPECT().
+					CreateImportStaging(gomock.Any(), gomock.Len(1)).
+					Times(1)
+
+				repository.EXPECT().
+					UpsertStagedProducts(gomock.Any(), gomock.Eq(job.ID)).
+					Times(1)
+
+				// another worker claimed the job after the lease expired
+				repository.EXPECT().
+					UpdateImportJobProgress(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ImportJob{}, sql.ErrNoRows)
+
+				repository.EXPECT().
+					UpdateImportJobStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, errLeaseLost)
+			},
+		},
+		{
+			name: "Repository returns an error",
+			job:  job,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("some error"))
+			},
+			check: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repository := mockdb.NewMockStore(ctrl)
+			tC.buildStubs(repository)
+
+			service := &importService{
+				config:     configs.Config{BulkChunkSize: 2},
+				repository: repository,
+				worker:     "worker",
+			}
+
+			err := service.processJob(context.Background(), tC.job)
+
+			tC.check(t, err)
+		})
+	}
+}
+
+func TestProcessUnfinishedImportJobs(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+	service := &importService{
+		config:     configs.Config{BulkChunkSize: 2},
+		repository: repository,
+		worker:     "worker",
+	}
+
+	claim := db.ClaimImportJobParams{ClaimedBy: "worker", LeaseSeconds: 60}
+	gomock.InOrder(
+		repository.EXPECT().
+			ClaimImportJob(gomock.Any(), gomock.Eq(claim)).
+			Return(db.ImportJob{ID: 1, Format: "xml", Status: model.ImportStatusRunning, ClaimedBy: "worker"}, nil),
+		repository.EXPECT().
+			UpdateImportJobStatus(gomock.Any(), gomock.Eq(db.UpdateImportJobStatusParams{
+				ID:        1,
+				ClaimedBy: "worker",
+				Status:    model.ImportStatusFailed,
+				Error:     "invalid import file",
+			})),
+		// the cause of an internal failure is only logged
+		repository.EXPECT().
+			ClaimImportJob(gomock.Any(), gomock.Eq(claim)).
+			Return(db.ImportJob{ID: 2, Format: model.ImportFormatCSV, Status: model.ImportStatusRunning, Payload: []byte(importCSV), ClaimedBy: "worker"}, nil),
+		repository.EXPECT().
+			ExecTx(gomock.Any(), gomock.Any()).
+			Return(errors.New("dial tcp 10.0.0.5:5432: connection refused")),
+		repository.EXPECT().
+			UpdateImportJobStatus(gomock.Any(), gomock.Eq(db.UpdateImportJobStatusParams{
+				ID:        2,
+				ClaimedBy: "worker",
+				Status:    model.ImportStatusFailed,
+				Error:     "import failed on an internal error",
+			})),
+		// no job left to claim
+		repository.EXPECT().
+			ClaimImportJob(gomock.Any(), gomock.Eq(claim)).
+			Return(db.ImportJob{}, sql.ErrNoRows),
+	)
+
+	// when
+	service.processUnfinished(context.Background())
+
+	// then
+	require.True(t, ctrl.Satisfied())
+}
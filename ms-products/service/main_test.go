@@ -3,21 +3,27 @@ package service
 import (
 	"testing"
 
+	"github.com/djudju12/ms-products/configs"
 	mockdb "github.com/djudju12/ms-products/db/mock"
 	"go.uber.org/mock/gomock"
 )
 
 type TestProductService struct {
 	ctrl       *gomock.Controller
-	repository *mockdb.MockQuerier
+	repository *mockdb.MockStore
 	service    ProductService
 }
 
 func NewTest(t *testing.T) *TestProductService {
 	ctrl := gomock.NewController(t)
 	ctrl.Finish()
-	repository := mockdb.NewMockQuerier(ctrl)
-	sevice := NewProductService(repository)
+	repository := mockdb.NewMockStore(ctrl)
+	config := configs.Config{
+		BulkChunkSize:   2,
+		ExportFetchSize: 100,
+	}
+
+	sevice := NewProductService(config, repository)
 
 	return &TestProductService{
 		ctrl:       ctrl,
@@ -2,27 +2,38 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 
+	"github.com/djudju12/ms-products/configs"
 	db "github.com/djudju12/ms-products/db/sqlc"
 	"github.com/djudju12/ms-products/model"
 )
 
 type ProductService interface {
 	GetProduct(ctx context.Context, productID int32) (*model.Product, error)
+	GetProductsByIDs(ctx context.Context, req model.BatchGetProductsRequest) (*model.BatchGetProductsResponse, error)
 	CreateProduct(ctx context.Context, req model.CreateProductRequest) (*model.Product, error)
 	ListProducts(ctx context.Context, req model.ListProductsRquest) ([]*model.Product, error)
 	UpdateProductStatus(ctx context.Context, req model.UpdateProductStatusRequest) (*model.Product, error)
 	InactiveProduct(ctx context.Context, productID int32) error
+	BulkProducts(ctx context.Context, req model.BulkProductsRequest) (*model.BulkProductsResponse, error)
+	ExportProducts(ctx context.Context, req model.ExportProductsRequest, w io.Writer) error
 }
 
 type productService struct {
-	repository db.Querier
+	config     configs.Config
+	repository db.Store
 }
 
 var _ ProductService = (*productService)(nil)
 
-func NewProductService(repository db.Querier) ProductService {
+func NewProductService(config configs.Config, repository db.Store) ProductService {
 	return &productService{
+		config:     config,
 		repository: repository,
 	}
 }
@@ -30,20 +41,50 @@ func NewProductService(repository db.Querier) ProductService {
 func (ps *productService) GetProduct(ctx context.Context, productID int32) (*model.Product, error) {
 	product, err := ps.repository.GetProduct(ctx, productID)
 	if err != nil {
-		return nil, err
+		return nil, repositoryError(err, "product", CodeProductNotFound)
 	}
 
 	return model.ProductDbToModel(product), nil
 }
 
+func (ps *productService) GetProductsByIDs(ctx context.Context, req model.BatchGetProductsRequest) (*model.BatchGetProductsResponse, error) {
+	products, err := ps.repository.GetProductsByIDs(ctx, req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[int32]db.Product, len(products))
+	for _, product := range products {
+		found[product.ID] = product
+	}
+
+	result := &model.BatchGetProductsResponse{
+		Products: make([]*model.Product, 0, len(products)),
+		Missing:  make([]int32, 0),
+	}
+
+	for _, id := range req.IDs {
+		product, ok := found[id]
+		if !ok {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+
+		result.Products = append(result.Products, model.ProductDbToModel(product))
+	}
+
+	return result, nil
+}
+
 func (ps *productService) CreateProduct(ctx context.Context, req model.CreateProductRequest) (*model.Product, error) {
 	arg := req.ToDB()
 
 	product, err := ps.repository.CreateProduct(ctx, arg)
 	if err != nil {
-		return nil, err
+		return nil, repositoryError(err, "product")
 	}
 
+	slog.InfoContext(ctx, "product created", "product_id", product.ID)
 	return model.ProductDbToModel(product), nil
 }
 
@@ -59,13 +100,24 @@ func (ps *productService) ListProducts(ctx context.Context, req model.ListProduc
 }
 
 func (ps *productService) UpdateProductStatus(ctx context.Context, req model.UpdateProductStatusRequest) (*model.Product, error) {
+	// callers that skip the request binding, like productsctl --direct, must not
+	// deactivate products this way either
+	if !model.IsValidStatus(req.Status) {
+		return nil, Validation("request has invalid fields", model.FieldProblem{
+			Field:   "status",
+			Rule:    "status",
+			Message: fmt.Sprintf("must be one of %s, %s", model.ProductStatusAvailable, model.ProductStatusOutOfStock),
+		})
+	}
+
 	arg := req.ToDB()
 
 	product, err := ps.repository.UpdateProductStatus(ctx, arg)
 	if err != nil {
-		return nil, err
+		return nil, repositoryError(err, "product", CodeProductNotFound)
 	}
 
+	slog.InfoContext(ctx, "product status updated", "product_id", product.ID, "status", product.Status)
 	return model.ProductDbToModel(product), nil
 }
 
@@ -76,9 +128,226 @@ func (ps *productService) InactiveProduct(ctx context.Context, productID int32)
 	}
 
 	_, err := ps.repository.UpdateProductStatus(ctx, arg)
+	if err != nil {
+		return repositoryError(err, "product", CodeProductNotFound)
+	}
+
+	slog.InfoContext(ctx, "product deactivated", "product_id", productID)
+	return nil
+}
+
+func (ps *productService) ExportProducts(ctx context.Context, req model.ExportProductsRequest, w io.Writer) error {
+	exporter, err := newProductExporter(req.Format, w)
+	if err != nil {
+		return err
+	}
+
+	arg := req.ToDB()
+	arg.FetchSize = ps.config.ExportFetchSize
+
+	err = ps.repository.ExportProducts(ctx, arg, func(product db.Product) error {
+		return exporter.Write(model.ProductDbToModel(product))
+	})
+	if err != nil {
+		exporter.Discard()
+		return err
+	}
+
+	return exporter.Close()
+}
+
+var errBulkRollback = errors.New("bulk operation failed")
+
+func (ps *productService) BulkProducts(ctx context.Context, req model.BulkProductsRequest) (*model.BulkProductsResponse, error) {
+	results := make([]model.BulkProductResult, len(req.Operations))
+	pending := make([]int, 0, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = model.BulkProductResult{Index: i}
+		if fieldErrors := validateOperation(op); fieldErrors != nil {
+			results[i].Status = model.BulkStatusInvalid
+			results[i].Errors = fieldErrors
+			continue
+		}
+
+		pending = append(pending, i)
+	}
+
+	var err error
+	if req.Atomic {
+		err = ps.bulkAtomic(ctx, req.Operations, pending, results)
+	} else {
+		err = ps.bulkChunked(ctx, req.Operations, pending, results)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]int)
+	for _, result := range results {
+		statuses[result.Status]++
+	}
+	slog.InfoContext(ctx, "bulk products applied", "operations", len(req.Operations), "atomic", req.Atomic, "statuses", statuses)
+
+	return &model.BulkProductsResponse{Results: results}, nil
+}
+
+// bulkAtomic runs every operation in a single transaction, aborting all of
+// them as soon as one fails.
+func (ps *productService) bulkAtomic(ctx context.Context, ops []model.BulkProductOperation, pending []int, results []model.BulkProductResult) error {
+	if len(pending) < len(ops) {
+		abortOperations(pending, results)
+		return nil
+	}
+
+	staged := make(map[int]model.BulkProductResult, len(pending))
+	err := ps.repository.ExecTx(ctx, func(q db.Querier) error {
+		for _, i := range pending {
+			result, err := applyOperation(ctx, q, i, ops[i])
+			if err != nil {
+				return err
+			}
+
+			if result.Failed() {
+				results[i] = result
+				return errBulkRollback
+			}
+
+			staged[i] = result
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBulkRollback) {
+		abortOperations(pending, results)
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
 
+	for i, result := range staged {
+		results[i] = result
+	}
+
+	return nil
+}
+
+// bulkChunked runs the operations in transactions of BulkChunkSize. A failed
+// operation rolls its chunk back, which is then retried without it.
+func (ps *productService) bulkChunked(ctx context.Context, ops []model.BulkProductOperation, pending []int, results []model.BulkProductResult) error {
+	chunkSize := max(ps.config.BulkChunkSize, 1)
+	for start := 0; start < len(pending); start += chunkSize {
+		chunk := pending[start:min(start+chunkSize, len(pending))]
+		if err := ps.bulkChunk(ctx, ops, chunk, results); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (ps *productService) bulkChunk(ctx context.Context, ops []model.BulkProductOperation, chunk []int, results []model.BulkProductResult) error {
+	for len(chunk) > 0 {
+		failed := -1
+		staged := make(map[int]model.BulkProductResult, len(chunk))
+		err := ps.repository.ExecTx(ctx, func(q db.Querier) error {
+			for _, i := range chunk {
+				result, err := applyOperation(ctx, q, i, ops[i])
+				if err != nil {
+					return err
+				}
+
+				if result.Failed() {
+					failed = i
+					results[i] = result
+					return errBulkRollback
+				}
+
+				staged[i] = result
+			}
+
+			return nil
+		})
+
+		if err == nil {
+			for i, result := range staged {
+				results[i] = result
+			}
+
+			return nil
+		}
+
+		if !errors.Is(err, errBulkRollback) {
+			return err
+		}
+
+		remaining := make([]int, 0, len(chunk)-1)
+		for _, i := range chunk {
+			if i != failed {
+				remaining = append(remaining, i)
+			}
+		}
+
+		chunk = remaining
+	}
+
+	return nil
+}
+
+func validateOperation(op model.BulkProductOperation) []model.FieldProblem {
+	switch op.Operation {
+	case model.BulkOperationCreate:
+		return model.ValidateProblems(op.ToCreateRequest())
+	case model.BulkOperationUpdate:
+		return model.ValidateProblems(op.ToUpdateRequest())
+	}
+
+	return []model.FieldProblem{{
+		Field:   "operation",
+		Rule:    "oneof",
+		Message: fmt.Sprintf("must be one of %s, %s", model.BulkOperationCreate, model.BulkOperationUpdate),
+	}}
+}
+
+func applyOperation(ctx context.Context, q db.Querier, index int, op model.BulkProductOperation) (model.BulkProductResult, error) {
+	result := model.BulkProductResult{Index: index}
+
+	var product db.Product
+	var err error
+	if op.Operation == model.BulkOperationCreate {
+		req := op.ToCreateRequest()
+		product, err = q.CreateProduct(ctx, req.ToDB())
+		result.Status = model.BulkStatusCreated
+	} else {
+		req := op.ToUpdateRequest()
+		product, err = q.UpdateProduct(ctx, req.ToDB())
+		result.Status = model.BulkStatusUpdated
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			result.Status = model.BulkStatusNotFound
+		case db.ErrorCode(err) == db.CodeUniqueViolation:
+			result.Status = model.BulkStatusConflict
+		default:
+			return result, err
+		}
+
+		return result, nil
+	}
+
+	result.Product = model.ProductDbToModel(product)
+	return result, nil
+}
+
+func abortOperations(pending []int, results []model.BulkProductResult) {
+	for _, i := range pending {
+		if results[i].Status == "" {
+			results[i].Status = model.BulkStatusAborted
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/djudju12/ms-products/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const productServiceName = "product"
+
+var tracer = otel.Tracer("github.com/djudju12/ms-products/service")
+
+// instrumentedProductService traces, counts and times every call to a
+// ProductService.
+type instrumentedProductService struct {
+	next    ProductService
+	metrics *metrics.Metrics
+}
+
+var _ ProductService = (*instrumentedProductService)(nil)
+
+func NewInstrumentedProductService(next ProductService, m *metrics.Metrics) ProductService {
+	return &instrumentedProductService{
+		next:    next,
+		metrics: m,
+	}
+}
+
+// start opens the span of a call to method. The returned function records
+// the call with the error it returned.
+func (s *instrumentedProductService) start(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "ProductService."+method)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		s.metrics.ObserveCall(productServiceName, method, time.Since(start), err)
+	}
+}
+
+func (s *instrumentedProductService) GetProduct(ctx context.Context, productID int32) (result *model.Product, err error) {
+	ctx, end := s.start(ctx, "GetProduct")
+	defer func() { end(err) }()
+	return s.next.GetProduct(ctx, productID)
+}
+
+func (s *instrumentedProductService) GetProductsByIDs(ctx context.Context, req model.BatchGetProductsRequest) (result *model.BatchGetProductsResponse, err error) {
+	ctx, end := s.start(ctx, "GetProductsByIDs")
+	defer func() { end(err) }()
+	return s.next.GetProductsByIDs(ctx, req)
+}
+
+func (s *instrumentedProductService) CreateProduct(ctx context.Context, req model.CreateProductRequest) (result *model.Product, err error) {
+	ctx, end := s.start(ctx, "CreateProduct")
+	defer func() { end(err) }()
+	return s.next.CreateProduct(ctx, req)
+}
+
+func (s *instrumentedProductService) ListProducts(ctx context.Context, req model.ListProductsRquest) (result []*model.Product, err error) {
+	ctx, end := s.start(ctx, "ListProducts")
+	defer func() { end(err) }()
+	return s.next.ListProducts(ctx, req)
+}
+
+func (s *instrumentedProductService) UpdateProductStatus(ctx context.Context, req model.UpdateProductStatusRequest) (result *model.Product, err error) {
+	ctx, end := s.start(ctx, "UpdateProductStatus")
+	defer func() { end(err) }()
+	return s.next.UpdateProductStatus(ctx, req)
+}
+
+func (s *instrumentedProductService) InactiveProduct(ctx context.Context, productID int32) (err error) {
+	ctx, end := s.start(ctx, "InactiveProduct")
+	defer func() { end(err) }()
+	return s.next.InactiveProduct(ctx, productID)
+}
+
+func (s *instrumentedProductService) BulkProducts(ctx context.Context, req model.BulkProductsRequest) (result *model.BulkProductsResponse, err error) {
+	ctx, end := s.start(ctx, "BulkProducts")
+	defer func() { end(err) }()
+	return s.next.BulkProducts(ctx, req)
+}
+
+func (s *instrumentedProductService) ExportProducts(ctx context.Context, req model.ExportProductsRequest, w io.Writer) (err error) {
+	ctx, end := s.start(ctx, "ExportProducts")
+	defer func() { end(err) }()
+	return s.next.ExportProducts(ctx, req, w)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/djudju12/ms-products/configs"
+	mockdb "github.com/djudju12/ms-products/db/mock"
+	db "github.com/djudju12/ms-products/db/sqlc"
+	"github.com/djudju12/ms-products/metrics"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInstrumentedProductService(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	ctrl := gomock.NewController(t)
+	repository := mockdb.NewMockStore(ctrl)
+	m := metrics.New()
+	service := NewInstrumentedProductService(NewProductService(configs.Config{}, repository), m)
+
+	gomock.InOrder(
+		repository.EXPECT().GetProduct(gomock.Any(), gomock.Eq(int32(1))).Return(db.Product{ID: 1}, nil),
+		repository.EXPECT().GetProduct(gomock.Any(), gomock.Eq(int32(2))).Return(db.Product{}, sql.ErrNoRows),
+	)
+
+	product, err := service.GetProduct(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), product.ID)
+
+	_, err = service.GetProduct(context.Background(), 2)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, recorder.Body.String(), `ms_products_service_calls_total{method="GetProduct",outcome="ok",service="product"} 1`)
+	require.Contains(t, recorder.Body.String(), `ms_products_service_calls_total{method="GetProduct",outcome="error",service="product"} 1`)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	require.Equal(t, "ProductService.GetProduct", ended[0].Name())
+	require.Equal(t, codes.Unset, ended[0].Status().Code)
+	require.Equal(t, codes.Error, ended[1].Status().Code)
+}
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/djudju12/ms-products/model"
 	"github.com/djudju12/ms-products/utils"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 	"go.uber.org/mock/gomock"
 )
 
@@ -20,14 +25,14 @@ func TestGetProduct(t *testing.T) {
 		name        string
 		description string
 		productID   int32
-		buildStubs  func(repository *mockdb.MockQuerier)
+		buildStubs  func(repository *mockdb.MockStore)
 		check       func(t *testing.T, product *model.Product, err error)
 	}{
 		{
 			name:        "Happy case",
 			productID:   product.ID,
 			description: "call GetProduct with a valid productID",
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
 					Times(1).
@@ -43,7 +48,7 @@ func TestGetProduct(t *testing.T) {
 			name:        "Repository returns an error",
 			productID:   product.ID,
 			description: "call GetProduct and repository returns an error",
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					GetProduct(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -67,6 +72,67 @@ func TestGetProduct(t *testing.T) {
 		})
 	}
 }
+func TestGetProductsByIDs(t *testing.T) {
+	product1 := RandomProduct()
+	product2 := RandomProduct()
+	product2.ID = product1.ID + 1
+	missingID := product2.ID + 1
+
+	req := model.BatchGetProductsRequest{
+		IDs: []int32{product2.ID, missingID, product1.ID},
+	}
+
+	testCases := []struct {
+		name       string
+		request    model.BatchGetProductsRequest
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, response *model.BatchGetProductsResponse, err error)
+	}{
+		{
+			name:    "Happy case",
+			request: req,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Eq(req.IDs)).
+					Times(1).
+					Return([]db.Product{product1, product2}, nil)
+			},
+			check: func(t *testing.T, response *model.BatchGetProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, response.Products, 2)
+				require.Equal(t, model.ProductDbToModel(product2), response.Products[0])
+				require.Equal(t, model.ProductDbToModel(product1), response.Products[1])
+				require.Equal(t, []int32{missingID}, response.Missing)
+			},
+		},
+		{
+			name:    "Repository returns an error",
+			request: req,
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					GetProductsByIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Product{}, errors.New("some error"))
+			},
+			check: func(t *testing.T, response *model.BatchGetProductsResponse, err error) {
+				require.Error(t, err)
+				require.Empty(t, response)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			test := NewTest(t)
+			tC.buildStubs(test.repository)
+
+			response, err := test.service.GetProductsByIDs(context.Background(), tC.request)
+
+			tC.check(t, response, err)
+		})
+	}
+}
+
 func TestCreateProduct(t *testing.T) {
 	product := RandomProduct()
 
@@ -79,13 +145,13 @@ func TestCreateProduct(t *testing.T) {
 	testCases := []struct {
 		name       string
 		request    model.CreateProductRequest
-		buildStubs func(repository *mockdb.MockQuerier)
+		buildStubs func(repository *mockdb.MockStore)
 		check      func(t *testing.T, product *model.Product, err error)
 	}{
 		{
 			name:    "Happy case",
 			request: req,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				expectedArg := req.ToDB()
 
 				repository.EXPECT().
@@ -102,7 +168,7 @@ func TestCreateProduct(t *testing.T) {
 		{
 			name:    "Repository returns an error",
 			request: model.CreateProductRequest{},
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					CreateProduct(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -142,13 +208,13 @@ func TestListProduct(t *testing.T) {
 	testCases := []struct {
 		name       string
 		request    model.ListProductsRquest
-		buildStubs func(repository *mockdb.MockQuerier)
+		buildStubs func(repository *mockdb.MockStore)
 		check      func(t *testing.T, productsModel []*model.Product, err error)
 	}{
 		{
 			name:    "Happy case",
 			request: req,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				expectedArg := req.ToDB()
 
 				repository.EXPECT().
@@ -167,7 +233,7 @@ func TestListProduct(t *testing.T) {
 		{
 			name:    "Repository returns an error",
 			request: model.ListProductsRquest{},
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					ListProducts(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -202,13 +268,13 @@ func TestUpdateProduct(t *testing.T) {
 	testCases := []struct {
 		name       string
 		request    model.UpdateProductStatusRequest
-		buildStubs func(repository *mockdb.MockQuerier)
+		buildStubs func(repository *mockdb.MockStore)
 		check      func(t *testing.T, productModel *model.Product, err error)
 	}{
 		{
 			name:    "Happy case",
 			request: request,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				expectedArg := db.UpdateProductStatusParams{
 					ID:     request.ID,
 					Status: request.Status,
@@ -227,7 +293,7 @@ func TestUpdateProduct(t *testing.T) {
 		{
 			name:    "Repository returns an error",
 			request: request,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					UpdateProductStatus(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -237,6 +303,20 @@ func TestUpdateProduct(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		{
+			name:    "Inactive is not a status update",
+			request: model.UpdateProductStatusRequest{ID: product.ID, Status: model.ProductStatusInactive},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					UpdateProductStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, productModel *model.Product, err error) {
+				var serviceErr *Error
+				require.ErrorAs(t, err, &serviceErr)
+				require.Equal(t, KindValidation, serviceErr.Kind)
+			},
+		},
 	}
 
 	for _, tC := range testCases {
@@ -257,13 +337,13 @@ func TestInactiveProduct(t *testing.T) {
 	testCases := []struct {
 		name       string
 		productID  int32
-		buildStubs func(repository *mockdb.MockQuerier)
+		buildStubs func(repository *mockdb.MockStore)
 		check      func(t *testing.T, err error)
 	}{
 		{
 			name:      "Happy case",
 			productID: product.ID,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				expectedArg := db.UpdateProductStatusParams{
 					ID:     product.ID,
 					Status: "inactive",
@@ -281,7 +361,7 @@ func TestInactiveProduct(t *testing.T) {
 		{
 			name:      "Repository returns an error",
 			productID: product.ID,
-			buildStubs: func(repository *mockdb.MockQuerier) {
+			buildStubs: func(repository *mockdb.MockStore) {
 				repository.EXPECT().
 					UpdateProductStatus(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -305,6 +385,343 @@ func TestInactiveProduct(t *testing.T) {
 	}
 }
 
+func TestBulkProducts(t *testing.T) {
+	product := RandomProduct()
+	create := model.BulkProductOperation{
+		Operation:   model.BulkOperationCreate,
+		Name:        product.Name,
+		Price:       product.Price,
+		Description: product.Description,
+	}
+	update := model.BulkProductOperation{
+		Operation:   model.BulkOperationUpdate,
+		ID:          product.ID,
+		Name:        product.Name,
+		Price:       product.Price,
+		Description: product.Description,
+	}
+	invalid := model.BulkProductOperation{
+		Operation: model.BulkOperationCreate,
+		Name:      product.Name,
+		Price:     "not a price",
+	}
+	conflict := &db.Error{Code: db.CodeUniqueViolation}
+
+	execTx := func(repository *mockdb.MockStore) func(ctx context.Context, fn func(db.Querier) error) error {
+		return func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(repository)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		request    model.BulkProductsRequest
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, response *model.BulkProductsResponse, err error)
+	}{
+		{
+			name: "Happy case",
+			request: model.BulkProductsRequest{
+				Operations: []model.BulkProductOperation{create, update},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(execTx(repository))
+
+				repository.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(product, nil)
+
+				repository.EXPECT().
+					UpdateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(product, nil)
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, response.Results, 2)
+				require.Equal(t, model.BulkStatusCreated, response.Results[0].Status)
+				require.Equal(t, model.BulkStatusUpdated, response.Results[1].Status)
+				require.Equal(t, model.ProductDbToModel(product), response.Results[1].Product)
+			},
+		},
+		{
+			name: "Invalid and failed operations are reported per item",
+			request: model.BulkProductsRequest{
+				Operations: []model.BulkProductOperation{invalid, create, update, create},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				// the chunk [create, update] is retried without the missing product,
+				// then the last create runs in its own chunk
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(3).
+					DoAndReturn(execTx(repository))
+
+				gomock.InOrder(
+					repository.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(product, nil),
+					repository.EXPECT().UpdateProduct(gomock.Any(), gomock.Any()).Return(db.Product{}, sql.ErrNoRows),
+					repository.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(product, nil),
+					repository.EXPECT().CreateProduct(gomock.Any(), gomock.Any()).Return(db.Product{}, conflict),
+				)
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, response.Results, 4)
+				require.Equal(t, model.BulkStatusInvalid, response.Results[0].Status)
+				require.ElementsMatch(t, []model.FieldProblem{
+					{Field: "price", Rule: "price", Message: "must be a decimal with up to 10 digits and 2 decimal places"},
+					{Field: "description", Rule: "required", Message: "is required"},
+				}, response.Results[0].Errors)
+				require.Equal(t, model.BulkStatusCreated, response.Results[1].Status)
+				require.Equal(t, model.BulkStatusNotFound, response.Results[2].Status)
+				require.Equal(t, model.BulkStatusConflict, response.Results[3].Status)
+			},
+		},
+		{
+			name: "Unknown operation",
+			request: model.BulkProductsRequest{
+				Operations: []model.BulkProductOperation{{Operation: "delete", ID: product.ID}},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, response.Results, 1)
+				require.Equal(t, model.BulkStatusInvalid, response.Results[0].Status)
+				require.Equal(t, []model.FieldProblem{
+					{Field: "operation", Rule: "oneof", Message: "must be one of create, update"},
+				}, response.Results[0].Errors)
+			},
+		},
+		{
+			name: "Atomic rolls back on first failure",
+			request: model.BulkProductsRequest{
+				Atomic:     true,
+				Operations: []model.BulkProductOperation{create, update, create},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(execTx(repository))
+
+				repository.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(product, nil)
+
+				repository.EXPECT().
+					UpdateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Product{}, conflict)
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, response.Results, 3)
+				require.Equal(t, model.BulkStatusAborted, response.Results[0].Status)
+				require.Nil(t, response.Results[0].Product)
+				require.Equal(t, model.BulkStatusConflict, response.Results[1].Status)
+				require.Equal(t, model.BulkStatusAborted, response.Results[2].Status)
+			},
+		},
+		{
+			name: "Atomic with invalid operations runs nothing",
+			request: model.BulkProductsRequest{
+				Atomic:     true,
+				Operations: []model.BulkProductOperation{create, invalid},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, model.BulkStatusAborted, response.Results[0].Status)
+				require.Equal(t, model.BulkStatusInvalid, response.Results[1].Status)
+			},
+		},
+		{
+			name: "Repository returns an error",
+			request: model.BulkProductsRequest{
+				Operations: []model.BulkProductOperation{create},
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExecTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(execTx(repository))
+
+				repository.EXPECT().
+					CreateProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Product{}, errors.New("some error"))
+			},
+			check: func(t *testing.T, response *model.BulkProductsResponse, err error) {
+				require.Error(t, err)
+				require.Empty(t, response)
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			test := NewTest(t)
+			tC.buildStubs(test.repository)
+
+			response, err := test.service.BulkProducts(context.Background(), tC.request)
+
+			tC.check(t, response, err)
+		})
+	}
+}
+
+func TestExportProducts(t *testing.T) {
+	products := []db.Product{RandomProduct(), RandomProduct()}
+
+	streamProducts := func(ctx context.Context, arg db.ExportProductsParams, fn func(db.Product) error) error {
+		for _, product := range products {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	testCases := []struct {
+		name       string
+		request    model.ExportProductsRequest
+		buildStubs func(repository *mockdb.MockStore)
+		check      func(t *testing.T, output *bytes.Buffer, err error)
+	}{
+		{
+			name: "CSV",
+			request: model.ExportProductsRequest{
+				Format: model.ExportFormatCSV,
+				Status: model.ProductStatusAvailable,
+			},
+			buildStubs: func(repository *mockdb.MockStore) {
+				expectedArg := db.ExportProductsParams{
+					Status:    model.ProductStatusAvailable,
+					FetchSize: 100,
+				}
+
+				repository.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Eq(expectedArg), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamProducts)
+			},
+			check: func(t *testing.T, output *bytes.Buffer, err error) {
+				require.NoError(t, err)
+
+				lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+				require.Len(t, lines, 3)
+				require.Equal(t, "id,name,price,description,status,created_at,updated_at", lines[0])
+				require.Contains(t, lines[1], products[0].Name)
+				require.Contains(t, lines[2], products[1].Name)
+			},
+		},
+		{
+			name:    "JSONL",
+			request: model.ExportProductsRequest{Format: model.ExportFormatJSONL},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamProducts)
+			},
+			check: func(t *testing.T, output *bytes.Buffer, err error) {
+				require.NoError(t, err)
+
+				decoder := json.NewDecoder(output)
+				for _, product := range products {
+					var exported model.Product
+					require.NoError(t, decoder.Decode(&exported))
+					require.Equal(t, product.ID, exported.ID)
+					require.Equal(t, product.Name, exported.Name)
+				}
+
+				require.False(t, decoder.More())
+			},
+		},
+		{
+			name:    "XLSX",
+			request: model.ExportProductsRequest{Format: model.ExportFormatXLSX},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamProducts)
+			},
+			check: func(t *testing.T, output *bytes.Buffer, err error) {
+				require.NoError(t, err)
+
+				file, err := excelize.OpenReader(output)
+				require.NoError(t, err)
+				defer file.Close()
+
+				rows, err := file.GetRows("Sheet1")
+				require.NoError(t, err)
+				require.Len(t, rows, 3)
+				require.Equal(t, "name", rows[0][1])
+				require.Equal(t, products[1].Name, rows[2][1])
+			},
+		},
+		{
+			name:    "Repository returns an error",
+			request: model.ExportProductsRequest{Format: model.ExportFormatCSV},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("some error"))
+			},
+			check: func(t *testing.T, output *bytes.Buffer, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:    "Repository fails during an XLSX export",
+			request: model.ExportProductsRequest{Format: model.ExportFormatXLSX},
+			buildStubs: func(repository *mockdb.MockStore) {
+				repository.EXPECT().
+					ExportProducts(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ExportProductsParams, fn func(db.Product) error) error {
+						if err := fn(products[0]); err != nil {
+							return err
+						}
+						return errors.New("some error")
+					})
+			},
+			check: func(t *testing.T, output *bytes.Buffer, err error) {
+				require.Error(t, err)
+				require.Zero(t, output.Len())
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			test := NewTest(t)
+			tC.buildStubs(test.repository)
+
+			var output bytes.Buffer
+			err := test.service.ExportProducts(context.Background(), tC.request, &output)
+
+			tC.check(t, &output, err)
+		})
+	}
+}
+
 func RandomProduct() db.Product {
 	return db.Product{
 		ID:          utils.RandomProductID(),
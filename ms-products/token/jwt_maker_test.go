@@ -0,0 +1,95 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTMaker(t *testing.T) {
+	maker, err := NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	subject := utils.RandomString(10, utils.DefaultAlphabet)
+	role := "merchandiser"
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(subject, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload2, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload2)
+
+	require.Equal(t, payload.ID, payload2.ID)
+	require.Equal(t, subject, payload2.Subject)
+	require.Equal(t, role, payload2.Role)
+	require.WithinDuration(t, issuedAt, payload2.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload2.ExpiredAt, time.Second)
+}
+
+func TestExpiredJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomString(10, utils.DefaultAlphabet), "admin", -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestTamperedJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomString(10, utils.DefaultAlphabet), "admin", time.Minute)
+	require.NoError(t, err)
+
+	otherMaker, err := NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	payload, err := otherMaker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	payload, err = maker.VerifyToken(token[:len(token)-2] + "xx")
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenAlgNone(t *testing.T) {
+	claims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   utils.RandomString(10, utils.DefaultAlphabet),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	maker, err := NewJWTMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestJWTMakerInvalidKey(t *testing.T) {
+	maker, err := NewJWTMaker(utils.RandomString(31, utils.DefaultAlphabet))
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
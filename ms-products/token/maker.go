@@ -0,0 +1,27 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TypeJWT    = "jwt"
+	TypePaseto = "paseto"
+)
+
+type Maker interface {
+	CreateToken(subject string, role string, duration time.Duration) (string, *Payload, error)
+	VerifyToken(token string) (*Payload, error)
+}
+
+func NewMaker(tokenType string, symmetricKey string) (Maker, error) {
+	switch tokenType {
+	case TypeJWT:
+		return NewJWTMaker(symmetricKey)
+	case TypePaseto:
+		return NewPasetoMaker(symmetricKey)
+	}
+
+	return nil, fmt.Errorf("unknown token type %q", tokenType)
+}
@@ -0,0 +1,79 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djudju12/ms-products/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	subject := utils.RandomString(10, utils.DefaultAlphabet)
+	role := "merchandiser"
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(subject, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload2, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload2)
+
+	require.Equal(t, payload.ID, payload2.ID)
+	require.Equal(t, subject, payload2.Subject)
+	require.Equal(t, role, payload2.Role)
+	require.WithinDuration(t, issuedAt, payload2.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload2.ExpiredAt, time.Second)
+}
+
+func TestExpiredPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomString(10, utils.DefaultAlphabet), "admin", -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestTamperedPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(utils.RandomString(10, utils.DefaultAlphabet), "admin", time.Minute)
+	require.NoError(t, err)
+
+	otherMaker, err := NewPasetoMaker(utils.RandomString(32, utils.DefaultAlphabet))
+	require.NoError(t, err)
+
+	payload, err := otherMaker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestNewMaker(t *testing.T) {
+	key := utils.RandomString(32, utils.DefaultAlphabet)
+
+	maker, err := NewMaker(TypeJWT, key)
+	require.NoError(t, err)
+	require.IsType(t, &JWTMaker{}, maker)
+
+	maker, err = NewMaker(TypePaseto, key)
+	require.NoError(t, err)
+	require.IsType(t, &PasetoMaker{}, maker)
+
+	maker, err = NewMaker("saml", key)
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	for _, exporter := range []string{ExporterNone, ExporterStdout, ExporterOTLP} {
+		t.Run(exporter, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), exporter, "localhost:4318", 1)
+			require.NoError(t, err)
+			require.NoError(t, shutdown(context.Background()))
+			require.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+		})
+	}
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), "jaeger", "", 1)
+	require.Error(t, err)
+}